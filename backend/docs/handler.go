@@ -0,0 +1,33 @@
+package docs
+
+import (
+	_ "embed"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync"
+)
+
+//go:embed index.html
+var indexHTML []byte
+
+// Спецификация не меняется за время работы процесса — собираем один раз.
+var specJSON = sync.OnceValues(func() ([]byte, error) {
+	return json.Marshal(Build())
+})
+
+// ServeSpec — GET /api/openapi.json
+func ServeSpec(c *gin.Context) {
+	body, err := specJSON()
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// ServeUI — GET /api/docs, Swagger UI по спецификации выше. Скрипты и стили
+// страница берёт с unpkg.com.
+func ServeUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", indexHTML)
+}
@@ -0,0 +1,225 @@
+// Package docs собирает спецификацию OpenAPI 3 по таблице операций
+// (operations.go) и Go-типам запросов и ответов и отдаёт её вместе
+// со страницей Swagger UI.
+package docs
+
+import (
+	"net/http"
+	"online_library/backend/internal/middleware"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const openAPIVersion = "3.0.3"
+
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Tags       []Tag                            `json:"tags"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Tags        []string              `json:"tags"`
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	Responses       map[string]*Response       `json:"responses"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Схемы авторизации
+const (
+	securityBearer     = "bearerAuth"
+	securityKosyncUser = "kosyncUser"
+	securityKosyncKey  = "kosyncKey"
+)
+
+const description = `API онлайн-библиотеки.
+
+Ошибки возвращаются в формате RFC 7807 (application/problem+json): поле code —
+машиночитаемый код, errors — ошибки по полям запроса. Язык сообщений выбирается
+по заголовку Accept-Language (en, ru).
+
+Актуальная версия — /api/v2: изменения через PATCH и PUT, удаление через DELETE
+с ответом 204, POST — создание (201) и действия. Карточки книг и категории
+отдаются с ETag и поддерживают If-None-Match (304). Маршруты /api/ без версии —
+v1: они работают по-прежнему, но устарели и отвечают с заголовками Deprecation
+и Link.
+
+Протокол /kosync — совместимый с KOReader, у него свой формат ошибок.`
+
+var ginParam = regexp.MustCompile(`[:*](\w+)`)
+
+// OpenAPIPath переводит путь gin в шаблон OpenAPI: /books/:book_id → /books/{book_id}.
+func OpenAPIPath(ginPath string) string {
+	return ginParam.ReplaceAllString(ginPath, "{$1}")
+}
+
+// Build собирает спецификацию по таблице operations.
+func Build() *Document {
+	reg := newSchemaRegistry()
+	doc := &Document{
+		OpenAPI: openAPIVersion,
+		Info:    Info{Title: "Online Library API", Version: "2.0", Description: description},
+		Paths:   map[string]map[string]*Operation{},
+		Components: Components{
+			Responses: map[string]*Response{
+				"Problem": {
+					Description: "Ошибка в формате RFC 7807",
+					Content: map[string]MediaType{
+						middleware.ProblemContentType: {Schema: reg.schemaOf(middleware.Problem{})},
+					},
+				},
+				"KosyncError": {
+					Description: "Ошибка протокола KOReader",
+					Content: map[string]MediaType{
+						"application/json": {Schema: reg.schemaOf(kosyncError)},
+					},
+				},
+			},
+			SecuritySchemes: map[string]*SecurityScheme{
+				securityBearer:     {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "Токен из POST /api/auth/login"},
+				securityKosyncUser: {Type: "apiKey", In: "header", Name: "x-auth-user", Description: "Email пользователя"},
+				securityKosyncKey:  {Type: "apiKey", In: "header", Name: "x-auth-key", Description: "MD5 пароля синхронизации"},
+			},
+		},
+	}
+
+	seenTags := map[string]bool{}
+	for _, op := range allOperations() {
+		if !seenTags[op.tag] {
+			seenTags[op.tag] = true
+			doc.Tags = append(doc.Tags, Tag{Name: op.tag})
+		}
+		p := OpenAPIPath(op.path)
+		if doc.Paths[p] == nil {
+			doc.Paths[p] = map[string]*Operation{}
+		}
+		doc.Paths[p][strings.ToLower(op.method)] = op.build(reg)
+	}
+
+	doc.Components.Schemas = reg.schemas
+	return doc
+}
+
+func (op operation) build(reg *schemaRegistry) *Operation {
+	o := &Operation{
+		OperationID: op.id,
+		Tags:        []string{op.tag},
+		Summary:     op.summary,
+		Description: op.access.description(),
+		Responses:   map[string]*Response{},
+		Deprecated:  op.deprecated,
+	}
+
+	for _, name := range ginParam.FindAllStringSubmatch(op.path, -1) {
+		o.Parameters = append(o.Parameters, Parameter{
+			Name: name[1], In: "path", Required: true, Schema: pathParamSchema(name[1]),
+		})
+	}
+	for _, q := range op.query {
+		o.Parameters = append(o.Parameters, Parameter{
+			Name: q.name, In: "query", Description: q.description, Required: q.required, Schema: &Schema{Type: q.typ},
+		})
+	}
+	for _, h := range op.headers {
+		o.Parameters = append(o.Parameters, Parameter{
+			Name: h.name, In: "header", Description: h.description, Required: h.required, Schema: &Schema{Type: h.typ},
+		})
+	}
+
+	if op.body != nil {
+		o.RequestBody = &RequestBody{
+			Required: !op.optionalBody,
+			Content:  map[string]MediaType{"application/json": {Schema: reg.schemaOf(op.body)}},
+		}
+	}
+
+	contentType := op.contentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	resp := &Response{Description: http.StatusText(op.status)}
+	if op.response != nil {
+		resp.Content = map[string]MediaType{contentType: {Schema: reg.schemaOf(op.response)}}
+	}
+	o.Responses[strconv.Itoa(op.status)] = resp
+	if op.conditional {
+		o.Parameters = append(o.Parameters, Parameter{
+			Name: ifNoneMatch.name, In: "header", Description: ifNoneMatch.description, Schema: &Schema{Type: ifNoneMatch.typ},
+		})
+		o.Responses[strconv.Itoa(http.StatusNotModified)] = &Response{Description: "ETag совпал с If-None-Match"}
+	}
+
+	if op.access == kosync {
+		o.Responses["default"] = &Response{Ref: "#/components/responses/KosyncError"}
+	} else {
+		o.Responses["default"] = &Response{Ref: "#/components/responses/Problem"}
+	}
+	o.Security = op.access.security()
+	return o
+}
+
+// Идентификаторы в путях — целые числа, кроме перечисленных.
+var stringPathParams = map[string]bool{"document": true, "title": true}
+
+func pathParamSchema(name string) *Schema {
+	if stringPathParams[name] {
+		return &Schema{Type: "string"}
+	}
+	return &Schema{Type: "integer", Minimum: float(1)}
+}
@@ -0,0 +1,486 @@
+package docs
+
+import (
+	"net/http"
+	"online_library/backend/internal/handlers"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/service"
+)
+
+// access — кто может вызвать операцию.
+type access int
+
+const (
+	public access = iota
+	user
+	owner // владелец ресурса или администратор
+	admin
+	superadmin
+	kosync // заголовки x-auth-user и x-auth-key протокола KOReader
+)
+
+func (a access) description() string {
+	switch a {
+	case owner:
+		return "Доступно владельцу и администраторам."
+	case admin:
+		return "Только для администраторов."
+	case superadmin:
+		return "Только для суперадминистратора."
+	}
+	return ""
+}
+
+func (a access) security() []map[string][]string {
+	switch a {
+	case public:
+		return nil
+	case kosync:
+		return []map[string][]string{{securityKosyncUser: {}, securityKosyncKey: {}}}
+	}
+	return []map[string][]string{{securityBearer: {}}}
+}
+
+type param struct {
+	name        string
+	typ         string
+	description string
+	required    bool
+}
+
+// operation — строка таблицы: маршрут из routes.go, его тело запроса
+// и ответ при успехе. Ошибки у всех операций описаны общим ответом Problem.
+type operation struct {
+	method       string
+	path         string // как в gin: /api/books/:book_id
+	id           string // operationId для генераторов клиентов
+	tag          string
+	summary      string
+	access       access
+	query        []param
+	headers      []param
+	body         interface{} // значение типа тела запроса; nil — без тела
+	optionalBody bool
+	status       int
+	response     interface{} // значение типа ответа; nil — без тела
+	contentType  string      // по умолчанию application/json
+	conditional  bool        // ответ с ETag, поддерживается If-None-Match → 304
+	deprecated   bool
+}
+
+// Ответы, которые обработчики собирают из gin.H
+var (
+	createdID = struct {
+		ID int `json:"id"`
+	}{}
+	messageBody = struct {
+		Message string `json:"message"`
+	}{}
+	tokenBody = struct {
+		Token string `json:"token"`
+	}{}
+	statusBody = struct {
+		Status string `json:"status"`
+	}{}
+	kosyncState = struct {
+		State string `json:"state"`
+	}{}
+	kosyncAuth = struct {
+		Authorized string `json:"authorized"`
+	}{}
+	kosyncError = struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}{}
+	mergedTags = struct {
+		BooksAffected int `json:"books_affected"`
+	}{}
+	authorsPage = struct {
+		Data  []models.Author `json:"data"`
+		Count int             `json:"count"`
+	}{}
+	shelfBooksPage = struct {
+		Data  []models.ShelfEntry `json:"data"`
+		Count int                 `json:"count"`
+	}{}
+	kosyncSaved = struct {
+		Document  string `json:"document"`
+		Timestamp int64  `json:"timestamp"`
+	}{}
+	kosyncProgress = struct {
+		handlers.KosyncProgressRequest
+		Timestamp int64 `json:"timestamp"`
+	}{}
+)
+
+var (
+	paging = []param{
+		{name: "limit", typ: "integer", description: "по умолчанию 10, не больше 100"},
+		{name: "offset", typ: "integer"},
+	}
+	pagingBy20 = []param{
+		{name: "limit", typ: "integer", description: "по умолчанию 20"},
+		{name: "offset", typ: "integer"},
+	}
+	ifMatch = []param{
+		{name: "If-Match", typ: "string", description: `ETag карточки книги, например "3-1f2e3d4c5b6a7988", или только номер версии "3"`, required: true},
+	}
+	ifNoneMatch = param{name: "If-None-Match", typ: "string", description: "ETag из прошлого ответа; если он не изменился — 304 без тела"}
+)
+
+// Теги операций — разделы документации.
+const (
+	tagService       = "Служебные"
+	tagAuth          = "Аутентификация"
+	tagCategories    = "Категории"
+	tagBooks         = "Книги"
+	tagBookEdits     = "Правки книг"
+	tagReviews       = "Рецензии"
+	tagAuthors       = "Авторы"
+	tagComments      = "Комментарии"
+	tagLoans         = "Выдачи"
+	tagNotifications = "Уведомления"
+	tagShelves       = "Полки"
+	tagProgress      = "Позиция чтения"
+	tagAnnotations   = "Пометки"
+	tagKosync        = "KOReader"
+	tagAudit         = "Аудит"
+	tagUsers         = "Пользователи"
+	tagTags          = "Теги"
+)
+
+// operations — все маршруты SetupRoutes, кроме /api/v2 (они в v2.go). Новый
+// маршрут без строки здесь не пройдёт тест в routes.
+var operations = []operation{
+	// Служебные
+	{method: http.MethodGet, path: "/healthz", id: "liveness", tag: tagService, summary: "Проверка, что процесс жив",
+		status: http.StatusOK, response: statusBody},
+	{method: http.MethodGet, path: "/readyz", id: "readiness", tag: tagService, summary: "Готовность принимать запросы (503, если нет)",
+		status: http.StatusOK, response: service.Readiness{}},
+	{method: http.MethodGet, path: "/metrics", id: "metrics", tag: tagService, summary: "Метрики Prometheus",
+		status: http.StatusOK, response: "", contentType: "text/plain"},
+	{method: http.MethodGet, path: "/api/openapi.json", id: "openAPISpec", tag: tagService, summary: "Эта спецификация",
+		status: http.StatusOK, response: map[string]interface{}{}},
+	{method: http.MethodGet, path: "/api/docs", id: "apiDocs", tag: tagService, summary: "Страница Swagger UI",
+		status: http.StatusOK, response: "", contentType: "text/html"},
+
+	// Категории
+	{method: http.MethodGet, path: "/api/categories", id: "getAllCategories", tag: tagCategories, summary: "Дерево категорий",
+		access: user, conditional: true, status: http.StatusOK, response: []map[string]interface{}{}},
+	{method: http.MethodGet, path: "/api/categories/root", id: "getRootCategories", tag: tagCategories, summary: "Корневые категории",
+		access: user, conditional: true, status: http.StatusOK, response: []models.Category{}},
+	{method: http.MethodGet, path: "/api/categories/:id", id: "getCategoryByID", tag: tagCategories, summary: "Категория",
+		access: user, conditional: true, status: http.StatusOK, response: models.Category{}},
+	{method: http.MethodGet, path: "/api/categories/:id/children", id: "getCategoryChildren", tag: tagCategories, summary: "Подкатегории",
+		access: user, conditional: true, status: http.StatusOK, response: []models.Category{}},
+	{method: http.MethodGet, path: "/api/categories/:id/books", id: "getBooksInCategory", tag: tagCategories, summary: "Книги категории и её подкатегорий",
+		access: user, status: http.StatusOK, response: []models.Book{}},
+	{method: http.MethodPost, path: "/api/categories", id: "createCategory", tag: tagCategories, summary: "Создать категорию",
+		access: admin, body: models.CategoryInput{}, status: http.StatusCreated, response: createdID},
+	{method: http.MethodPost, path: "/api/categories/:id", id: "updateCategory", tag: tagCategories, summary: "Обновить категорию",
+		access: admin, body: models.CategoryInput{}, status: http.StatusOK, response: messageBody},
+	{method: http.MethodPost, path: "/api/categories/:id/delete", id: "deleteCategory", tag: tagCategories, summary: "Удалить категорию",
+		access: admin, status: http.StatusOK, response: messageBody},
+
+	// Книги
+	{method: http.MethodGet, path: "/api/books", id: "searchBooks", tag: tagBooks, summary: "Поиск книг",
+		access: user, query: append([]param{
+			{name: "q", typ: "string", description: "поиск по названию и тегам"},
+			{name: "sort", typ: "string", description: "newest, rating или relevance"},
+		}, pagingBy20...), status: http.StatusOK, response: []models.Book{}},
+	{method: http.MethodGet, path: "/api/books/:book_id", id: "getBookByID", tag: tagBooks, summary: "Карточка книги, версия — в ETag",
+		access: user, conditional: true, status: http.StatusOK, response: models.Book{}},
+	{method: http.MethodGet, path: "/api/books/author/:author_id", id: "getBooksByAuthor", tag: tagBooks, summary: "Книги автора",
+		access: user, query: append([]param{{name: "role", typ: "string", description: "роль автора в книге"}}, pagingBy20...),
+		status: http.StatusOK, response: []models.Book{}},
+	{method: http.MethodGet, path: "/api/books/tag/:tag_id", id: "getBooksByTag", tag: tagBooks, summary: "Книги с тегом",
+		access: user, query: pagingBy20, status: http.StatusOK, response: []models.Book{}},
+	{method: http.MethodGet, path: "/api/books/duplicates/:title", id: "getDuplicateBooks", tag: tagBooks, summary: "Книги с похожим названием",
+		access: user, status: http.StatusOK, response: []models.Book{}},
+	{method: http.MethodGet, path: "/api/books/mine", id: "getUserBooks", tag: tagBooks, summary: "Мои книги",
+		access: user, status: http.StatusOK, response: []models.Book{}},
+	{method: http.MethodGet, path: "/api/books/favorites", id: "getUserFavoriteBooks", tag: tagBooks, summary: "Избранные книги",
+		access: user, status: http.StatusOK, response: []models.Book{}},
+	{method: http.MethodPost, path: "/api/books/:book_id/favorite/add", id: "addBookToFavorites", tag: tagBooks, summary: "Добавить в избранное",
+		access: user, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/books/:book_id/favorite/remove", id: "removeBookFromFavorites", tag: tagBooks, summary: "Убрать из избранного",
+		access: user, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/books", id: "createBook", tag: tagBooks, summary: "Создать книгу вместе с авторами, тегами и категориями",
+		access: user, body: handlers.CreateBookRequest{}, status: http.StatusCreated, response: createdID},
+	{method: http.MethodPost, path: "/api/books/:book_id", id: "updateBook", tag: tagBooks, summary: "Полностью обновить книгу",
+		access: owner, body: models.BookInput{}, status: http.StatusOK},
+	{method: http.MethodPost, path: "/api/books/:book_id/delete", id: "deleteBook", tag: tagBooks, summary: "Удалить книгу",
+		access: owner, status: http.StatusOK},
+	{method: http.MethodPatch, path: "/api/books/:book_id", id: "patchBook", tag: tagBooks, summary: "Частично обновить книгу",
+		access: user, headers: ifMatch, body: models.BookPatch{}, status: http.StatusOK, response: models.Book{}},
+	{method: http.MethodGet, path: "/api/books/:book_id/history", id: "getBookHistory", tag: tagBooks, summary: "Ревизии книги, новые первыми",
+		access: user, query: paging, status: http.StatusOK, response: []models.BookRevision{}},
+	{method: http.MethodPost, path: "/api/books/:book_id/history/:revision_id/rollback", id: "rollbackBook", tag: tagBooks, summary: "Вернуть книгу к ревизии",
+		access: admin, status: http.StatusOK, response: models.Book{}},
+	{method: http.MethodGet, path: "/api/books/review-queue", id: "getReviewQueue", tag: tagBooks, summary: "Книги на модерации",
+		access: admin, query: paging, status: http.StatusOK, response: []models.ReviewQueueItem{}},
+	{method: http.MethodPost, path: "/api/books/:book_id/status", id: "updateBookStatus", tag: tagBooks, summary: "Сменить статус книги",
+		access: user, body: handlers.StatusUpdateRequest{}, status: http.StatusNoContent},
+	{method: http.MethodGet, path: "/api/books/:book_id/status/history", id: "getStatusHistory", tag: tagBooks, summary: "История статусов",
+		access: user, status: http.StatusOK, response: []models.BookStatusChange{}},
+	{method: http.MethodPost, path: "/api/books/:book_id/authors", id: "setBookAuthors", tag: tagBooks, summary: "Заменить авторов книги",
+		access: owner, body: handlers.AuthorListRequest{}, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/books/:book_id/authors/:author_id", id: "addBookAuthor", tag: tagBooks, summary: "Добавить автора",
+		access: owner, query: []param{
+			{name: "role", typ: "string", description: "по умолчанию author"},
+			{name: "position", typ: "integer"},
+		}, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/books/:book_id/authors/:author_id/remove", id: "removeBookAuthor", tag: tagBooks, summary: "Убрать автора",
+		access: owner, query: []param{{name: "role", typ: "string", description: "пусто — все роли автора"}}, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/books/:book_id/tags", id: "setBookTags", tag: tagBooks, summary: "Заменить теги книги",
+		access: owner, body: handlers.TagListRequest{}, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/books/:book_id/tags/:tag_id", id: "addBookTag", tag: tagBooks, summary: "Добавить тег",
+		access: owner, query: []param{{name: "weight", typ: "integer", description: "1 — основной (по умолчанию), 0 — дополнительный"}},
+		status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/books/:book_id/tags/:tag_id/remove", id: "removeBookTag", tag: tagBooks, summary: "Убрать тег",
+		access: owner, status: http.StatusNoContent},
+
+	// Оценки и файлы книги
+	{method: http.MethodGet, path: "/api/books/:book_id/rating", id: "getRatingSummary", tag: tagBooks, summary: "Средняя оценка и моя оценка",
+		access: user, status: http.StatusOK, response: models.RatingSummary{}},
+	{method: http.MethodPost, path: "/api/books/:book_id/rating", id: "rateBook", tag: tagBooks, summary: "Оценить книгу",
+		access: user, body: handlers.RateBookRequest{}, status: http.StatusOK, response: models.BookRating{}},
+	{method: http.MethodPost, path: "/api/books/:book_id/rating/remove", id: "removeRating", tag: tagBooks, summary: "Убрать оценку",
+		access: user, status: http.StatusNoContent},
+	{method: http.MethodGet, path: "/api/books/:book_id/files", id: "getBookFiles", tag: tagBooks, summary: "Файлы книги",
+		access: user, status: http.StatusOK, response: []models.BookFile{}},
+	{method: http.MethodGet, path: "/api/books/:book_id/files/:file_id/download", id: "downloadFile", tag: tagBooks, summary: "Скачать файл (для книг с копиями — только при активной выдаче)",
+		access: user, status: http.StatusOK, response: "", contentType: "application/octet-stream"},
+
+	// Правки книг
+	{method: http.MethodPost, path: "/api/books/:book_id/edits", id: "proposeEdit", tag: tagBookEdits, summary: "Предложить правку",
+		access: user, body: handlers.BookEditRequest{}, status: http.StatusCreated, response: models.BookEditProposal{}},
+	{method: http.MethodGet, path: "/api/books/:book_id/edits", id: "getBookEdits", tag: tagBookEdits, summary: "Правки книги",
+		access: user, query: append([]param{{name: "status", typ: "string", description: "pending, accepted или rejected"}}, paging...),
+		status: http.StatusOK, response: []models.BookEditProposal{}},
+	{method: http.MethodGet, path: "/api/edits/pending", id: "getPendingEdits", tag: tagBookEdits, summary: "Правки к моим книгам (админу — ко всем)",
+		access: user, query: paging, status: http.StatusOK, response: []models.BookEditProposal{}},
+	{method: http.MethodGet, path: "/api/edits/mine", id: "getMyEdits", tag: tagBookEdits, summary: "Мои предложенные правки",
+		access: user, query: paging, status: http.StatusOK, response: []models.BookEditProposal{}},
+	{method: http.MethodPost, path: "/api/edits/:id/accept", id: "acceptEdit", tag: tagBookEdits, summary: "Принять правку",
+		access: user, body: handlers.EditReviewRequest{}, optionalBody: true, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/edits/:id/reject", id: "rejectEdit", tag: tagBookEdits, summary: "Отклонить правку",
+		access: user, body: handlers.EditReviewRequest{}, optionalBody: true, status: http.StatusNoContent},
+
+	// Выдача
+	{method: http.MethodPost, path: "/api/books/:book_id/copies", id: "setBookCopies", tag: tagLoans, summary: "Число экземпляров (null — открытый доступ)",
+		access: admin, body: handlers.BookCopiesRequest{}, status: http.StatusNoContent},
+	{method: http.MethodGet, path: "/api/books/:book_id/availability", id: "getAvailability", tag: tagLoans, summary: "Доступность книги",
+		access: user, status: http.StatusOK, response: models.BookAvailability{}},
+	{method: http.MethodPost, path: "/api/books/:book_id/checkout", id: "checkout", tag: tagLoans, summary: "Взять книгу",
+		access: user, status: http.StatusCreated, response: models.Loan{}},
+	{method: http.MethodPost, path: "/api/books/:book_id/hold", id: "placeHold", tag: tagLoans, summary: "Встать в очередь",
+		access: user, status: http.StatusCreated, response: models.LoanHold{}},
+	{method: http.MethodPost, path: "/api/books/:book_id/hold/cancel", id: "cancelHold", tag: tagLoans, summary: "Отменить бронь",
+		access: user, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/loans/:id/return", id: "returnLoan", tag: tagLoans, summary: "Вернуть книгу",
+		access: user, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/loans/:id/renew", id: "renewLoan", tag: tagLoans, summary: "Продлить выдачу",
+		access: user, status: http.StatusOK, response: models.Loan{}},
+	{method: http.MethodGet, path: "/api/users/me/loans", id: "getMyLoans", tag: tagLoans, summary: "Мои выдачи и брони",
+		access: user, status: http.StatusOK, response: service.UserLoans{}},
+
+	// Рецензии
+	{method: http.MethodGet, path: "/api/reviews/book/:book_id", id: "getReviewsByBook", tag: tagReviews, summary: "Рецензии на книгу",
+		access: user, query: paging, status: http.StatusOK, response: []models.BookReview{}},
+	{method: http.MethodPost, path: "/api/reviews", id: "saveReview", tag: tagReviews, summary: "Написать или обновить свою рецензию",
+		access: user, body: handlers.ReviewRequest{}, status: http.StatusOK, response: models.BookReview{}},
+	{method: http.MethodPost, path: "/api/reviews/:id/delete", id: "deleteReview", tag: tagReviews, summary: "Удалить рецензию",
+		access: user, status: http.StatusOK},
+	{method: http.MethodPost, path: "/api/reviews/:id/status", id: "setReviewStatus", tag: tagReviews, summary: "Модерация рецензии",
+		access: admin, body: handlers.CommentStatusRequest{}, status: http.StatusOK},
+
+	// Авторы
+	{method: http.MethodGet, path: "/api/authors", id: "listAuthors", tag: tagAuthors, summary: "Авторы; с query — поиск и общее число",
+		access: user, query: []param{
+			{name: "query", typ: "string", description: "поиск по имени и псевдонимам"},
+			{name: "limit", typ: "integer", description: "по умолчанию 10"},
+			{name: "offset", typ: "integer"},
+		}, status: http.StatusOK, response: oneOf{[]models.Author{}, authorsPage}},
+	{method: http.MethodGet, path: "/api/authors/duplicates", id: "listDuplicateAuthors", tag: tagAuthors, summary: "Похожие авторы",
+		access: admin, query: []param{
+			{name: "threshold", typ: "number", description: "порог сходства (0, 1], по умолчанию 0.5"},
+			{name: "limit", typ: "integer", description: "по умолчанию 50"},
+		}, status: http.StatusOK, response: []models.DuplicateAuthors{}},
+	{method: http.MethodGet, path: "/api/authors/merges", id: "listAuthorMerges", tag: tagAuthors, summary: "Журнал объединений",
+		access: admin, query: paging, status: http.StatusOK, response: []models.AuthorMerge{}},
+	{method: http.MethodGet, path: "/api/authors/:id", id: "getAuthorByID", tag: tagAuthors, summary: "Автор с книгами и соавторами",
+		access: user, query: paging, status: http.StatusOK, response: models.AuthorDetails{}},
+	{method: http.MethodPost, path: "/api/authors", id: "createAuthor", tag: tagAuthors, summary: "Создать автора",
+		access: user, body: handlers.AuthorRequest{}, status: http.StatusCreated, response: models.Author{}},
+	{method: http.MethodPost, path: "/api/authors/:id", id: "updateAuthor", tag: tagAuthors, summary: "Обновить автора",
+		access: user, body: handlers.AuthorRequest{}, status: http.StatusOK, response: models.Author{}},
+	{method: http.MethodPost, path: "/api/authors/:id/delete", id: "deleteAuthor", tag: tagAuthors, summary: "Удалить автора без книг",
+		access: admin, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/authors/:id/merge", id: "mergeAuthors", tag: tagAuthors, summary: "Объединить авторов в этого",
+		access: admin, body: handlers.MergeAuthorsRequest{}, status: http.StatusOK, response: []models.AuthorMerge{}},
+	{method: http.MethodPost, path: "/api/authors/:id/aliases", id: "addAuthorAlias", tag: tagAuthors, summary: "Добавить псевдоним",
+		access: user, body: handlers.AliasRequest{}, status: http.StatusCreated, response: models.AuthorAlias{}},
+	{method: http.MethodPost, path: "/api/authors/:id/aliases/:alias_id/remove", id: "removeAuthorAlias", tag: tagAuthors, summary: "Удалить псевдоним",
+		access: admin, status: http.StatusNoContent},
+
+	// Комментарии
+	{method: http.MethodPost, path: "/api/comments", id: "createComment", tag: tagComments, summary: "Написать комментарий",
+		access: user, body: handlers.CreateCommentRequest{}, status: http.StatusCreated, response: models.Comment{}},
+	{method: http.MethodPost, path: "/api/comments/:id", id: "updateComment", tag: tagComments, summary: "Изменить текст",
+		access: owner, body: handlers.UpdateCommentRequest{}, status: http.StatusOK},
+	{method: http.MethodPost, path: "/api/comments/:id/delete", id: "deleteComment", tag: tagComments, summary: "Удалить комментарий",
+		access: owner, status: http.StatusOK},
+	{method: http.MethodGet, path: "/api/comments/book/:book_id", id: "getCommentsByBook", tag: tagComments, summary: "Комментарии к книге",
+		access: user, query: paging, status: http.StatusOK, response: []models.Comment{}},
+	{method: http.MethodGet, path: "/api/comments/user/:user_id", id: "getCommentsByUser", tag: tagComments, summary: "Комментарии пользователя",
+		access: owner, query: paging, status: http.StatusOK, response: []models.Comment{}},
+	{method: http.MethodGet, path: "/api/comments/last", id: "getLastComments", tag: tagComments, summary: "Последние комментарии",
+		access: user, query: paging[:1], status: http.StatusOK, response: []models.Comment{}},
+	{method: http.MethodPost, path: "/api/comments/:id/status", id: "setCommentStatus", tag: tagComments, summary: "Модерация комментария",
+		access: admin, body: handlers.CommentStatusRequest{}, status: http.StatusOK},
+
+	// Уведомления
+	{method: http.MethodGet, path: "/api/users/me/notifications", id: "getMyNotifications", tag: tagNotifications, summary: "Мои уведомления",
+		access: user, query: append([]param{{name: "unread", typ: "boolean", description: "только непрочитанные"}}, paging...),
+		status: http.StatusOK, response: service.NotificationList{}},
+	{method: http.MethodPost, path: "/api/notifications/read-all", id: "markAllNotificationsRead", tag: tagNotifications, summary: "Прочитать все",
+		access: user, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/notifications/:id/read", id: "markNotificationRead", tag: tagNotifications, summary: "Прочитать уведомление",
+		access: user, status: http.StatusNoContent},
+
+	// Полки
+	{method: http.MethodGet, path: "/api/shelves", id: "getMyShelves", tag: tagShelves, summary: "Мои полки",
+		access: user, status: http.StatusOK, response: []models.Shelf{}},
+	{method: http.MethodPost, path: "/api/shelves", id: "createShelf", tag: tagShelves, summary: "Создать полку",
+		access: user, body: handlers.ShelfRequest{}, status: http.StatusCreated, response: models.Shelf{}},
+	{method: http.MethodPost, path: "/api/shelves/:id", id: "renameShelf", tag: tagShelves, summary: "Переименовать полку",
+		access: user, body: handlers.ShelfRequest{}, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/shelves/:id/delete", id: "deleteShelf", tag: tagShelves, summary: "Удалить полку",
+		access: user, status: http.StatusNoContent},
+	{method: http.MethodGet, path: "/api/shelves/:id/books", id: "getShelfBooks", tag: tagShelves, summary: "Книги на полке",
+		access: user, query: pagingBy20, status: http.StatusOK, response: shelfBooksPage},
+	{method: http.MethodPost, path: "/api/shelves/:id/books/:book_id", id: "addBookToShelf", tag: tagShelves, summary: "Поставить книгу на полку",
+		access: user, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/shelves/:id/books/:book_id/remove", id: "removeBookFromShelf", tag: tagShelves, summary: "Снять книгу с полки",
+		access: user, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/shelves/:id/books/:book_id/move", id: "moveBook", tag: tagShelves, summary: "Переставить книгу на другую полку",
+		access: user, body: handlers.MoveBookRequest{}, status: http.StatusNoContent},
+
+	// Позиция чтения
+	{method: http.MethodGet, path: "/api/progress", id: "getMyProgress", tag: tagProgress, summary: "Что я читаю",
+		access: user, query: pagingBy20, status: http.StatusOK, response: []models.ReadingProgress{}},
+	{method: http.MethodGet, path: "/api/progress/files/:file_id", id: "getFileProgress", tag: tagProgress, summary: "Позиция в файле (204 — ещё не читал)",
+		access: user, status: http.StatusOK, response: models.ReadingProgress{}},
+	{method: http.MethodPost, path: "/api/progress/files/:file_id", id: "saveFileProgress", tag: tagProgress, summary: "Сохранить позицию",
+		access: user, body: service.ProgressInput{}, status: http.StatusOK, response: models.ReadingProgress{}},
+	{method: http.MethodGet, path: "/api/progress/files/:file_id/history", id: "getFileProgressHistory", tag: tagProgress, summary: "История позиций по устройствам",
+		access: user, query: pagingBy20, status: http.StatusOK, response: []models.ReadingProgress{}},
+	{method: http.MethodPost, path: "/api/progress/sync-password", id: "setSyncPassword", tag: tagProgress, summary: "Пароль синхронизации KOReader",
+		access: user, body: handlers.SyncPasswordRequest{}, status: http.StatusNoContent},
+
+	// Пометки
+	{method: http.MethodGet, path: "/api/annotations/book/:book_id", id: "getMyAnnotations", tag: tagAnnotations, summary: "Мои пометки в книге",
+		access: user, status: http.StatusOK, response: []models.Annotation{}},
+	{method: http.MethodGet, path: "/api/annotations/book/:book_id/public", id: "getPublicAnnotations", tag: tagAnnotations, summary: "Публичные пометки",
+		access: user, query: paging, status: http.StatusOK, response: []models.Annotation{}},
+	{method: http.MethodGet, path: "/api/annotations/book/:book_id/export", id: "exportAnnotations", tag: tagAnnotations, summary: "Экспорт моих пометок",
+		access: user, query: []param{{name: "format", typ: "string", description: "json (по умолчанию) или markdown"}},
+		status: http.StatusOK, response: service.AnnotationExport{}},
+	{method: http.MethodPost, path: "/api/annotations", id: "createAnnotation", tag: tagAnnotations, summary: "Создать пометку",
+		access: user, body: handlers.AnnotationCreateRequest{}, status: http.StatusCreated, response: models.Annotation{}},
+	{method: http.MethodPost, path: "/api/annotations/:id", id: "updateAnnotation", tag: tagAnnotations, summary: "Изменить пометку",
+		access: user, body: handlers.AnnotationUpdateRequest{}, status: http.StatusOK, response: models.Annotation{}},
+	{method: http.MethodPost, path: "/api/annotations/:id/delete", id: "deleteAnnotation", tag: tagAnnotations, summary: "Удалить пометку",
+		access: user, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/annotations/:id/status", id: "setAnnotationStatus", tag: tagAnnotations, summary: "Модерация публичной пометки",
+		access: admin, body: handlers.CommentStatusRequest{}, status: http.StatusOK},
+
+	// KOReader
+	{method: http.MethodGet, path: "/kosync/healthcheck", id: "kosyncHealthcheck", tag: tagKosync, summary: "Проверка сервера",
+		status: http.StatusOK, response: kosyncState},
+	{method: http.MethodPost, path: "/kosync/users/create", id: "kosyncCreateUser", tag: tagKosync, summary: "Регистрация отключена, всегда 402",
+		status: http.StatusPaymentRequired, response: kosyncError},
+	{method: http.MethodGet, path: "/kosync/users/auth", id: "kosyncAuthUser", tag: tagKosync, summary: "Проверка учётных данных",
+		access: kosync, status: http.StatusOK, response: kosyncAuth},
+	{method: http.MethodPut, path: "/kosync/syncs/progress", id: "kosyncUpdateProgress", tag: tagKosync, summary: "Сохранить позицию в документе",
+		access: kosync, body: handlers.KosyncProgressRequest{}, status: http.StatusOK, response: kosyncSaved},
+	{method: http.MethodGet, path: "/kosync/syncs/progress/:document", id: "kosyncGetProgress", tag: tagKosync, summary: "Позиция в документе ({} — нет)",
+		access: kosync, status: http.StatusOK, response: kosyncProgress},
+
+	// Аудит
+	{method: http.MethodGet, path: "/api/audit", id: "listAudit", tag: tagAudit, summary: "Журнал аудита",
+		access: superadmin, query: append([]param{
+			{name: "actor_id", typ: "integer"},
+			{name: "action", typ: "string"},
+			{name: "target_type", typ: "string"},
+			{name: "target_id", typ: "integer"},
+			{name: "request_id", typ: "string"},
+			{name: "from", typ: "string", description: "RFC 3339"},
+			{name: "to", typ: "string", description: "RFC 3339"},
+		}, paging...), status: http.StatusOK, response: []models.AuditEntry{}},
+
+	// Пользователи
+	{method: http.MethodGet, path: "/api/users", id: "getUsers", tag: tagUsers, summary: "Пользователи",
+		access: admin, status: http.StatusOK, response: []models.User{}},
+	{method: http.MethodPost, path: "/api/users", id: "createUser", tag: tagUsers, summary: "Создать пользователя",
+		access: admin, body: models.UserInput{}, status: http.StatusCreated, response: models.User{}},
+	{method: http.MethodPut, path: "/api/users/:id", id: "updateUser", tag: tagUsers, summary: "Обновить профиль",
+		access: owner, body: models.UserInput{}, status: http.StatusOK, response: models.User{}},
+	{method: http.MethodPut, path: "/api/users/:id/admin", id: "adminUpdateUser", tag: tagUsers, summary: "Изменить пользователя и роль",
+		access: admin, body: models.AdminUserUpdateInput{}, status: http.StatusOK, response: models.User{}},
+	{method: http.MethodPost, path: "/api/users/:id/delete", id: "softDeleteUser", tag: tagUsers, summary: "Деактивировать пользователя",
+		access: admin, status: http.StatusOK, response: messageBody},
+	{method: http.MethodPost, path: "/api/users/:id/harddelete", id: "hardDeleteUser", tag: tagUsers, summary: "Удалить пользователя навсегда",
+		access: superadmin, status: http.StatusOK, response: messageBody},
+
+	// Аутентификация
+	{method: http.MethodPost, path: "/api/auth/login", id: "login", tag: tagAuth, summary: "Вход, JWT в ответе",
+		body: handlers.LoginRequest{}, status: http.StatusOK, response: tokenBody},
+	{method: http.MethodPost, path: "/api/auth/register", id: "register", tag: tagAuth, summary: "Регистрация",
+		body: handlers.RegisterRequest{}, status: http.StatusCreated, response: messageBody},
+
+	// Теги
+	{method: http.MethodGet, path: "/api/tags", id: "searchTags", tag: tagTags, summary: "Поиск тегов с учётом синонимов",
+		access: user, query: append([]param{{name: "query", typ: "string"}}, paging...), status: http.StatusOK, response: []models.TagUsage{}},
+	{method: http.MethodGet, path: "/api/tags/cloud", id: "getTagCloud", tag: tagTags, summary: "Облако тегов",
+		access: user, query: []param{
+			{name: "category_id", typ: "integer"},
+			{name: "limit", typ: "integer", description: "по умолчанию 50"},
+		}, status: http.StatusOK, response: []models.TagCloudEntry{}},
+	{method: http.MethodGet, path: "/api/tags/proposed", id: "getProposedTags", tag: tagTags, summary: "Теги на модерации",
+		access: admin, query: paging, status: http.StatusOK, response: []models.Tag{}},
+	{method: http.MethodGet, path: "/api/tags/blocklist", id: "getTagBlocklist", tag: tagTags, summary: "Запрещённые названия",
+		access: admin, status: http.StatusOK, response: []models.TagBlock{}},
+	{method: http.MethodPost, path: "/api/tags/blocklist", id: "addToTagBlocklist", tag: tagTags, summary: "Запретить название",
+		access: admin, body: handlers.TagBlockRequest{}, status: http.StatusCreated, response: models.TagBlock{}},
+	{method: http.MethodPost, path: "/api/tags/blocklist/:id/remove", id: "removeFromTagBlocklist", tag: tagTags, summary: "Снять запрет",
+		access: admin, status: http.StatusNoContent},
+	{method: http.MethodGet, path: "/api/tags/:id", id: "getTagByID", tag: tagTags, summary: "Тег с синонимами",
+		access: user, status: http.StatusOK, response: models.Tag{}},
+	{method: http.MethodPost, path: "/api/tags", id: "createTag", tag: tagTags, summary: "Создать или предложить тег (200 — уже есть)",
+		access: user, body: handlers.TagRequest{}, status: http.StatusCreated, response: models.Tag{}},
+	{method: http.MethodPut, path: "/api/tags/:id", id: "updateTag", tag: tagTags, summary: "Обновить тег",
+		access: admin, body: handlers.TagRequest{}, status: http.StatusOK, response: models.Tag{}},
+	{method: http.MethodPost, path: "/api/tags/:id/delete", id: "deleteTag", tag: tagTags, summary: "Удалить тег",
+		access: admin, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/tags/:id/approve", id: "approveTag", tag: tagTags, summary: "Одобрить тег",
+		access: admin, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/tags/:id/reject", id: "rejectTag", tag: tagTags, summary: "Отклонить тег",
+		access: admin, body: handlers.TagRejectRequest{}, optionalBody: true, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/api/tags/:id/merge", id: "mergeTags", tag: tagTags, summary: "Объединить теги в этот",
+		access: admin, body: handlers.MergeTagsRequest{}, status: http.StatusOK, response: mergedTags},
+	{method: http.MethodPost, path: "/api/tags/:id/synonyms", id: "addTagSynonym", tag: tagTags, summary: "Добавить синоним",
+		access: admin, body: handlers.SynonymRequest{}, status: http.StatusCreated, response: models.TagSynonym{}},
+	{method: http.MethodPost, path: "/api/tags/:id/synonyms/:synonym_id/remove", id: "removeTagSynonym", tag: tagTags, summary: "Удалить синоним",
+		access: admin, status: http.StatusNoContent},
+	{method: http.MethodGet, path: "/api/tags/book/:bookID", id: "getTagsByBookID", tag: tagTags, summary: "Теги книги",
+		access: user, status: http.StatusOK, response: []models.Tag{}},
+	{method: http.MethodPost, path: "/api/tags/assign", id: "assignTagToBook", tag: tagTags, summary: "Назначить тег книге",
+		access: owner, body: handlers.BookTagRequest{}, status: http.StatusOK},
+	{method: http.MethodPost, path: "/api/tags/remove", id: "removeTagFromBook", tag: tagTags, summary: "Снять тег с книги",
+		access: owner, query: []param{
+			{name: "book_id", typ: "integer", required: true},
+			{name: "tag_id", typ: "integer", required: true},
+		}, status: http.StatusOK},
+}
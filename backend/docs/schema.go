@@ -0,0 +1,225 @@
+package docs
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema — объект схемы OpenAPI 3.0 (подмножество, которое нам нужно).
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaRegistry строит схемы по Go-типам: именованные структуры попадают
+// в components/schemas и подставляются ссылкой.
+type schemaRegistry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{schemas: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// oneOf — ответ, форма которого зависит от параметров запроса.
+type oneOf []interface{}
+
+// schemaOf — схема значения v; nil — тела нет.
+func (r *schemaRegistry) schemaOf(v interface{}) *Schema {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case oneOf:
+		s := &Schema{}
+		for _, variant := range v {
+			s.OneOf = append(s.OneOf, r.schemaOf(variant))
+		}
+		return s
+	}
+	return r.schema(reflect.TypeOf(v))
+}
+
+func (r *schemaRegistry) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawType:
+		return &Schema{Description: "произвольный JSON"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: r.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + r.register(t)}
+	}
+	// interface{} — любое значение
+	return &Schema{}
+}
+
+// register добавляет именованную структуру в components/schemas. При
+// совпадении имён из разных пакетов к имени добавляется пакет.
+func (r *schemaRegistry) register(t reflect.Type) string {
+	if name, ok := r.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := r.schemas[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	r.names[t] = name
+	r.schemas[name] = nil // против бесконечной рекурсии на ссылающихся на себя типах
+	r.schemas[name] = r.object(t)
+	return name
+}
+
+// object — схема структуры по правилам encoding/json: встроенные структуры
+// без имени в json раскрываются, поля с "-" и неэкспортируемые пропускаются.
+func (r *schemaRegistry) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	r.addFields(s, t)
+	return s
+}
+
+func (r *schemaRegistry) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				r.addFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := r.schema(f.Type)
+		if required := applyBinding(prop, f.Tag.Get("binding")); required {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// applyBinding переносит правила binding в ограничения схемы: required —
+// в список обязательных полей, правила после dive — на элементы массива.
+func applyBinding(s *Schema, tag string) (required bool) {
+	if tag == "" {
+		return false
+	}
+	target := s
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = target == s
+		case "dive":
+			if target.Items != nil {
+				target = target.Items
+			}
+		case "email":
+			target.Format = "email"
+		case "url":
+			target.Format = "uri"
+		case "color":
+			target.Pattern = "^#[0-9A-Fa-f]{6}$"
+		case "year":
+			target.Minimum = float(1)
+			target.Description = "год издания, не позже следующего года"
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				if target.Type == "integer" {
+					n, _ := strconv.Atoi(v)
+					target.Enum = append(target.Enum, n)
+				} else {
+					target.Enum = append(target.Enum, v)
+				}
+			}
+		case "min", "max":
+			applyLimit(target, name, param)
+		}
+	}
+	return required
+}
+
+func applyLimit(s *Schema, rule, param string) {
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return
+	}
+	switch {
+	case s.Type == "string" && rule == "min":
+		s.MinLength = &n
+	case s.Type == "string":
+		s.MaxLength = &n
+	case s.Type == "array" && rule == "min":
+		s.MinItems = &n
+	case s.Type == "array":
+		s.MaxItems = &n
+	case rule == "min":
+		s.Minimum = float(n)
+	default:
+		s.Maximum = float(n)
+	}
+}
+
+func float(n int) *float64 {
+	f := float64(n)
+	return &f
+}
@@ -0,0 +1,197 @@
+package docs
+
+import (
+	"net/http"
+	"online_library/backend/internal/models"
+	"strings"
+)
+
+// v2Route — маршрут /api/v2 и операция v1, которую он повторяет: тело,
+// параметры и ответ берутся оттуда, меняются метод, путь и код без тела.
+type v2Route struct {
+	method string
+	path   string
+	from   string // operationId в v1
+}
+
+var v2Routes = []v2Route{
+	// Категории
+	{http.MethodGet, "/api/v2/categories", "getAllCategories"},
+	{http.MethodGet, "/api/v2/categories/root", "getRootCategories"},
+	{http.MethodGet, "/api/v2/categories/:id", "getCategoryByID"},
+	{http.MethodGet, "/api/v2/categories/:id/children", "getCategoryChildren"},
+	{http.MethodGet, "/api/v2/categories/:id/books", "getBooksInCategory"},
+	{http.MethodPost, "/api/v2/categories", "createCategory"},
+	{http.MethodPut, "/api/v2/categories/:id", "updateCategory"},
+	{http.MethodDelete, "/api/v2/categories/:id", "deleteCategory"},
+
+	// Книги
+	{http.MethodGet, "/api/v2/books", "searchBooks"},
+	{http.MethodGet, "/api/v2/books/:book_id", "getBookByID"},
+	{http.MethodGet, "/api/v2/books/author/:author_id", "getBooksByAuthor"},
+	{http.MethodGet, "/api/v2/books/tag/:tag_id", "getBooksByTag"},
+	{http.MethodGet, "/api/v2/books/duplicates/:title", "getDuplicateBooks"},
+	{http.MethodGet, "/api/v2/books/mine", "getUserBooks"},
+	{http.MethodGet, "/api/v2/books/favorites", "getUserFavoriteBooks"},
+	{http.MethodGet, "/api/v2/books/review-queue", "getReviewQueue"},
+	{http.MethodPost, "/api/v2/books", "createBook"},
+	{http.MethodPut, "/api/v2/books/:book_id", "updateBook"},
+	{http.MethodPatch, "/api/v2/books/:book_id", "patchBook"},
+	{http.MethodDelete, "/api/v2/books/:book_id", "deleteBook"},
+	{http.MethodPost, "/api/v2/books/:book_id/favorite", "addBookToFavorites"},
+	{http.MethodDelete, "/api/v2/books/:book_id/favorite", "removeBookFromFavorites"},
+	{http.MethodGet, "/api/v2/books/:book_id/history", "getBookHistory"},
+	{http.MethodPost, "/api/v2/books/:book_id/history/:revision_id/rollback", "rollbackBook"},
+	{http.MethodPost, "/api/v2/books/:book_id/status", "updateBookStatus"},
+	{http.MethodGet, "/api/v2/books/:book_id/status/history", "getStatusHistory"},
+	{http.MethodPost, "/api/v2/books/:book_id/edits", "proposeEdit"},
+	{http.MethodGet, "/api/v2/books/:book_id/edits", "getBookEdits"},
+	{http.MethodPut, "/api/v2/books/:book_id/authors", "setBookAuthors"},
+	{http.MethodPut, "/api/v2/books/:book_id/authors/:author_id", "addBookAuthor"},
+	{http.MethodDelete, "/api/v2/books/:book_id/authors/:author_id", "removeBookAuthor"},
+	{http.MethodPut, "/api/v2/books/:book_id/tags", "setBookTags"},
+	{http.MethodPut, "/api/v2/books/:book_id/tags/:tag_id", "addBookTag"},
+	{http.MethodDelete, "/api/v2/books/:book_id/tags/:tag_id", "removeBookTag"},
+	{http.MethodGet, "/api/v2/books/:book_id/rating", "getRatingSummary"},
+	{http.MethodPut, "/api/v2/books/:book_id/rating", "rateBook"},
+	{http.MethodDelete, "/api/v2/books/:book_id/rating", "removeRating"},
+	{http.MethodGet, "/api/v2/books/:book_id/files", "getBookFiles"},
+	{http.MethodGet, "/api/v2/books/:book_id/files/:file_id/download", "downloadFile"},
+	{http.MethodPut, "/api/v2/books/:book_id/copies", "setBookCopies"},
+	{http.MethodGet, "/api/v2/books/:book_id/availability", "getAvailability"},
+	{http.MethodPost, "/api/v2/books/:book_id/checkout", "checkout"},
+	{http.MethodPost, "/api/v2/books/:book_id/hold", "placeHold"},
+	{http.MethodDelete, "/api/v2/books/:book_id/hold", "cancelHold"},
+
+	// Рецензии
+	{http.MethodGet, "/api/v2/reviews/book/:book_id", "getReviewsByBook"},
+	{http.MethodPut, "/api/v2/reviews", "saveReview"},
+	{http.MethodDelete, "/api/v2/reviews/:id", "deleteReview"},
+	{http.MethodPatch, "/api/v2/reviews/:id/status", "setReviewStatus"},
+
+	// Авторы
+	{http.MethodGet, "/api/v2/authors", "listAuthors"},
+	{http.MethodGet, "/api/v2/authors/duplicates", "listDuplicateAuthors"},
+	{http.MethodGet, "/api/v2/authors/merges", "listAuthorMerges"},
+	{http.MethodGet, "/api/v2/authors/:id", "getAuthorByID"},
+	{http.MethodPost, "/api/v2/authors", "createAuthor"},
+	{http.MethodPatch, "/api/v2/authors/:id", "updateAuthor"},
+	{http.MethodDelete, "/api/v2/authors/:id", "deleteAuthor"},
+	{http.MethodPost, "/api/v2/authors/:id/merge", "mergeAuthors"},
+	{http.MethodPost, "/api/v2/authors/:id/aliases", "addAuthorAlias"},
+	{http.MethodDelete, "/api/v2/authors/:id/aliases/:alias_id", "removeAuthorAlias"},
+
+	// Комментарии
+	{http.MethodPost, "/api/v2/comments", "createComment"},
+	{http.MethodPatch, "/api/v2/comments/:id", "updateComment"},
+	{http.MethodDelete, "/api/v2/comments/:id", "deleteComment"},
+	{http.MethodGet, "/api/v2/comments/book/:book_id", "getCommentsByBook"},
+	{http.MethodGet, "/api/v2/comments/user/:user_id", "getCommentsByUser"},
+	{http.MethodGet, "/api/v2/comments/last", "getLastComments"},
+	{http.MethodPatch, "/api/v2/comments/:id/status", "setCommentStatus"},
+
+	// Выдачи, правки, уведомления
+	{http.MethodPost, "/api/v2/loans/:id/return", "returnLoan"},
+	{http.MethodPost, "/api/v2/loans/:id/renew", "renewLoan"},
+	{http.MethodGet, "/api/v2/edits/pending", "getPendingEdits"},
+	{http.MethodGet, "/api/v2/edits/mine", "getMyEdits"},
+	{http.MethodPost, "/api/v2/edits/:id/accept", "acceptEdit"},
+	{http.MethodPost, "/api/v2/edits/:id/reject", "rejectEdit"},
+	{http.MethodPost, "/api/v2/notifications/read-all", "markAllNotificationsRead"},
+	{http.MethodPost, "/api/v2/notifications/:id/read", "markNotificationRead"},
+
+	// Полки
+	{http.MethodGet, "/api/v2/shelves", "getMyShelves"},
+	{http.MethodPost, "/api/v2/shelves", "createShelf"},
+	{http.MethodPatch, "/api/v2/shelves/:id", "renameShelf"},
+	{http.MethodDelete, "/api/v2/shelves/:id", "deleteShelf"},
+	{http.MethodGet, "/api/v2/shelves/:id/books", "getShelfBooks"},
+	{http.MethodPut, "/api/v2/shelves/:id/books/:book_id", "addBookToShelf"},
+	{http.MethodDelete, "/api/v2/shelves/:id/books/:book_id", "removeBookFromShelf"},
+	{http.MethodPost, "/api/v2/shelves/:id/books/:book_id/move", "moveBook"},
+
+	// Позиция чтения
+	{http.MethodGet, "/api/v2/progress", "getMyProgress"},
+	{http.MethodGet, "/api/v2/progress/files/:file_id", "getFileProgress"},
+	{http.MethodPut, "/api/v2/progress/files/:file_id", "saveFileProgress"},
+	{http.MethodGet, "/api/v2/progress/files/:file_id/history", "getFileProgressHistory"},
+	{http.MethodPut, "/api/v2/progress/sync-password", "setSyncPassword"},
+
+	// Пометки
+	{http.MethodGet, "/api/v2/annotations/book/:book_id", "getMyAnnotations"},
+	{http.MethodGet, "/api/v2/annotations/book/:book_id/public", "getPublicAnnotations"},
+	{http.MethodGet, "/api/v2/annotations/book/:book_id/export", "exportAnnotations"},
+	{http.MethodPost, "/api/v2/annotations", "createAnnotation"},
+	{http.MethodPatch, "/api/v2/annotations/:id", "updateAnnotation"},
+	{http.MethodDelete, "/api/v2/annotations/:id", "deleteAnnotation"},
+	{http.MethodPatch, "/api/v2/annotations/:id/status", "setAnnotationStatus"},
+
+	// Аудит, пользователи, аутентификация
+	{http.MethodGet, "/api/v2/audit", "listAudit"},
+	{http.MethodGet, "/api/v2/users", "getUsers"},
+	{http.MethodGet, "/api/v2/users/me/loans", "getMyLoans"},
+	{http.MethodGet, "/api/v2/users/me/notifications", "getMyNotifications"},
+	{http.MethodPost, "/api/v2/users", "createUser"},
+	{http.MethodPatch, "/api/v2/users/:id", "updateUser"},
+	{http.MethodPatch, "/api/v2/users/:id/admin", "adminUpdateUser"},
+	{http.MethodDelete, "/api/v2/users/:id", "softDeleteUser"},
+	{http.MethodDelete, "/api/v2/users/:id/permanent", "hardDeleteUser"},
+	{http.MethodPost, "/api/v2/auth/login", "login"},
+	{http.MethodPost, "/api/v2/auth/register", "register"},
+
+	// Теги
+	{http.MethodGet, "/api/v2/tags", "searchTags"},
+	{http.MethodGet, "/api/v2/tags/cloud", "getTagCloud"},
+	{http.MethodGet, "/api/v2/tags/proposed", "getProposedTags"},
+	{http.MethodGet, "/api/v2/tags/blocklist", "getTagBlocklist"},
+	{http.MethodPost, "/api/v2/tags/blocklist", "addToTagBlocklist"},
+	{http.MethodDelete, "/api/v2/tags/blocklist/:id", "removeFromTagBlocklist"},
+	{http.MethodGet, "/api/v2/tags/:id", "getTagByID"},
+	{http.MethodPost, "/api/v2/tags", "createTag"},
+	{http.MethodPatch, "/api/v2/tags/:id", "updateTag"},
+	{http.MethodDelete, "/api/v2/tags/:id", "deleteTag"},
+	{http.MethodPost, "/api/v2/tags/:id/approve", "approveTag"},
+	{http.MethodPost, "/api/v2/tags/:id/reject", "rejectTag"},
+	{http.MethodPost, "/api/v2/tags/:id/merge", "mergeTags"},
+	{http.MethodPost, "/api/v2/tags/:id/synonyms", "addTagSynonym"},
+	{http.MethodDelete, "/api/v2/tags/:id/synonyms/:synonym_id", "removeTagSynonym"},
+	{http.MethodGet, "/api/v2/tags/book/:bookID", "getTagsByBookID"},
+	{http.MethodPost, "/api/v2/tags/assign", "assignTagToBook"},
+}
+
+// Операции, которых в v1 нет.
+var v2Only = []operation{
+	{method: http.MethodPatch, path: "/api/v2/categories/:id", id: "patchCategoryV2", tag: tagCategories, summary: "Частично обновить категорию",
+		access: admin, body: models.CategoryPatch{}, status: http.StatusOK, response: models.Category{}},
+}
+
+// isV1 — маршрут устаревшего API v1.
+func isV1(op operation) bool {
+	return strings.HasPrefix(op.path, "/api/") && !strings.HasPrefix(op.path, "/api/v2/") && op.tag != tagService
+}
+
+// allOperations — таблица operations с пометкой v1 как устаревшего и
+// операции v2, собранные по v2Routes.
+func allOperations() []operation {
+	byID := make(map[string]operation, len(operations))
+	all := make([]operation, 0, len(operations)+len(v2Routes)+len(v2Only))
+	for _, op := range operations {
+		byID[op.id] = op
+		op.deprecated = isV1(op)
+		all = append(all, op)
+	}
+	for _, route := range v2Routes {
+		op, ok := byID[route.from]
+		if !ok {
+			panic("docs: v2 route " + route.path + " refers to unknown operation " + route.from)
+		}
+		op.method, op.path, op.id = route.method, route.path, route.from+"V2"
+		// В v1 действие без результата отвечало 200 (иногда с сообщением),
+		// в v2 — 204 без тела
+		if op.status == http.StatusOK && (op.response == nil || op.response == messageBody) {
+			op.status, op.response = http.StatusNoContent, nil
+		}
+		all = append(all, op)
+	}
+	return append(all, v2Only...)
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"online_library/backend/internal/middleware"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/service"
+	"strconv"
+)
+
+type AnnotationHandler struct {
+	service service.AnnotationService
+	log     *slog.Logger
+}
+
+// AnnotationCreateRequest — новая пометка; kind и locator_type по умолчанию
+// highlight и cfi.
+type AnnotationCreateRequest struct {
+	BookID      int     `json:"book_id" binding:"required,min=1"`
+	BookFileID  *int    `json:"book_file_id" binding:"omitempty,min=1"`
+	Kind        string  `json:"kind" binding:"omitempty,oneof=highlight bookmark note"`
+	Locator     string  `json:"locator" binding:"required"`
+	LocatorType string  `json:"locator_type" binding:"omitempty,oneof=cfi xpointer page percentage"`
+	Excerpt     *string `json:"excerpt"`
+	Color       *string `json:"color" binding:"omitempty,color"`
+	Note        *string `json:"note"`
+	IsPublic    bool    `json:"is_public"`
+}
+
+type AnnotationUpdateRequest struct {
+	Color    *string `json:"color" binding:"omitempty,color"`
+	Note     *string `json:"note"`
+	IsPublic bool    `json:"is_public"`
+}
+
+func NewAnnotationHandler(service service.AnnotationService, log *slog.Logger) *AnnotationHandler {
+	return &AnnotationHandler{service: service, log: log}
+}
+
+// POST /api/annotations
+func (h *AnnotationHandler) CreateAnnotation(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	var req AnnotationCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	a := models.Annotation{
+		UserID:      userID,
+		BookID:      req.BookID,
+		BookFileID:  req.BookFileID,
+		Kind:        req.Kind,
+		Locator:     req.Locator,
+		LocatorType: req.LocatorType,
+		Excerpt:     req.Excerpt,
+		Color:       req.Color,
+		Note:        req.Note,
+		IsPublic:    req.IsPublic,
+	}
+	if err := h.service.Create(c.Request.Context(), &a, userRole); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, a)
+}
+
+// POST /api/annotations/:id
+func (h *AnnotationHandler) UpdateAnnotation(c *gin.Context) {
+	userID, _, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParam("id"))
+		return
+	}
+
+	var req AnnotationUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	a := models.Annotation{ID: id, Color: req.Color, Note: req.Note, IsPublic: req.IsPublic}
+	if err := h.service.Update(c.Request.Context(), &a, userID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, a)
+}
+
+// POST /api/annotations/:id/delete
+func (h *AnnotationHandler) DeleteAnnotation(c *gin.Context) {
+	userID, _, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParam("id"))
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id, userID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GET /api/annotations/book/:book_id — мои пометки
+func (h *AnnotationHandler) GetMyAnnotations(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+
+	annotations, err := h.service.GetMyAnnotations(c.Request.Context(), bookID, userID, userRole)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, annotations)
+}
+
+// GET /api/annotations/book/:book_id/public?limit=&offset=
+func (h *AnnotationHandler) GetPublicAnnotations(c *gin.Context) {
+	_, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", DefaultLimit))
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	annotations, err := h.service.GetPublicAnnotations(c.Request.Context(), bookID, userRole, limit, offset)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, annotations)
+}
+
+// GET /api/annotations/book/:book_id/export?format=markdown|json
+func (h *AnnotationHandler) ExportAnnotations(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+
+	export, err := h.service.Export(c.Request.Context(), bookID, userID, userRole)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "markdown", "md":
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="annotations-book-%d.md"`, bookID))
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(export.Markdown()))
+	case "json":
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="annotations-book-%d.json"`, bookID))
+		c.JSON(http.StatusOK, export)
+	default:
+		respondError(c, apperr.Validation("invalid_format", "format must be markdown or json"))
+	}
+}
+
+// POST /api/annotations/:id/status
+func (h *AnnotationHandler) SetStatus(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParam("id"))
+		return
+	}
+
+	var req CommentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	if err := h.service.SetStatus(c.Request.Context(), id, req.Status, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondNoContent(c, nil)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/service"
+	"strconv"
+	"time"
+)
+
+type AuditHandler struct {
+	service service.AuditService
+	log     *slog.Logger
+}
+
+func NewAuditHandler(service service.AuditService, log *slog.Logger) *AuditHandler {
+	return &AuditHandler{service: service, log: log}
+}
+
+// GET /api/audit?actor_id=&action=&target_type=&target_id=&request_id=&from=&to= (суперадмин)
+// from и to — в формате RFC 3339.
+func (h *AuditHandler) List(c *gin.Context) {
+	filter := models.AuditFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		RequestID:  c.Query("request_id"),
+	}
+	filter.Limit, filter.Offset = pagination(c)
+	filter.ActorID, _ = strconv.Atoi(c.Query("actor_id"))
+	filter.TargetID, _ = strconv.Atoi(c.Query("target_id"))
+
+	for param, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			respondError(c, invalidParam(param).WithCause(err))
+			return
+		}
+		*dst = &t
+	}
+
+	entries, err := h.service.List(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
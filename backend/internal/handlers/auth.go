@@ -2,49 +2,52 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
+	"log/slog"
 	"net/http"
+	"online_library/backend/internal/repository"
 	"online_library/backend/internal/service"
 )
 
 type AuthHandler struct {
 	Service *service.AuthService
+	log     *slog.Logger
 }
 
-func NewAuthHandler(s *service.AuthService) *AuthHandler {
-	return &AuthHandler{Service: s}
+func NewAuthHandler(s *service.AuthService, log *slog.Logger) *AuthHandler {
+	return &AuthHandler{Service: s, log: log}
 }
 
-type registerRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Name     string `json:"name" binding:"required"`
-	Password string `json:"password" binding:"required"`
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required,email,max=255"`
+	Name     string `json:"name" binding:"required,max=255"`
+	Password string `json:"password" binding:"required,max=72"` // bcrypt учитывает только первые 72 байта
 	Bio      string `json:"bio"`
 }
 
-type loginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
-	var req loginRequest
+	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		respondError(c, invalidBody(err))
 		return
 	}
 
-	token, err := h.Service.Login(req.Email, req.Password)
+	token, err := h.Service.Login(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"token": token})
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
-	var req registerRequest
+	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		respondError(c, invalidBody(err))
 		return
 	}
 
@@ -52,14 +55,14 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		req.Bio = "Пока нет описания"
 	}
 
-	if exists, _ := h.Service.UserService.CheckEmailExists(req.Email); exists {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "email already exists"})
+	if exists, _ := h.Service.UserService.CheckEmailExists(c.Request.Context(), req.Email); exists {
+		respondError(c, repository.ErrEmailTaken)
 		return
 	}
 
-	err := h.Service.Register(req.Email, req.Name, req.Password, req.Bio)
+	err := h.Service.Register(c.Request.Context(), req.Email, req.Name, req.Password, req.Bio)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to register"})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"message": "registered"})
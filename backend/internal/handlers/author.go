@@ -2,30 +2,64 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
+	"log/slog"
 	"net/http"
+	"online_library/backend/internal/middleware"
 	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/translit"
 	"online_library/backend/internal/service"
 	"strconv"
 )
 
 type AuthorHandler struct {
 	service service.AuthorServiceInterface
+	log     *slog.Logger
 }
 
-func NewAuthorHandler(service service.AuthorServiceInterface) *AuthorHandler {
-	return &AuthorHandler{service: service}
+func NewAuthorHandler(service service.AuthorServiceInterface, log *slog.Logger) *AuthorHandler {
+	return &AuthorHandler{service: service, log: log}
+}
+
+// AuthorRequest — профиль автора; достаточно одного из имён, английское
+// при необходимости получается транслитерацией по TranslitScheme.
+type AuthorRequest struct {
+	NameRU         string  `json:"name_ru" binding:"max=255"`
+	NameEN         string  `json:"name_en" binding:"max=255"`
+	Bio            *string `json:"bio"`
+	PhotoURL       *string `json:"photo_url" binding:"omitempty,url,max=512"`
+	TranslitScheme string  `json:"translit_scheme" binding:"omitempty,oneof=practical gost779 iso9 bgn icao"`
+}
+
+func (r AuthorRequest) author(id int) models.Author {
+	return models.Author{ID: id, NameRU: r.NameRU, NameEN: r.NameEN, Bio: r.Bio, PhotoURL: r.PhotoURL}
+}
+
+type AliasRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+	Kind string `json:"kind" binding:"omitempty,oneof=pseudonym spelling translit"` // по умолчанию spelling
+}
+
+type MergeAuthorsRequest struct {
+	SourceIDs []int `json:"source_ids" binding:"required,min=1,dive,min=1"`
 }
 
 // POST /api/authors
 func (h *AuthorHandler) CreateAuthor(c *gin.Context) {
-	var author models.Author
-	if err := c.ShouldBindJSON(&author); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	var req AuthorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
 		return
 	}
+	author := req.author(0)
 
-	if err := h.service.CreateAuthor(&author); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := h.service.CreateAuthor(c.Request.Context(), &author, translit.Scheme(req.TranslitScheme), actor); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -34,21 +68,27 @@ func (h *AuthorHandler) CreateAuthor(c *gin.Context) {
 
 // PUT /api/authors/:id
 func (h *AuthorHandler) UpdateAuthor(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid author ID"})
+		respondError(c, invalidParam("id"))
 		return
 	}
 
-	var author models.Author
-	if err := c.ShouldBindJSON(&author); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+	var req AuthorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
 		return
 	}
-	author.ID = id
+	author := req.author(id)
 
-	if err := h.service.UpdateAuthor(&author); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := h.service.UpdateAuthor(c.Request.Context(), &author, translit.Scheme(req.TranslitScheme), actor); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -57,14 +97,20 @@ func (h *AuthorHandler) UpdateAuthor(c *gin.Context) {
 
 // DELETE /api/authors/:id
 func (h *AuthorHandler) DeleteAuthor(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid author ID"})
+		respondError(c, invalidParam("id"))
 		return
 	}
 
-	if err := h.service.DeleteAuthor(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete author"})
+	if err := h.service.DeleteAuthor(c.Request.Context(), id, actor); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -75,17 +121,85 @@ func (h *AuthorHandler) DeleteAuthor(c *gin.Context) {
 func (h *AuthorHandler) GetAuthorByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid author ID"})
+		respondError(c, invalidParam("id"))
+		return
+	}
+
+	_, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
-	author, err := h.service.GetAuthorByID(id)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", DefaultLimit))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 {
+		limit, _ = strconv.Atoi(DefaultLimit)
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	details, err := h.service.GetAuthorDetails(c.Request.Context(), id, userRole, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "author not found"})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, author)
+	c.JSON(http.StatusOK, details)
+}
+
+// POST /api/authors/:id/aliases
+func (h *AuthorHandler) AddAlias(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParam("id"))
+		return
+	}
+
+	var req AliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+	alias := models.AuthorAlias{AuthorID: id, Name: req.Name, Kind: req.Kind}
+
+	if err := h.service.AddAlias(c.Request.Context(), &alias); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, alias)
+}
+
+// POST /api/authors/:id/aliases/:alias_id/remove
+func (h *AuthorHandler) RemoveAlias(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParam("id"))
+		return
+	}
+	aliasID, err := strconv.Atoi(c.Param("alias_id"))
+	if err != nil {
+		respondError(c, invalidParam("alias_id"))
+		return
+	}
+
+	if err := h.service.RemoveAlias(c.Request.Context(), id, aliasID, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
 }
 
 // GET /api/authors
@@ -95,20 +209,99 @@ func (h *AuthorHandler) ListAuthors(c *gin.Context) {
 	query := c.Query("query")
 
 	if query != "" {
-		authors, count, err := h.service.SearchAuthors(query, limit, offset)
+		authors, count, err := h.service.SearchAuthors(c.Request.Context(), query, limit, offset)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search authors"})
+			respondError(c, err)
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"data": authors, "count": count})
 		return
 	}
 
-	authors, err := h.service.GetAllAuthors(limit, offset)
+	authors, err := h.service.GetAllAuthors(c.Request.Context(), limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch authors"})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, authors)
 }
+
+// POST /api/authors/:id/merge
+func (h *AuthorHandler) MergeAuthors(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParam("id"))
+		return
+	}
+
+	var req MergeAuthorsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	merges, err := h.service.MergeAuthors(c.Request.Context(), targetID, req.SourceIDs, userID, userRole)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, merges)
+}
+
+// GET /api/authors/merges
+func (h *AuthorHandler) ListMerges(c *gin.Context) {
+	_, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", DefaultLimit))
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	merges, err := h.service.GetMerges(c.Request.Context(), userRole, limit, offset)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, merges)
+}
+
+// GET /api/authors/duplicates
+func (h *AuthorHandler) ListDuplicates(c *gin.Context) {
+	_, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	threshold, err := strconv.ParseFloat(c.DefaultQuery("threshold", "0.5"), 64)
+	if err != nil {
+		respondError(c, invalidParam("threshold"))
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	duplicates, err := h.service.FindDuplicates(c.Request.Context(), userRole, threshold, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, duplicates)
+}
@@ -1,50 +1,116 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
+	"log/slog"
 	"net/http"
 	"online_library/backend/internal/middleware"
 	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/repository"
 	"online_library/backend/internal/service"
 	"strconv"
+	"strings"
 )
 
 type BookHandler struct {
 	bookService service.BookService
+	log         *slog.Logger
 }
 
+// TagListRequest принимает либо tags с весами, либо tag_ids — тогда все теги основные.
 type TagListRequest struct {
-	TagIDs []int `json:"tag_ids"`
+	TagIDs []int              `json:"tag_ids" binding:"omitempty,dive,min=1"`
+	Tags   []TagWeightRequest `json:"tags" binding:"omitempty,dive"`
 }
 
+type TagWeightRequest struct {
+	TagID  int  `json:"tag_id" binding:"required,min=1"`
+	Weight *int `json:"weight" binding:"omitempty,oneof=0 1"` // по умолчанию 1 (основной)
+}
+
+func (r TagListRequest) bookTags(bookID int) []models.BookTag {
+	if len(r.Tags) > 0 {
+		tags := make([]models.BookTag, len(r.Tags))
+		for i, t := range r.Tags {
+			tags[i] = models.BookTag{BookID: bookID, TagID: t.TagID, Weight: models.TagWeightPrimary}
+			if t.Weight != nil {
+				tags[i].Weight = *t.Weight
+			}
+		}
+		return tags
+	}
+	tags := make([]models.BookTag, len(r.TagIDs))
+	for i, id := range r.TagIDs {
+		tags[i] = models.BookTag{BookID: bookID, TagID: id, Weight: models.TagWeightPrimary}
+	}
+	return tags
+}
+
+// AuthorListRequest принимает либо authors с ролями и порядком, либо
+// старый формат author_ids — тогда все получают роль "author" в порядке списка.
 type AuthorListRequest struct {
-	AuthorIDs []int `json:"author_ids"`
+	AuthorIDs []int                    `json:"author_ids" binding:"omitempty,dive,min=1"`
+	Authors   []models.BookContributor `json:"authors" binding:"omitempty,dive"`
+}
+
+func (r AuthorListRequest) contributors() []models.BookContributor {
+	if len(r.Authors) > 0 {
+		return r.Authors
+	}
+	contributors := make([]models.BookContributor, len(r.AuthorIDs))
+	for i, id := range r.AuthorIDs {
+		contributors[i] = models.BookContributor{AuthorID: id, Role: models.ContributorAuthor, Position: i}
+	}
+	return contributors
+}
+
+// CreateBookRequest — поля книги и, по желанию, её авторы, теги и категории:
+// всё создаётся одной транзакцией.
+type CreateBookRequest struct {
+	models.CreateBookInput
+	AuthorListRequest
+	TagListRequest
+	CategoryIDs []int `json:"category_ids" binding:"omitempty,dive,min=1"`
+}
+
+func (r CreateBookRequest) relations() models.BookRelations {
+	rel := models.BookRelations{CategoryIDs: r.CategoryIDs}
+	if r.Authors != nil || r.AuthorIDs != nil {
+		rel.Authors = r.contributors()
+	}
+	if r.Tags != nil || r.TagIDs != nil {
+		rel.Tags = r.bookTags(0)
+	}
+	return rel
 }
 
 type StatusUpdateRequest struct {
-	Status string `json:"status"`
+	Status string `json:"status" binding:"required,oneof=draft quarantine visible rejected archived private"`
+	Reason string `json:"reason"` // обязательна при отклонении
 }
 
-func NewBookHandler(bookService service.BookService) *BookHandler {
-	return &BookHandler{bookService: bookService}
+func NewBookHandler(bookService service.BookService, log *slog.Logger) *BookHandler {
+	return &BookHandler{bookService: bookService, log: log}
 }
 
 func (h *BookHandler) CreateBook(c *gin.Context) {
-	userID, userRole, ok := middleware.ExtractUser(c)
+	actor, ok := middleware.ExtractActor(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
-	var book models.Book
-	if err := c.ShouldBindJSON(&book); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+	var req CreateBookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
 		return
 	}
 
-	bookID, err := h.bookService.CreateBook(&book, userRole, userID)
+	bookID, err := h.bookService.CreateBook(c.Request.Context(), req.CreateBookInput, req.relations(), actor)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -52,82 +118,196 @@ func (h *BookHandler) CreateBook(c *gin.Context) {
 }
 
 func (h *BookHandler) UpdateBook(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+
+	var input models.BookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	if err := h.bookService.UpdateBook(c.Request.Context(), bookID, input, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondNoContent(c, nil)
+}
+
+// respondBook отвечает карточкой книги с ETag "<версия>-<хеш>": версия нужна
+// для If-Match при правке, хеш меняется и при смене статуса, рейтинга или
+// авторов, которые версию не увеличивают.
+func respondBook(c *gin.Context, book *models.Book) {
+	respondWithETag(c, strconv.Itoa(book.Version), book)
+}
+
+// parseETagVersion разбирает If-Match: "3-…" из ответа или просто "3";
+// 0 — заголовок пустой или не наш.
+func parseETagVersion(header string) int {
+	header = strings.Trim(strings.TrimPrefix(strings.TrimSpace(header), "W/"), `"`)
+	header, _, _ = strings.Cut(header, "-")
+	version, err := strconv.Atoi(header)
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+// PATCH /api/books/:book_id — частичное обновление, требует If-Match с версией книги
+func (h *BookHandler) PatchBook(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+
+	version := parseETagVersion(c.GetHeader("If-Match"))
+	if version == 0 {
+		respondError(c, service.ErrVersionRequired)
+		return
+	}
+
+	var patch models.BookPatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	book, err := h.bookService.PatchBook(c.Request.Context(), bookID, patch, version, actor)
+	if errors.Is(err, repository.ErrBookVersionConflict) {
+		// If-Match не совпал с текущей версией — это 412, а не 409
+		respondError(c, repository.ErrBookVersionConflict.WithKind(apperr.KindPreconditionFailed).WithCause(err))
+		return
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondBook(c, book)
+}
+
+// GET /api/books/:book_id/history — ревизии книги, новые первыми
+func (h *BookHandler) GetBookHistory(c *gin.Context) {
 	userID, userRole, ok := middleware.ExtractUser(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+	limit, offset := pagination(c)
+
+	revisions, err := h.bookService.GetBookHistory(c.Request.Context(), bookID, userID, userRole, limit, offset)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, revisions)
+}
 
-	var book models.Book
-	if err := c.ShouldBindJSON(&book); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+// POST /api/books/:book_id/history/:revision_id/rollback — вернуть поля книги к ревизии (админ)
+func (h *BookHandler) RollbackBook(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
-	if err := h.bookService.UpdateBook(&book, userID, userRole); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+	revisionID, err := strconv.Atoi(c.Param("revision_id"))
+	if err != nil {
+		respondError(c, invalidParam("revision_id"))
 		return
 	}
 
-	c.Status(http.StatusOK)
+	book, err := h.bookService.RollbackBook(c.Request.Context(), bookID, revisionID, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondBook(c, book)
 }
 
 func (h *BookHandler) DeleteBook(c *gin.Context) {
-	userID, userRole, ok := middleware.ExtractUser(c)
+	actor, ok := middleware.ExtractActor(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
-	bookID, err := strconv.Atoi(c.Param("id"))
+	bookID, err := strconv.Atoi(c.Param("book_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		respondError(c, invalidParam("book_id"))
 		return
 	}
 
-	if err := h.bookService.DeleteBook(bookID, userID, userRole); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	if err := h.bookService.DeleteBook(c.Request.Context(), bookID, actor); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.Status(http.StatusOK)
+	respondNoContent(c, nil)
 }
 
 func (h *BookHandler) GetBookByID(c *gin.Context) {
-	_, userRole, ok := middleware.ExtractUser(c)
+	userID, userRole, ok := middleware.ExtractUser(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
-	bookID, err := strconv.Atoi(c.Param("id"))
+	bookID, err := strconv.Atoi(c.Param("book_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		respondError(c, invalidParam("book_id"))
 		return
 	}
 
-	book, err := h.bookService.GetBookByID(bookID, userRole)
+	book, err := h.bookService.GetBookByID(c.Request.Context(), bookID, userID, userRole)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, book)
+	respondBook(c, book)
 }
 
 func (h *BookHandler) GetBooksByStatuses(c *gin.Context) {
 	_, userRole, ok := middleware.ExtractUser(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	books, err := h.bookService.GetBooksByStatuses(userRole, offset, limit)
+	books, err := h.bookService.GetBooksByStatuses(c.Request.Context(), userRole, offset, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -137,17 +317,24 @@ func (h *BookHandler) GetBooksByStatuses(c *gin.Context) {
 func (h *BookHandler) SearchBooks(c *gin.Context) {
 	_, userRole, ok := middleware.ExtractUser(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
 	query := c.Query("q")
+	sortBy := c.Query("sort") // newest | rating | relevance
+	if sortBy == "" {
+		sortBy = models.BookSortNewest
+		if query != "" {
+			sortBy = models.BookSortRelevance
+		}
+	}
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	books, err := h.bookService.SearchBooks(query, userRole, limit, offset)
+	books, err := h.bookService.SearchBooks(c.Request.Context(), query, userRole, sortBy, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -157,22 +344,28 @@ func (h *BookHandler) SearchBooks(c *gin.Context) {
 func (h *BookHandler) GetBooksByAuthor(c *gin.Context) {
 	_, userRole, ok := middleware.ExtractUser(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
 	authorID, err := strconv.Atoi(c.Param("author_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid author ID"})
+		respondError(c, invalidParam("author_id"))
 		return
 	}
 
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	books, err := h.bookService.GetBooksByAuthor(authorID, userRole, offset, limit)
+	role := c.Query("role")
+	if role != "" && !models.IsContributorRole(role) {
+		respondError(c, apperr.Validationf("unknown_contributor_role", "unknown contributor role: %s", role))
+		return
+	}
+
+	books, err := h.bookService.GetBooksByAuthor(c.Request.Context(), authorID, role, userRole, offset, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -182,22 +375,22 @@ func (h *BookHandler) GetBooksByAuthor(c *gin.Context) {
 func (h *BookHandler) GetBooksByTag(c *gin.Context) {
 	_, userRole, ok := middleware.ExtractUser(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
 	tagID, err := strconv.Atoi(c.Param("tag_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tag ID"})
+		respondError(c, invalidParam("tag_id"))
 		return
 	}
 
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	books, err := h.bookService.GetBooksByTag(tagID, userRole, offset, limit)
+	books, err := h.bookService.GetBooksByTag(c.Request.Context(), tagID, userRole, offset, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -207,13 +400,13 @@ func (h *BookHandler) GetBooksByTag(c *gin.Context) {
 func (h *BookHandler) GetUserBooks(c *gin.Context) {
 	userID, _, ok := middleware.ExtractUser(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
-	books, err := h.bookService.GetUserBooks(userID)
+	books, err := h.bookService.GetUserBooks(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -223,13 +416,13 @@ func (h *BookHandler) GetUserBooks(c *gin.Context) {
 func (h *BookHandler) GetUserFavoriteBooks(c *gin.Context) {
 	userID, userRole, ok := middleware.ExtractUser(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
-	books, err := h.bookService.GetUserFavoriteBooks(userID, userRole)
+	books, err := h.bookService.GetUserFavoriteBooks(c.Request.Context(), userID, userRole)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -239,18 +432,18 @@ func (h *BookHandler) GetUserFavoriteBooks(c *gin.Context) {
 func (h *BookHandler) AddBookToFavorites(c *gin.Context) {
 	userID, _, ok := middleware.ExtractUser(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
 	bookID, err := strconv.Atoi(c.Param("book_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		respondError(c, invalidParam("book_id"))
 		return
 	}
 
-	if err := h.bookService.AddBookToFavorites(userID, bookID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.bookService.AddBookToFavorites(c.Request.Context(), userID, bookID); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -260,18 +453,18 @@ func (h *BookHandler) AddBookToFavorites(c *gin.Context) {
 func (h *BookHandler) RemoveBookFromFavorites(c *gin.Context) {
 	userID, _, ok := middleware.ExtractUser(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
 	bookID, err := strconv.Atoi(c.Param("book_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		respondError(c, invalidParam("book_id"))
 		return
 	}
 
-	if err := h.bookService.RemoveBookFromFavorites(userID, bookID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.bookService.RemoveBookFromFavorites(c.Request.Context(), userID, bookID); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -279,26 +472,26 @@ func (h *BookHandler) RemoveBookFromFavorites(c *gin.Context) {
 }
 
 func (h *BookHandler) SetBookTags(c *gin.Context) {
-	userID, userRole, ok := middleware.ExtractUser(c)
+	actor, ok := middleware.ExtractActor(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
 	bookID, err := strconv.Atoi(c.Param("book_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		respondError(c, invalidParam("book_id"))
 		return
 	}
 
 	var req TagListRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		respondError(c, invalidBody(err))
 		return
 	}
 
-	if err := h.bookService.SetBookTags(bookID, req.TagIDs, userID, userRole); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	if err := h.bookService.SetBookTags(c.Request.Context(), bookID, req.bookTags(bookID), actor); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -306,17 +499,22 @@ func (h *BookHandler) SetBookTags(c *gin.Context) {
 }
 
 func (h *BookHandler) AddBookTag(c *gin.Context) {
-	userID, userRole, ok := middleware.ExtractUser(c)
+	actor, ok := middleware.ExtractActor(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
 	bookID, _ := strconv.Atoi(c.Param("book_id"))
 	tagID, _ := strconv.Atoi(c.Param("tag_id"))
+	weight, err := strconv.Atoi(c.DefaultQuery("weight", strconv.Itoa(models.TagWeightPrimary)))
+	if err != nil {
+		respondError(c, invalidParam("weight"))
+		return
+	}
 
-	if err := h.bookService.AddBookTag(bookID, tagID, userID, userRole); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	if err := h.bookService.AddBookTag(c.Request.Context(), bookID, tagID, weight, actor); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -324,17 +522,17 @@ func (h *BookHandler) AddBookTag(c *gin.Context) {
 }
 
 func (h *BookHandler) RemoveBookTag(c *gin.Context) {
-	userID, userRole, ok := middleware.ExtractUser(c)
+	actor, ok := middleware.ExtractActor(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
 	bookID, _ := strconv.Atoi(c.Param("book_id"))
 	tagID, _ := strconv.Atoi(c.Param("tag_id"))
 
-	if err := h.bookService.RemoveBookTag(bookID, tagID, userID, userRole); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	if err := h.bookService.RemoveBookTag(c.Request.Context(), bookID, tagID, actor); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -342,9 +540,9 @@ func (h *BookHandler) RemoveBookTag(c *gin.Context) {
 }
 
 func (h *BookHandler) SetBookAuthors(c *gin.Context) {
-	userID, userRole, ok := middleware.ExtractUser(c)
+	actor, ok := middleware.ExtractActor(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
@@ -352,12 +550,12 @@ func (h *BookHandler) SetBookAuthors(c *gin.Context) {
 
 	var req AuthorListRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		respondError(c, invalidBody(err))
 		return
 	}
 
-	if err := h.bookService.SetBookAuthors(bookID, req.AuthorIDs, userID, userRole); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	if err := h.bookService.SetBookAuthors(c.Request.Context(), bookID, req.contributors(), actor); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -365,17 +563,19 @@ func (h *BookHandler) SetBookAuthors(c *gin.Context) {
 }
 
 func (h *BookHandler) AddBookAuthor(c *gin.Context) {
-	userID, userRole, ok := middleware.ExtractUser(c)
+	actor, ok := middleware.ExtractActor(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
 	bookID, _ := strconv.Atoi(c.Param("book_id"))
 	authorID, _ := strconv.Atoi(c.Param("author_id"))
+	position, _ := strconv.Atoi(c.DefaultQuery("position", "0"))
 
-	if err := h.bookService.AddBookAuthor(bookID, authorID, userID, userRole); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	contributor := models.BookContributor{AuthorID: authorID, Role: c.Query("role"), Position: position}
+	if err := h.bookService.AddBookAuthor(c.Request.Context(), bookID, contributor, actor); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -383,17 +583,17 @@ func (h *BookHandler) AddBookAuthor(c *gin.Context) {
 }
 
 func (h *BookHandler) RemoveBookAuthor(c *gin.Context) {
-	userID, userRole, ok := middleware.ExtractUser(c)
+	actor, ok := middleware.ExtractActor(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
 	bookID, _ := strconv.Atoi(c.Param("book_id"))
 	authorID, _ := strconv.Atoi(c.Param("author_id"))
 
-	if err := h.bookService.RemoveBookAuthor(bookID, authorID, userID, userRole); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	if err := h.bookService.RemoveBookAuthor(c.Request.Context(), bookID, authorID, c.Query("role"), actor); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -401,9 +601,9 @@ func (h *BookHandler) RemoveBookAuthor(c *gin.Context) {
 }
 
 func (h *BookHandler) UpdateBookStatus(c *gin.Context) {
-	_, userRole, ok := middleware.ExtractUser(c)
+	actor, ok := middleware.ExtractActor(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
@@ -411,34 +611,72 @@ func (h *BookHandler) UpdateBookStatus(c *gin.Context) {
 
 	var req StatusUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		respondError(c, invalidBody(err))
 		return
 	}
 
-	if err := h.bookService.UpdateBookStatus(bookID, req.Status, userRole); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	if err := h.bookService.ChangeBookStatus(c.Request.Context(), bookID, req.Status, req.Reason, actor); err != nil {
+		respondError(c, err)
 		return
 	}
-
 	c.Status(http.StatusNoContent)
 }
 
+// GET /api/books/:book_id/status/history
+func (h *BookHandler) GetStatusHistory(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, _ := strconv.Atoi(c.Param("book_id"))
+	history, err := h.bookService.GetStatusHistory(c.Request.Context(), bookID, userID, userRole)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// GET /api/books/review-queue — книги на модерации вместе с отправителем
+func (h *BookHandler) GetReviewQueue(c *gin.Context) {
+	_, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", DefaultLimit))
+	if limit <= 0 || limit > MaxLimit {
+		limit = MaxLimit
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	queue, err := h.bookService.GetReviewQueue(c.Request.Context(), userRole, limit, offset)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, queue)
+}
+
 func (h *BookHandler) GetDuplicateBooks(c *gin.Context) {
 	_, role, ok := middleware.ExtractUser(c)
 	if !ok || !middleware.IsAdmin(role) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		respondError(c, middleware.ErrAdminRequired)
 		return
 	}
 
 	title := c.Param("title")
 	if title == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing title"})
+		respondError(c, invalidParam("title"))
 		return
 	}
 
-	books, err := h.bookService.GetDuplicateBooks(title)
+	books, err := h.bookService.GetDuplicateBooks(c.Request.Context(), title)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to find duplicates"})
+		respondError(c, err)
 		return
 	}
 
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"online_library/backend/internal/middleware"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/service"
+	"strconv"
+)
+
+type BookEditHandler struct {
+	service service.BookEditService
+	log     *slog.Logger
+}
+
+func NewBookEditHandler(service service.BookEditService, log *slog.Logger) *BookEditHandler {
+	return &BookEditHandler{service: service, log: log}
+}
+
+type BookEditRequest struct {
+	Changes models.BookEditChanges `json:"changes" binding:"required"`
+	Comment string                 `json:"comment"`
+}
+
+type EditReviewRequest struct {
+	Comment string `json:"comment"`
+}
+
+// POST /api/books/:book_id/edits — предложить правку
+func (h *BookEditHandler) ProposeEdit(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+
+	var req BookEditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	proposal, err := h.service.ProposeEdit(c.Request.Context(), bookID, req.Changes, req.Comment, userID, userRole)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, proposal)
+}
+
+// GET /api/books/:book_id/edits?status=pending — правки книги (владелец/админ)
+func (h *BookEditHandler) GetBookEdits(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+	limit, offset := pagination(c)
+
+	edits, err := h.service.GetBookEdits(c.Request.Context(), bookID, c.Query("status"), userID, userRole, limit, offset)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, edits)
+}
+
+// GET /api/edits/pending — очередь правок к моим книгам (админу — ко всем)
+func (h *BookEditHandler) GetPendingEdits(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+	limit, offset := pagination(c)
+
+	edits, err := h.service.GetPendingEdits(c.Request.Context(), userID, userRole, limit, offset)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, edits)
+}
+
+// GET /api/edits/mine — мои предложенные правки
+func (h *BookEditHandler) GetMyEdits(c *gin.Context) {
+	userID, _, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+	limit, offset := pagination(c)
+
+	edits, err := h.service.GetUserEdits(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, edits)
+}
+
+// POST /api/edits/:id/accept
+func (h *BookEditHandler) AcceptEdit(c *gin.Context) {
+	h.review(c, h.service.AcceptEdit)
+}
+
+// POST /api/edits/:id/reject
+func (h *BookEditHandler) RejectEdit(c *gin.Context) {
+	h.review(c, h.service.RejectEdit)
+}
+
+func (h *BookEditHandler) review(c *gin.Context, action func(ctx context.Context, id int, comment string, actor models.Actor) error) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParam("id"))
+		return
+	}
+
+	// Комментарий необязателен, тело запроса может быть пустым
+	var req EditReviewRequest
+	_ = c.ShouldBindJSON(&req)
+
+	if err := action(c.Request.Context(), id, req.Comment, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"online_library/backend/internal/middleware"
+	"online_library/backend/internal/service"
+	"strconv"
+)
+
+type BookFileHandler struct {
+	service service.BookFileService
+	log     *slog.Logger
+}
+
+func NewBookFileHandler(service service.BookFileService, log *slog.Logger) *BookFileHandler {
+	return &BookFileHandler{service: service, log: log}
+}
+
+// GET /api/books/:book_id/files
+func (h *BookFileHandler) GetBookFiles(c *gin.Context) {
+	_, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+
+	files, err := h.service.GetBookFiles(c.Request.Context(), bookID, userRole)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, files)
+}
+
+// GET /api/books/:book_id/files/:file_id/download
+func (h *BookFileHandler) DownloadFile(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+	fileID, err := strconv.Atoi(c.Param("file_id"))
+	if err != nil {
+		respondError(c, invalidParam("file_id"))
+		return
+	}
+
+	file, obj, err := h.service.OpenDownload(c.Request.Context(), bookID, fileID, userID, userRole)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	defer obj.Body.Close()
+
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	// Файл отдаётся только после проверки выдачи — кешировать его нельзя
+	c.DataFromReader(http.StatusOK, obj.Size, contentType, obj.Body, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="book-%d.%s"`, file.BookID, file.Format),
+		"Cache-Control":       "private, no-store",
+	})
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
+	"log/slog"
 	"net/http"
+	"online_library/backend/internal/middleware"
 	"online_library/backend/internal/models"
 	"online_library/backend/internal/service"
 	"strconv"
@@ -10,117 +12,162 @@ import (
 
 type CategoryHandler struct {
 	service service.CategoryService
+	log     *slog.Logger
 }
 
-func NewCategoryHandler(s service.CategoryService) *CategoryHandler {
-	return &CategoryHandler{service: s}
+func NewCategoryHandler(s service.CategoryService, log *slog.Logger) *CategoryHandler {
+	return &CategoryHandler{service: s, log: log}
 }
 
 func (h *CategoryHandler) GetAllCategories(c *gin.Context) {
-	tree, err := h.service.GetCategoryTree()
+	tree, err := h.service.GetCategoryTree(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения категорий"})
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, tree)
+	respondWithETag(c, "", tree)
 }
 
 func (h *CategoryHandler) GetRootCategories(c *gin.Context) {
-	root, err := h.service.GetCategoryRoot()
+	root, err := h.service.GetCategoryRoot(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось получить корневые категории"})
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, root)
+	respondWithETag(c, "", root)
 }
 
 func (h *CategoryHandler) GetCategoryByID(c *gin.Context) {
 	id, errC := strconv.Atoi(c.Param("id"))
 	if errC != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+		respondError(c, invalidParam("id"))
 		return
 	}
-	cat, err := h.service.GetCategoryByID(id)
+	cat, err := h.service.GetCategoryByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Категория не найдена" + c.Param("id")})
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, cat)
+	respondWithETag(c, "", cat)
 }
 
 func (h *CategoryHandler) GetCategoryChildren(c *gin.Context) {
 	id, errC := strconv.Atoi(c.Param("id"))
 	if errC != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+		respondError(c, invalidParam("id"))
 		return
 	}
-	children, err := h.service.GetCategoryChildren(id)
+	children, err := h.service.GetCategoryChildren(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения подкатегорий" + c.Param("id")})
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, children)
+	respondWithETag(c, "", children)
 }
 
 func (h *CategoryHandler) GetBooksInCategory(c *gin.Context) {
 	id, errC := strconv.Atoi(c.Param("id"))
 	if errC != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+		respondError(c, invalidParam("id"))
 		return
 	}
-	books, err := h.service.GetBooksByCategoryIDRecursive(id)
+	books, err := h.service.GetBooksByCategoryIDRecursive(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения книг" + c.Param("id")})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, books)
 }
 
 func (h *CategoryHandler) CreateCategory(c *gin.Context) {
-	var input models.Category
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	var input models.CategoryInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ввод"})
+		respondError(c, invalidBody(err))
 		return
 	}
 
-	id, err := h.service.CreateCategory(&input)
+	id, err := h.service.CreateCategory(c.Request.Context(), input, actor)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось создать категорию"})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"id": id})
 }
 
 func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
-	var input models.Category
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParam("id"))
+		return
+	}
+
+	var input models.CategoryInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ввод"})
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	if err := h.service.UpdateCategory(c.Request.Context(), id, input, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+	respondNoContent(c, gin.H{"message": "Категория обновлена"})
+}
+
+func (h *CategoryHandler) PatchCategory(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID"})
+		respondError(c, invalidParam("id"))
+		return
+	}
+
+	var patch models.CategoryPatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		respondError(c, invalidBody(err))
 		return
 	}
-	input.ID = id
 
-	if err := h.service.UpdateCategory(&input); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка обновления"})
+	cat, err := h.service.PatchCategory(c.Request.Context(), id, patch, actor)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Категория обновлена"})
+	respondWithETag(c, "", cat)
 }
 
 func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID"})
+		respondError(c, invalidParam("id"))
 		return
 	}
-	if err := h.service.DeleteCategory(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка удаления"})
+	if err := h.service.DeleteCategory(c.Request.Context(), id, actor); err != nil {
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Категория удалена"})
+	respondNoContent(c, gin.H{"message": "Категория удалена"})
 }
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
+	"log/slog"
 	"net/http"
 	"online_library/backend/internal/middleware"
 	"online_library/backend/internal/models"
@@ -15,34 +16,63 @@ const (
 	MaxLimit     = 100
 )
 
+// pagination читает limit и offset из query, limit ограничен MaxLimit.
+func pagination(c *gin.Context) (limit, offset int) {
+	limit, _ = strconv.Atoi(c.DefaultQuery("limit", DefaultLimit))
+	if limit <= 0 || limit > MaxLimit {
+		limit = MaxLimit
+	}
+	offset, _ = strconv.Atoi(c.DefaultQuery("offset", "0"))
+	return limit, offset
+}
+
+type CreateCommentRequest struct {
+	BookID int    `json:"book_id" binding:"required,min=1"`
+	Text   string `json:"text" binding:"required"`
+}
+
+type UpdateCommentRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// CommentStatusRequest — статус модерации комментария, рецензии или пометки.
+type CommentStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active hidden deleted pending"`
+}
+
 type CommentHandler struct {
 	service service.CommentService
+	log     *slog.Logger
 }
 
-func NewCommentHandler(service service.CommentService) *CommentHandler {
-	return &CommentHandler{service: service}
+func NewCommentHandler(service service.CommentService, log *slog.Logger) *CommentHandler {
+	return &CommentHandler{service: service, log: log}
 }
 
 func (h *CommentHandler) CreateComment(c *gin.Context) {
 	userID, _, ok := middleware.ExtractUser(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
-	var comment models.Comment
-	if err := c.ShouldBindJSON(&comment); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
 		return
 	}
 
-	comment.UserID = userID
-	comment.CreatedAt = time.Now()
+	comment := models.Comment{
+		BookID:    req.BookID,
+		UserID:    userID,
+		Text:      req.Text,
+		CreatedAt: time.Now(),
+		Status:    models.CommentStatusActive, // по умолчанию
+	}
 	comment.UpdatedAt = comment.CreatedAt
-	comment.Status = models.CommentStatusActive // по умолчанию
 
-	if err := h.service.Create(&comment); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.Create(c.Request.Context(), &comment); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -52,70 +82,69 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 func (h *CommentHandler) UpdateComment(c *gin.Context) {
 	userID, role, ok := middleware.ExtractUser(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	var input models.Comment
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid comment id"})
+		respondError(c, invalidParam("id"))
 		return
 	}
 
-	input.ID = id
+	var req UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
 
-	if err := h.service.Update(&input, userID, role); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	input := models.Comment{ID: id, Text: req.Text}
+	if err := h.service.Update(c.Request.Context(), &input, userID, role); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.Status(http.StatusOK)
+	respondNoContent(c, nil)
 }
 
 func (h *CommentHandler) DeleteComment(c *gin.Context) {
-	userID, role, ok := middleware.ExtractUser(c)
+	actor, ok := middleware.ExtractActor(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, invalidParam("id"))
 		return
 	}
 
-	existing, err := h.service.GetByID(id)
+	existing, err := h.service.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+		respondError(c, err)
 		return
 	}
 
-	isOwner := existing.UserID == userID
-	isAdmin := role == models.RoleAdmin || role == models.RoleSuperAdmin
+	isOwner := existing.UserID == actor.ID
+	isAdmin := actor.Role == models.RoleAdmin || actor.Role == models.RoleSuperAdmin
 	if !isOwner && !isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		respondError(c, middleware.ErrAccessDenied)
 		return
 	}
 
-	if err := h.service.Delete(id, userID, role); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.Delete(c.Request.Context(), id, actor); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.Status(http.StatusOK)
+	respondNoContent(c, nil)
 }
 
 func (h *CommentHandler) GetCommentsByBook(c *gin.Context) {
 	bookID, err := strconv.Atoi(c.Param("book_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book id"})
+		respondError(c, invalidParam("book_id"))
 		return
 	}
 
@@ -129,9 +158,9 @@ func (h *CommentHandler) GetCommentsByBook(c *gin.Context) {
 
 	statuses := []string{models.CommentStatusActive}
 
-	comments, err := h.service.GetByBookID(bookID, limit, offset, statuses)
+	comments, err := h.service.GetByBookID(c.Request.Context(), bookID, limit, offset, statuses)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -141,7 +170,7 @@ func (h *CommentHandler) GetCommentsByBook(c *gin.Context) {
 func (h *CommentHandler) GetCommentsByUser(c *gin.Context) {
 	userID, err := strconv.Atoi(c.Param("user_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		respondError(c, invalidParam("user_id"))
 		return
 	}
 
@@ -153,9 +182,9 @@ func (h *CommentHandler) GetCommentsByUser(c *gin.Context) {
 
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	comments, err := h.service.GetByUserID(userID, limit, offset)
+	comments, err := h.service.GetByUserID(c.Request.Context(), userID, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -169,9 +198,9 @@ func (h *CommentHandler) GetLastComments(c *gin.Context) {
 		limit = MaxLimit
 	}
 
-	comments, err := h.service.GetLast(limit)
+	comments, err := h.service.GetLast(c.Request.Context(), limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -179,31 +208,28 @@ func (h *CommentHandler) GetLastComments(c *gin.Context) {
 }
 
 func (h *CommentHandler) SetStatus(c *gin.Context) {
-	_, role, ok := middleware.ExtractUser(c)
-	if !ok || (role != models.RoleAdmin && role != models.RoleSuperAdmin) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+	actor, ok := middleware.ExtractActor(c)
+	if !ok || (actor.Role != models.RoleAdmin && actor.Role != models.RoleSuperAdmin) {
+		respondError(c, middleware.ErrAccessDenied)
 		return
 	}
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, invalidParam("id"))
 		return
 	}
 
-	var payload struct {
-		Status string `json:"status"`
-	}
-
-	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+	var req CommentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
 		return
 	}
 
-	if err := h.service.SetStatus(id, payload.Status, role); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.SetStatus(c.Request.Context(), id, req.Status, actor); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.Status(http.StatusOK)
+	respondNoContent(c, nil)
 }
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"online_library/backend/internal/pkg/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondError передаёт ошибку middleware.Errors — оно выберет статус
+// и ответит application/problem+json.
+func respondError(c *gin.Context, err error) {
+	_ = c.Error(err)
+}
+
+// invalidParam — параметр пути или запроса не удалось разобрать.
+func invalidParam(name string) *apperr.Error {
+	return apperr.Validationf("invalid_parameter", "invalid %s", name)
+}
+
+// invalidBody — тело запроса не удалось разобрать или оно не прошло проверку
+// правил binding; во втором случае в ответе перечислены ошибки по полям.
+func invalidBody(err error) *apperr.Error {
+	if fields, ok := fieldErrors(err); ok {
+		e := errValidationFailed.WithCause(err)
+		e.Fields = fields
+		return e
+	}
+	return apperr.Validation("invalid_body", "invalid request body").WithCause(err)
+}
@@ -1 +1,42 @@
 package handlers
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"online_library/backend/internal/service"
+	"time"
+)
+
+// ReadinessTimeout ограничивает проверку готовности, чтобы зонд не висел
+// вместе с зависшей базой.
+const ReadinessTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	service service.HealthService
+	log     *slog.Logger
+}
+
+func NewHealthHandler(service service.HealthService, log *slog.Logger) *HealthHandler {
+	return &HealthHandler{service: service, log: log}
+}
+
+// GET /healthz — процесс жив и обрабатывает запросы, зависимости не проверяются
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GET /readyz — база доступна и схема актуальна, иначе 503
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), ReadinessTimeout)
+	defer cancel()
+
+	readiness := h.service.Readiness(ctx)
+	if !readiness.Ready {
+		h.log.WarnContext(c.Request.Context(), "not ready", "checks", readiness.Checks, "details", readiness.Details)
+		c.JSON(http.StatusServiceUnavailable, readiness)
+		return
+	}
+	c.JSON(http.StatusOK, readiness)
+}
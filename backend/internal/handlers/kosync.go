@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"online_library/backend/internal/middleware"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/service"
+	"strings"
+)
+
+// KosyncHandler реализует протокол KOReader progress sync
+// (https://github.com/koreader/koreader-sync-server), чтобы e-reader'ы
+// синхронизировались с нашим сервером: в KOReader указывается
+// пользовательский сервер http://<host>:8080/kosync.
+type KosyncHandler struct {
+	service service.ProgressService
+	log     *slog.Logger
+}
+
+type KosyncProgressRequest struct {
+	Document   string  `json:"document"`
+	Progress   string  `json:"progress"`
+	Percentage float64 `json:"percentage"`
+	Device     string  `json:"device"`
+	DeviceID   string  `json:"device_id"`
+}
+
+// Коды ошибок в духе koreader-sync-server, клиент показывает поле message
+const (
+	kosyncErrUnauthorized = 2001
+	kosyncErrRegistration = 2005
+	kosyncErrInvalidField = 2003
+	kosyncErrDocumentKey  = 2004
+)
+
+func NewKosyncHandler(service service.ProgressService, log *slog.Logger) *KosyncHandler {
+	return &KosyncHandler{service: service, log: log}
+}
+
+func kosyncError(c *gin.Context, status, code int, message string) {
+	c.AbortWithStatusJSON(status, gin.H{"code": code, "message": message})
+}
+
+// Auth — аналог AuthRequired для KOReader: заголовки x-auth-user (email) и x-auth-key (md5 пароля синхронизации).
+func (h *KosyncHandler) Auth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		email := c.GetHeader("x-auth-user")
+		key := c.GetHeader("x-auth-key")
+		if email == "" || key == "" {
+			kosyncError(c, http.StatusUnauthorized, kosyncErrUnauthorized, "Unauthorized")
+			return
+		}
+
+		user, err := h.service.AuthenticateSyncUser(c.Request.Context(), email, key)
+		if err != nil {
+			kosyncError(c, http.StatusUnauthorized, kosyncErrUnauthorized, "Unauthorized")
+			return
+		}
+
+		c.Set("userID", user.ID)
+		c.Set("role", user.Role)
+		c.Next()
+	}
+}
+
+// GET /kosync/healthcheck
+func (h *KosyncHandler) Healthcheck(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"state": "OK"})
+}
+
+// POST /kosync/users/create — регистрация только через сайт
+func (h *KosyncHandler) CreateUser(c *gin.Context) {
+	kosyncError(c, http.StatusPaymentRequired, kosyncErrRegistration,
+		"Registration is disabled. Register on the library site and set a sync password in your profile.")
+}
+
+// GET /kosync/users/auth
+func (h *KosyncHandler) AuthUser(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"authorized": "OK"})
+}
+
+// PUT /kosync/syncs/progress
+func (h *KosyncHandler) UpdateProgress(c *gin.Context) {
+	userID, _, ok := middleware.ExtractUser(c)
+	if !ok {
+		kosyncError(c, http.StatusUnauthorized, kosyncErrUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req KosyncProgressRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Progress == "" || req.Device == "" {
+		kosyncError(c, http.StatusForbidden, kosyncErrInvalidField, "Invalid request")
+		return
+	}
+	if req.Document == "" {
+		kosyncError(c, http.StatusForbidden, kosyncErrDocumentKey, "Field 'document' not provided.")
+		return
+	}
+
+	locatorType := models.LocatorPage
+	if strings.HasPrefix(req.Progress, "/") {
+		locatorType = models.LocatorXPointer
+	}
+
+	progress, err := h.service.SaveDocumentProgress(c.Request.Context(), req.Document, userID, service.ProgressInput{
+		Locator:     req.Progress,
+		LocatorType: locatorType,
+		Percentage:  req.Percentage,
+		Device:      req.Device,
+		DeviceID:    req.DeviceID,
+	})
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) && appErr.Kind == apperr.KindValidation {
+		kosyncError(c, http.StatusForbidden, kosyncErrInvalidField, appErr.Message)
+		return
+	}
+	if err != nil {
+		_ = c.Error(err) // в журнал её пишет middleware.Errors
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unknown server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"document": progress.Document, "timestamp": progress.UpdatedAt.Unix()})
+}
+
+// GET /kosync/syncs/progress/:document
+func (h *KosyncHandler) GetProgress(c *gin.Context) {
+	userID, _, ok := middleware.ExtractUser(c)
+	if !ok {
+		kosyncError(c, http.StatusUnauthorized, kosyncErrUnauthorized, "Unauthorized")
+		return
+	}
+
+	progress, err := h.service.GetDocumentProgress(c.Request.Context(), c.Param("document"), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusOK, gin.H{})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err) // в журнал её пишет middleware.Errors
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Unknown server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"document":   progress.Document,
+		"progress":   progress.Locator,
+		"percentage": progress.Percentage,
+		"device":     progress.Device,
+		"device_id":  progress.DeviceID,
+		"timestamp":  progress.UpdatedAt.Unix(),
+	})
+}
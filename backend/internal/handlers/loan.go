@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"online_library/backend/internal/middleware"
+	"online_library/backend/internal/service"
+	"strconv"
+)
+
+type LoanHandler struct {
+	service service.LoanService
+	log     *slog.Logger
+}
+
+type BookCopiesRequest struct {
+	Copies *int `json:"copies" binding:"omitempty,min=0"` // null — книга в открытом доступе
+}
+
+func NewLoanHandler(service service.LoanService, log *slog.Logger) *LoanHandler {
+	return &LoanHandler{service: service, log: log}
+}
+
+// POST /api/books/:book_id/copies
+func (h *LoanHandler) SetBookCopies(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+
+	var req BookCopiesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	if err := h.service.SetBookCopies(c.Request.Context(), bookID, req.Copies, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GET /api/books/:book_id/availability
+func (h *LoanHandler) GetAvailability(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+
+	availability, err := h.service.GetAvailability(c.Request.Context(), bookID, userID, userRole)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
+
+// POST /api/books/:book_id/checkout
+func (h *LoanHandler) Checkout(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+
+	loan, err := h.service.Checkout(c.Request.Context(), bookID, userID, userRole)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, loan)
+}
+
+// POST /api/books/:book_id/hold
+func (h *LoanHandler) PlaceHold(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+
+	hold, err := h.service.PlaceHold(c.Request.Context(), bookID, userID, userRole)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+// POST /api/books/:book_id/hold/cancel
+func (h *LoanHandler) CancelHold(c *gin.Context) {
+	userID, _, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+
+	if err := h.service.CancelHold(c.Request.Context(), bookID, userID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// POST /api/loans/:id/return
+func (h *LoanHandler) ReturnLoan(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	loanID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParam("id"))
+		return
+	}
+
+	if err := h.service.ReturnLoan(c.Request.Context(), loanID, userID, userRole); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// POST /api/loans/:id/renew
+func (h *LoanHandler) RenewLoan(c *gin.Context) {
+	userID, _, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	loanID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParam("id"))
+		return
+	}
+
+	loan, err := h.service.RenewLoan(c.Request.Context(), loanID, userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, loan)
+}
+
+// GET /api/users/me/loans
+func (h *LoanHandler) GetMyLoans(c *gin.Context) {
+	userID, _, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	loans, err := h.service.GetUserLoans(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, loans)
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"online_library/backend/internal/middleware"
+	"online_library/backend/internal/repository"
+	"online_library/backend/internal/service"
+	"strconv"
+)
+
+type ProgressHandler struct {
+	service service.ProgressService
+	log     *slog.Logger
+}
+
+type SyncPasswordRequest struct {
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+func NewProgressHandler(service service.ProgressService, log *slog.Logger) *ProgressHandler {
+	return &ProgressHandler{service: service, log: log}
+}
+
+// GET /api/progress — последние позиции чтения пользователя
+func (h *ProgressHandler) GetMyProgress(c *gin.Context) {
+	userID, _, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	progress, err := h.service.GetUserProgress(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// GET /api/progress/files/:file_id
+func (h *ProgressHandler) GetFileProgress(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	fileID, err := strconv.Atoi(c.Param("file_id"))
+	if err != nil {
+		respondError(c, invalidParam("file_id"))
+		return
+	}
+
+	progress, err := h.service.GetFileProgress(c.Request.Context(), fileID, userID, userRole)
+	if errors.Is(err, repository.ErrProgressNotFound) {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// POST /api/progress/files/:file_id
+func (h *ProgressHandler) SaveFileProgress(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	fileID, err := strconv.Atoi(c.Param("file_id"))
+	if err != nil {
+		respondError(c, invalidParam("file_id"))
+		return
+	}
+
+	var input service.ProgressInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	progress, err := h.service.SaveFileProgress(c.Request.Context(), fileID, userID, userRole, input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Если на сервере позиция свежее присланной, в ответе будет именно она
+	c.JSON(http.StatusOK, progress)
+}
+
+// GET /api/progress/files/:file_id/history?limit=&offset=
+func (h *ProgressHandler) GetFileProgressHistory(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	fileID, err := strconv.Atoi(c.Param("file_id"))
+	if err != nil {
+		respondError(c, invalidParam("file_id"))
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	history, err := h.service.GetFileProgressHistory(c.Request.Context(), fileID, userID, userRole, limit, offset)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// POST /api/progress/sync-password — пароль для e-reader клиентов (KOReader)
+func (h *ProgressHandler) SetSyncPassword(c *gin.Context) {
+	userID, _, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	var req SyncPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	if err := h.service.SetSyncPassword(c.Request.Context(), userID, req.Password); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
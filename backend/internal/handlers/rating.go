@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"online_library/backend/internal/middleware"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/service"
+	"strconv"
+)
+
+type RatingHandler struct {
+	service service.RatingService
+	log     *slog.Logger
+}
+
+type RateBookRequest struct {
+	Score int `json:"score" binding:"required,min=1,max=5"`
+}
+
+type ReviewRequest struct {
+	BookID int    `json:"book_id" binding:"required,min=1"`
+	Text   string `json:"text" binding:"required"`
+	Score  *int   `json:"score,omitempty" binding:"omitempty,min=1,max=5"` // необязательная оценка вместе с рецензией
+}
+
+func NewRatingHandler(service service.RatingService, log *slog.Logger) *RatingHandler {
+	return &RatingHandler{service: service, log: log}
+}
+
+// GET /api/books/:book_id/rating
+func (h *RatingHandler) GetRatingSummary(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+
+	summary, err := h.service.GetRatingSummary(c.Request.Context(), bookID, userID, userRole)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// POST /api/books/:book_id/rating
+func (h *RatingHandler) RateBook(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+
+	var req RateBookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	rating, err := h.service.RateBook(c.Request.Context(), bookID, req.Score, userID, userRole)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rating)
+}
+
+// POST /api/books/:book_id/rating/remove
+func (h *RatingHandler) RemoveRating(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+
+	if err := h.service.RemoveRating(c.Request.Context(), bookID, userID, userRole); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondNoContent(c, nil)
+}
+
+// GET /api/reviews/book/:book_id
+func (h *RatingHandler) GetReviewsByBook(c *gin.Context) {
+	_, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		respondError(c, invalidParam("book_id"))
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", DefaultLimit))
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	reviews, err := h.service.GetReviewsByBook(c.Request.Context(), bookID, userRole, limit, offset)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// POST /api/reviews — создание или обновление своей рецензии
+func (h *RatingHandler) SaveReview(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	var req ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	review := models.BookReview{
+		BookID: req.BookID,
+		UserID: userID,
+		Text:   req.Text,
+		Score:  req.Score,
+	}
+
+	if err := h.service.SaveReview(c.Request.Context(), &review, userRole); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// POST /api/reviews/:id/delete
+func (h *RatingHandler) DeleteReview(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParam("id"))
+		return
+	}
+
+	if err := h.service.DeleteReview(c.Request.Context(), id, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondNoContent(c, nil)
+}
+
+// POST /api/reviews/:id/status
+func (h *RatingHandler) SetReviewStatus(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParam("id"))
+		return
+	}
+
+	var req CommentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	if err := h.service.SetReviewStatus(c.Request.Context(), id, req.Status, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondNoContent(c, nil)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"online_library/backend/internal/middleware"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondNoContent — действие выполнено, возвращать нечего. В v2 это 204;
+// v1 по-прежнему отвечает 200 и прежним телом legacy (nil — без тела).
+func respondNoContent(c *gin.Context, legacy interface{}) {
+	switch {
+	case middleware.ExtractAPIVersion(c) >= middleware.APIv2:
+		c.Status(http.StatusNoContent)
+	case legacy == nil:
+		c.Status(http.StatusOK)
+	default:
+		c.JSON(http.StatusOK, legacy)
+	}
+}
+
+// respondWithETag отвечает JSON с сильным ETag: prefix (если задан) и хеш
+// тела. На GET с совпавшим If-None-Match тело не отправляется — 304.
+func respondWithETag(c *gin.Context, prefix string, obj interface{}) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	sum := sha256.Sum256(body)
+	tag := hex.EncodeToString(sum[:8])
+	if prefix != "" {
+		tag = prefix + "-" + tag
+	}
+	etag := strconv.Quote(tag)
+
+	c.Header("ETag", etag)
+	// Ответ зависит от роли пользователя: кешировать можно только в браузере
+	// и только с проверкой
+	c.Header("Cache-Control", "private, no-cache")
+	if (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) &&
+		etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// etagMatches — слабое сравнение для If-None-Match: список через запятую или *.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
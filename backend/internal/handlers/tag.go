@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
+	"log/slog"
 	"net/http"
+	"online_library/backend/internal/middleware"
 	"online_library/backend/internal/models"
 	"online_library/backend/internal/service"
 	"strconv"
@@ -11,113 +13,383 @@ import (
 
 type TagHandler struct {
 	tagService service.TagService
+	log        *slog.Logger
 }
 
-func NewTagHandler(tagService service.TagService) *TagHandler {
-	return &TagHandler{tagService: tagService}
+func NewTagHandler(tagService service.TagService, log *slog.Logger) *TagHandler {
+	return &TagHandler{tagService: tagService, log: log}
 }
 
+// GET /api/tags?query=&limit=&offset=
+// С query — автодополнение по префиксу и похожести, без — все теги, популярные первыми.
 func (h *TagHandler) SearchTags(c *gin.Context) {
-	query := strings.TrimSpace(c.Query("query"))
-
-	tags, err := h.tagService.GetAllTags()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tags"})
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
 
-	if query == "" {
-		c.JSON(http.StatusOK, tags)
-		return
+	query := strings.TrimSpace(c.Query("query"))
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", DefaultLimit))
+	if limit <= 0 || limit > MaxLimit {
+		limit = MaxLimit
 	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	var filtered []models.Tag
-	queryLower := strings.ToLower(query)
-	for _, tag := range tags {
-		if strings.Contains(strings.ToLower(tag.Name), queryLower) {
-			filtered = append(filtered, tag)
-		}
+	tags, err := h.tagService.SearchTags(c.Request.Context(), query, userID, userRole, limit, offset)
+	if err != nil {
+		respondError(c, err)
+		return
 	}
 
-	c.JSON(http.StatusOK, filtered)
+	c.JSON(http.StatusOK, tags)
 }
 
 func (h *TagHandler) GetTagByID(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
 	id, _ := strconv.Atoi(c.Param("id"))
-	tag, err := h.tagService.GetTagByID(id)
+	tag, err := h.tagService.GetTagByID(c.Request.Context(), id, userID, userRole)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, tag)
 }
 
+// TagRequest — название и цвет тега; статус и автора выставляет сервис.
+type TagRequest struct {
+	Name  string `json:"name" binding:"required,max=100"`
+	Color string `json:"color" binding:"omitempty,color"`
+}
+
+type BookTagRequest struct {
+	BookID  int    `json:"book_id" binding:"required,min=1"`
+	TagID   int    `json:"tag_id" binding:"omitempty,min=1"`
+	TagName string `json:"tag_name" binding:"required_without=TagID,max=100"` // вместо tag_id можно указать название или синоним
+	Weight  *int   `json:"weight" binding:"omitempty,oneof=0 1"`              // по умолчанию 1 (основной), как при создании книги
+}
+
+type SynonymRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type MergeTagsRequest struct {
+	SourceIDs []int `json:"source_ids" binding:"required,min=1,dive,min=1"`
+}
+
+type TagBlockRequest struct {
+	Pattern string `json:"pattern" binding:"required,max=100"`
+}
+
+type TagRejectRequest struct {
+	Reason string `json:"reason"`
+}
+
 func (h *TagHandler) CreateTag(c *gin.Context) {
-	var tag models.Tag
-	if err := c.ShouldBindJSON(&tag); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
-	if err := h.tagService.CreateTag(&tag); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create tag"})
+
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+	tag := models.Tag{Name: req.Name, Color: req.Color}
+	created, err := h.tagService.CreateTag(c.Request.Context(), &tag, userID, userRole)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !created {
+		// Такой тег или синоним уже есть — отдаём канонический
+		c.JSON(http.StatusOK, tag)
 		return
 	}
 	c.JSON(http.StatusCreated, tag)
 }
 
 func (h *TagHandler) UpdateTag(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
 	id, _ := strconv.Atoi(c.Param("id"))
-	var tag models.Tag
-	if err := c.ShouldBindJSON(&tag); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
 		return
 	}
-	tag.ID = id
-	if err := h.tagService.UpdateTag(&tag); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update tag"})
+	tag := models.Tag{ID: id, Name: req.Name, Color: req.Color}
+	if err := h.tagService.UpdateTag(c.Request.Context(), &tag, actor); err != nil {
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, tag)
 }
 
 func (h *TagHandler) DeleteTag(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
 	id, _ := strconv.Atoi(c.Param("id"))
-	if err := h.tagService.DeleteTag(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete tag"})
+	if err := h.tagService.DeleteTag(c.Request.Context(), id, actor); err != nil {
+		respondError(c, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
 func (h *TagHandler) GetTagsByBookID(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
 	bookID, _ := strconv.Atoi(c.Param("bookID"))
-	tags, err := h.tagService.GetTagsByBookID(bookID)
+	tags, err := h.tagService.GetTagsByBookID(c.Request.Context(), bookID, userID, userRole)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tags"})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, tags)
 }
 
 func (h *TagHandler) AssignTagToBook(c *gin.Context) {
-	var bt models.BookTag
-	if err := c.ShouldBindJSON(&bt); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
 		return
 	}
-	if err := h.tagService.AssignTagToBook(&bt); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to assign tag"})
+
+	var req BookTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+	bt := models.BookTag{BookID: req.BookID, TagID: req.TagID, TagName: req.TagName, Weight: models.TagWeightPrimary}
+	if req.Weight != nil {
+		bt.Weight = *req.Weight
+	}
+	if err := h.tagService.AssignTagToBook(c.Request.Context(), &bt, userID, userRole); err != nil {
+		respondError(c, err)
 		return
 	}
-	c.Status(http.StatusOK)
+	respondNoContent(c, nil)
 }
 
 func (h *TagHandler) RemoveTagFromBook(c *gin.Context) {
+	userID, userRole, ok := middleware.ExtractUser(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
 	bookID, _ := strconv.Atoi(c.Query("book_id"))
 	tagID, _ := strconv.Atoi(c.Query("tag_id"))
-	if err := h.tagService.RemoveTagFromBook(bookID, tagID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove tag"})
+	if err := h.tagService.RemoveTagFromBook(c.Request.Context(), bookID, tagID, userID, userRole); err != nil {
+		respondError(c, err)
+		return
+	}
+	respondNoContent(c, nil)
+}
+
+// POST /api/tags/:id/synonyms
+func (h *TagHandler) AddSynonym(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req SynonymRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+	synonym := models.TagSynonym{TagID: id, Name: req.Name}
+	if err := h.tagService.AddSynonym(c.Request.Context(), &synonym, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, synonym)
+}
+
+// POST /api/tags/:id/synonyms/:synonym_id/remove
+func (h *TagHandler) RemoveSynonym(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	synonymID, _ := strconv.Atoi(c.Param("synonym_id"))
+	if err := h.tagService.RemoveSynonym(c.Request.Context(), id, synonymID, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// POST /api/tags/:id/merge
+func (h *TagHandler) MergeTags(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req MergeTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+	books, err := h.tagService.MergeTags(c.Request.Context(), id, req.SourceIDs, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"books_affected": books})
+}
+
+// GET /api/tags/cloud?category_id=&limit=
+func (h *TagHandler) GetTagCloud(c *gin.Context) {
+	var categoryID *int
+	if raw := c.Query("category_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			respondError(c, invalidParam("category_id"))
+			return
+		}
+		categoryID = &id
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	cloud, err := h.tagService.GetTagCloud(c.Request.Context(), categoryID, limit)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
-	c.Status(http.StatusOK)
+	c.JSON(http.StatusOK, cloud)
+}
+
+// GET /api/tags/proposed
+func (h *TagHandler) GetProposedTags(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", DefaultLimit))
+	if limit <= 0 || limit > MaxLimit {
+		limit = MaxLimit
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	tags, err := h.tagService.GetProposedTags(c.Request.Context(), limit, offset)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+// POST /api/tags/:id/approve
+func (h *TagHandler) ApproveTag(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := h.tagService.ApproveTag(c.Request.Context(), id, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// POST /api/tags/:id/reject
+func (h *TagHandler) RejectTag(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req TagRejectRequest
+	// Причина необязательна, тело может быть пустым
+	_ = c.ShouldBindJSON(&req)
+
+	if err := h.tagService.RejectTag(c.Request.Context(), id, req.Reason, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GET /api/tags/blocklist
+func (h *TagHandler) GetBlocklist(c *gin.Context) {
+	blocks, err := h.tagService.GetBlocklist(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, blocks)
+}
+
+// POST /api/tags/blocklist
+func (h *TagHandler) AddToBlocklist(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	var req TagBlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+	block := models.TagBlock{Pattern: req.Pattern}
+
+	if err := h.tagService.AddToBlocklist(c.Request.Context(), &block, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, block)
+}
+
+// POST /api/tags/blocklist/:id/remove
+func (h *TagHandler) RemoveFromBlocklist(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := h.tagService.RemoveFromBlocklist(c.Request.Context(), id, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
 }
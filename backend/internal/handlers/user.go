@@ -1,46 +1,139 @@
 package handlers
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
+	"log/slog"
 	"net/http"
+	"online_library/backend/internal/middleware"
+	"online_library/backend/internal/models"
 	"online_library/backend/internal/service"
+	"strconv"
 )
 
 type UserHandler struct {
 	service service.UserService
+	log     *slog.Logger
 }
 
-func NewUserHandler(s service.UserService) *UserHandler {
-	return &UserHandler{service: s}
+func NewUserHandler(s service.UserService, log *slog.Logger) *UserHandler {
+	return &UserHandler{service: s, log: log}
 }
 
 // админ / суперадмин
 func (h *UserHandler) GetUsers(c *gin.Context) {
 	users, err := h.service.GetAllUsers(c.Request.Context())
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "cannot fetch users"})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, users)
 }
 
 // админ / суперадмин
-func (h *UserHandler) СreateUser(c *gin.Context) {
-	// заглушка
-	c.JSON(http.StatusCreated, gin.H{"message": "User created"})
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	var input models.UserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	user, err := h.service.CreateUser(c.Request.Context(), input, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, user)
 }
 
 // владелец или админ/ суперадмин
 func (h *UserHandler) UpdateUser(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "User updated"})
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParam("id"))
+		return
+	}
+
+	var input models.UserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	user, err := h.service.UpdateUser(c.Request.Context(), id, input, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// админ / суперадмин; роли admin и superadmin меняет только суперадмин
+func (h *UserHandler) AdminUpdateUser(c *gin.Context) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParam("id"))
+		return
+	}
+
+	var input models.AdminUserUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, invalidBody(err))
+		return
+	}
+
+	user, err := h.service.UpdateUserByAdmin(c.Request.Context(), id, input, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
 }
 
 // только админы
 func (h *UserHandler) SoftDeleteUser(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+	h.deleteUser(c, h.service.SoftDeleteUser)
 }
 
 // Только для суперадмина
 func (h *UserHandler) HardDeleteUser(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+	h.deleteUser(c, h.service.HardDeleteUser)
+}
+
+func (h *UserHandler) deleteUser(c *gin.Context, del func(ctx context.Context, id int, actor models.Actor) error) {
+	actor, ok := middleware.ExtractActor(c)
+	if !ok {
+		respondError(c, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParam("id"))
+		return
+	}
+
+	if err := del(c.Request.Context(), id, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+	respondNoContent(c, gin.H{"message": "User deleted"})
 }
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var errValidationFailed = apperr.Validation("validation_failed", "request validation failed")
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	// В ошибках поля называются так же, как в JSON запроса
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	_ = v.RegisterValidation("color", func(fl validator.FieldLevel) bool {
+		return models.IsHexColor(fl.Field().String())
+	})
+	// Год издания — не раньше первого и не позже следующего года (анонсы)
+	_ = v.RegisterValidation("year", func(fl validator.FieldLevel) bool {
+		y := fl.Field().Int()
+		return y >= 1 && y <= int64(maxPublishYear())
+	})
+}
+
+func maxPublishYear() int {
+	return time.Now().Year() + 1
+}
+
+// fieldErrors переводит ошибки валидатора и несовпадение типов JSON в ошибки
+// полей; ok = false, если err — что-то другое (например, битый JSON).
+func fieldErrors(err error) ([]apperr.FieldError, bool) {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		fields := make([]apperr.FieldError, 0, len(verrs))
+		for _, fe := range verrs {
+			fields = append(fields, fieldError(fe))
+		}
+		return fields, true
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []apperr.FieldError{apperr.NewFieldError(typeErr.Field, apperr.FieldInvalid, "")}, true
+	}
+	return nil, false
+}
+
+// fieldError — правило валидатора в терминах API: коды не зависят от
+// названий тегов validator и различают длину строки и величину числа.
+func fieldError(fe validator.FieldError) apperr.FieldError {
+	field := fieldPath(fe.Namespace())
+	isString := fe.Kind() == reflect.String
+
+	switch fe.Tag() {
+	case "required", "required_without":
+		return apperr.NewFieldError(field, apperr.FieldRequired, "")
+	case "email":
+		return apperr.NewFieldError(field, apperr.FieldEmail, "")
+	case "url":
+		return apperr.NewFieldError(field, apperr.FieldURL, "")
+	case "color":
+		return apperr.NewFieldError(field, apperr.FieldColor, "")
+	case "year":
+		return apperr.NewFieldError(field, apperr.FieldYear, strconv.Itoa(maxPublishYear()))
+	case "oneof":
+		return apperr.NewFieldError(field, apperr.FieldOneOf, strings.Join(strings.Fields(fe.Param()), ", "))
+	case "min", "gte":
+		if isString {
+			return apperr.NewFieldError(field, apperr.FieldMinLength, fe.Param())
+		}
+		if fe.Kind() == reflect.Slice {
+			return apperr.NewFieldError(field, apperr.FieldMinItems, fe.Param())
+		}
+		return apperr.NewFieldError(field, apperr.FieldMin, fe.Param())
+	case "max", "lte":
+		if isString {
+			return apperr.NewFieldError(field, apperr.FieldMaxLength, fe.Param())
+		}
+		return apperr.NewFieldError(field, apperr.FieldMax, fe.Param())
+	}
+	return apperr.NewFieldError(field, apperr.FieldInvalid, "")
+}
+
+// fieldPath оставляет в пути поля только имена из JSON: имя структуры запроса
+// и встроенных структур (они с заглавной буквы) убираются —
+// "CreateBookRequest.AuthorListRequest.authors[0].author_id" → "authors[0].author_id".
+func fieldPath(namespace string) string {
+	parts := strings.Split(namespace, ".")
+	path := parts[:0]
+	for _, p := range parts {
+		if p != "" && !unicode.IsUpper([]rune(p)[0]) {
+			path = append(path, p)
+		}
+	}
+	return strings.Join(path, ".")
+}
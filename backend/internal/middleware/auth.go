@@ -2,31 +2,39 @@ package middleware
 
 import (
 	"fmt"
-	"net/http"
 	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
 	"online_library/backend/internal/pkg/auth"
+	"online_library/backend/internal/pkg/logger"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+var (
+	errMissingToken       = apperr.Unauthorized("missing_token", "missing or invalid token")
+	ErrAdminRequired      = apperr.Forbidden("admin_required", "admin access required")
+	errSuperAdminRequired = apperr.Forbidden("superadmin_required", "superadmin access required")
+)
+
 func AuthRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		header := c.GetHeader("Authorization")
 		if header == "" || !strings.HasPrefix(header, "Bearer ") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid token"})
+			AbortWithError(c, errMissingToken)
 			return
 		}
 		tokenStr := strings.TrimPrefix(header, "Bearer ")
 
 		claims, err := auth.ParseToken(tokenStr)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			AbortWithError(c, ErrUnauthorized)
 			return
 		}
 
 		c.Set("userID", claims.UserID)
 		c.Set("role", claims.Role)
+		c.Request = c.Request.WithContext(logger.WithUserID(c.Request.Context(), claims.UserID))
 
 		c.Next()
 	}
@@ -37,7 +45,7 @@ func AdminOnly() gin.HandlerFunc {
 		roleVal, ok := c.Get("role")
 		role, _ := roleVal.(string)
 		if !ok || (role != models.RoleAdmin && role != models.RoleSuperAdmin) {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+			AbortWithError(c, ErrAdminRequired)
 			return
 		}
 		c.Next()
@@ -48,22 +56,24 @@ func SuperAdminOnly() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
 		if !exists || role != models.RoleSuperAdmin {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "superadmin access required"})
+			AbortWithError(c, errSuperAdminRequired)
 			return
 		}
 		c.Next()
 	}
 }
 
-func OwnerOrAdmin() gin.HandlerFunc {
+// OwnerOrAdmin пропускает админа или пользователя, чей id совпадает с параметром пути param.
+// Для ресурсов (книги, комментарии) владельца проверяет сервис.
+func OwnerOrAdmin(param string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDFromToken, ok := c.Get("userID")
 		roleVal, _ := c.Get("role")
 		role, _ := roleVal.(string)
 
-		paramID := c.Param("userID") // string
+		paramID := c.Param(param)
 		if !ok || (fmt.Sprintf("%v", userIDFromToken) != paramID && !IsAdmin(role)) {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			AbortWithError(c, ErrAccessDenied)
 			return
 		}
 		c.Next()
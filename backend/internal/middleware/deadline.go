@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"online_library/backend/internal/pkg/apperr"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatusClientClosedRequest — нестандартный код (как у nginx) для запросов,
+// клиент которых отключился, не дождавшись ответа. Клиент его не увидит, он нужен
+// журналу и метрикам.
+const StatusClientClosedRequest = 499
+
+// DefaultRequestTimeout — срок на обработку запроса, если не задан REQUEST_TIMEOUT.
+const DefaultRequestTimeout = 15 * time.Second
+
+// Deadline ограничивает контекст запроса сроком timeout: SQL-запросы, начатые
+// из обработчика, отменяются вместе с ним. Если к моменту ответа с ошибкой срок
+// истёк или клиент отключился, ответ заменяется на 504 или 499 — вместо
+// случайной ошибки драйвера об отменённом запросе.
+func Deadline(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Writer = &deadlineWriter{ResponseWriter: c.Writer, ctx: ctx, req: c.Request, requestID: c.GetString("requestID")}
+		c.Next()
+	}
+}
+
+type deadlineWriter struct {
+	gin.ResponseWriter
+	ctx       context.Context
+	req       *http.Request
+	requestID string
+	replaced  bool
+	written   bool
+}
+
+func (w *deadlineWriter) WriteHeader(code int) {
+	if code >= http.StatusBadRequest && w.ctx.Err() != nil {
+		code = cancelledStatus(w.ctx.Err())
+		w.replaced = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write при подменённом статусе отбрасывает тело обработчика и пишет своё.
+func (w *deadlineWriter) Write(b []byte) (int, error) {
+	if !w.replaced {
+		return w.ResponseWriter.Write(b)
+	}
+	if !w.written {
+		w.written = true
+		body, _ := json.Marshal(newProblem(w.req, w.requestID, cancelledError(w.ctx.Err())))
+		w.Header().Set("Content-Type", ProblemContentType)
+		if _, err := w.ResponseWriter.Write(body); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (w *deadlineWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func cancelledStatus(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return StatusClientClosedRequest
+}
+
+func cancelledError(err error) *apperr.Error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errRequestTimedOut
+	}
+	return errRequestCancelled
+}
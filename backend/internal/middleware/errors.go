@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"online_library/backend/internal/pkg/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType — тип ответа об ошибке по RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+var (
+	ErrUnauthorized = apperr.Unauthorized("unauthorized", "unauthorized")
+	ErrAccessDenied = apperr.Forbidden("access_denied", "access denied")
+
+	errInternal         = apperr.New(apperr.KindInternal, "internal_error", "internal server error")
+	errNotFound         = apperr.NotFound("not_found", "resource not found")
+	errRequestTimedOut  = apperr.New(apperr.KindTimeout, "request_timeout", "request timed out")
+	errRequestCancelled = apperr.New(apperr.KindCanceled, "request_canceled", "request canceled by client")
+)
+
+// Problem — тело ответа об ошибке. code и request_id — расширения RFC 7807:
+// по code клиент различает ошибки, по request_id запрос находится в журнале.
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	Code      string              `json:"code"`
+	RequestID string              `json:"request_id,omitempty"`
+	Errors    []apperr.FieldError `json:"errors,omitempty"`
+}
+
+// Errors отвечает на последнюю ошибку из c.Errors, если обработчик сам ничего
+// не записал. Обработчики только передают ошибку через c.Error — статус выбирается
+// по виду доменной ошибки, а текст внутренних ошибок клиенту не показывается.
+// Здесь же ошибка один раз пишется в журнал: request_id и user_id добавляет
+// обработчик журнала из контекста запроса.
+func Errors(log *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			return
+		}
+		err := c.Errors.Last().Err
+		logError(c, log, err)
+		if !c.Writer.Written() {
+			writeProblem(c, err)
+		}
+	}
+}
+
+// logError — внутренние ошибки с причиной на уровне error, ошибки клиента — warn.
+func logError(c *gin.Context, log *slog.Logger, err error) {
+	e := problemFor(err)
+	level := slog.LevelWarn
+	if e.Kind.Status() >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	log.Log(c.Request.Context(), level, "request failed",
+		"code", e.Code, "status", e.Kind.Status(), "route", c.FullPath(), "error", err)
+}
+
+// AbortWithError прерывает цепочку обработчиков с ошибкой, ответ пишет Errors.
+func AbortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// problemFor переводит ошибку в доменную: всё, что не *apperr.Error, —
+// внутренняя ошибка с общим текстом.
+func problemFor(err error) *apperr.Error {
+	if e, ok := apperr.As(err); ok {
+		return e
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return errNotFound
+	}
+	return errInternal
+}
+
+func writeProblem(c *gin.Context, err error) {
+	e := problemFor(err)
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(e.Kind.Status(), newProblem(c.Request, c.GetString("requestID"), e))
+}
+
+func newProblem(r *http.Request, requestID string, e *apperr.Error) Problem {
+	lang := apperr.PreferredLanguage(r.Header.Get("Accept-Language"))
+	return Problem{
+		Type:      "/problems/" + e.Code,
+		Title:     apperr.Title(e.Kind, lang),
+		Status:    e.Kind.Status(),
+		Detail:    e.Localize(lang),
+		Instance:  r.URL.Path,
+		Code:      e.Code,
+		RequestID: requestID,
+		Errors:    e.LocalizeFields(lang),
+	}
+}
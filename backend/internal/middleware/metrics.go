@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"online_library/backend/internal/pkg/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics замеряет время ответа. Метка route — шаблон маршрута, чтобы
+// /api/books/1 и /api/books/2 попадали в один ряд; несуществующие пути
+// собираются под "unmatched".
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequestDuration.
+			WithLabelValues(route, c.Request.Method, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
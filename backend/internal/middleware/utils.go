@@ -25,3 +25,17 @@ func ExtractUser(c *gin.Context) (int, string, bool) {
 	}
 	return userID, role, true
 }
+
+// ExtractActor — текущий пользователь вместе с IP и id запроса, для журнала аудита.
+func ExtractActor(c *gin.Context) (models.Actor, bool) {
+	userID, role, ok := ExtractUser(c)
+	if !ok {
+		return models.Actor{}, false
+	}
+	return models.Actor{
+		ID:        userID,
+		Role:      role,
+		IP:        c.ClientIP(),
+		RequestID: c.GetString("requestID"),
+	}, true
+}
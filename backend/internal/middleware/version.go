@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Версии API. Маршруты без APIVersion относятся к v1.
+const (
+	APIv1 = 1
+	APIv2 = 2
+)
+
+// APIVersion запоминает версию API группы маршрутов. Обработчики общие для
+// обеих версий и по ней выбирают коды ответа там, где v1 отвечает по-старому.
+func APIVersion(version int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("apiVersion", version)
+		c.Next()
+	}
+}
+
+// ExtractAPIVersion — версия API текущего маршрута.
+func ExtractAPIVersion(c *gin.Context) int {
+	if v, ok := c.Get("apiVersion"); ok {
+		if version, ok := v.(int); ok {
+			return version
+		}
+	}
+	return APIv1
+}
+
+// Deprecated помечает ответы устаревшей версии заголовком Deprecation
+// (RFC 9745) с датой, с которой версия устарела, и ссылкой на документацию.
+func Deprecated(since time.Time, docs string) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(since.Unix(), 10)
+	link := "<" + docs + `>; rel="deprecation"`
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		c.Header("Link", link)
+		c.Next()
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+const (
+	AnnotationHighlight = "highlight"
+	AnnotationBookmark  = "bookmark"
+	AnnotationNote      = "note"
+)
+
+type Annotation struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	BookID      int       `json:"book_id"`
+	BookFileID  *int      `json:"book_file_id,omitempty"`
+	Kind        string    `json:"kind"`
+	Locator     string    `json:"locator"`
+	LocatorType string    `json:"locator_type"`
+	Excerpt     *string   `json:"excerpt,omitempty"`
+	Color       *string   `json:"color,omitempty"`
+	Note        *string   `json:"note,omitempty"`
+	IsPublic    bool      `json:"is_public"` // по умолчанию пометки видны только автору
+	Status      string    `json:"status"`    // статусы модерации комментариев
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Действия, которые пишутся в журнал аудита
+const (
+	AuditBookCreate        = "book.create"
+	AuditBookUpdate        = "book.update"
+	AuditBookPatch         = "book.patch"
+	AuditBookDelete        = "book.delete"
+	AuditBookStatus        = "book.status"
+	AuditBookRollback      = "book.rollback"
+	AuditBookEditAccept    = "book.edit_accept"
+	AuditBookAuthors       = "book.authors"
+	AuditBookTags          = "book.tags"
+	AuditBookCopies        = "book.copies"
+	AuditTagUpdate         = "tag.update"
+	AuditTagApprove        = "tag.approve"
+	AuditTagReject         = "tag.reject"
+	AuditTagDelete         = "tag.delete"
+	AuditTagMerge          = "tag.merge"
+	AuditTagSynonymAdd     = "tag.synonym_add"
+	AuditTagSynonymRemove  = "tag.synonym_remove"
+	AuditTagBlockAdd       = "tag.block_add"
+	AuditTagBlockRemove    = "tag.block_remove"
+	AuditAuthorCreate      = "author.create"
+	AuditAuthorUpdate      = "author.update"
+	AuditAuthorDelete      = "author.delete"
+	AuditAuthorAliasRemove = "author.alias_remove"
+	AuditCategoryCreate    = "category.create"
+	AuditCategoryUpdate    = "category.update"
+	AuditCategoryDelete    = "category.delete"
+	AuditUserCreate        = "user.create"
+	AuditUserUpdate        = "user.admin_update"
+	AuditUserProfile       = "user.update"
+	AuditUserSoftDelete    = "user.soft_delete"
+	AuditUserHardDelete    = "user.hard_delete"
+	AuditCommentStatus     = "comment.status"
+	AuditCommentDelete     = "comment.delete"
+	AuditReviewStatus      = "review.status"
+	AuditAnnotationStatus  = "annotation.status"
+)
+
+// Actor — кто выполняет действие и откуда пришёл запрос.
+type Actor struct {
+	ID        int
+	Role      string
+	IP        string
+	RequestID string
+}
+
+// AuditEntry — запись журнала аудита. Before/After — состояние объекта
+// до и после изменения, null для создания и удаления соответственно.
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	ActorID    *int            `json:"actor_id,omitempty"`
+	ActorRole  string          `json:"actor_role"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   int             `json:"target_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	IP         *string         `json:"ip,omitempty"`
+	RequestID  *string         `json:"request_id,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AuditFilter — фильтры выборки журнала, пустые поля не учитываются.
+type AuditFilter struct {
+	ActorID    int
+	Action     string
+	TargetType string
+	TargetID   int
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
@@ -1,9 +1,65 @@
 package models
 
+import "time"
+
 type Author struct {
-	ID       int     `json:"id"`
-	NameRU   string  `json:"name_ru"`
-	NameEN   string  `json:"name_en"`
-	Bio      *string `json:"bio,omitempty"`
-	PhotoURL *string `json:"photo_url,omitempty"`
+	ID       int           `json:"id"`
+	NameRU   string        `json:"name_ru"`
+	NameEN   string        `json:"name_en"`
+	Bio      *string       `json:"bio,omitempty"`
+	PhotoURL *string       `json:"photo_url,omitempty"`
+	Aliases  []AuthorAlias `json:"aliases,omitempty"`
+}
+
+const (
+	AliasPseudonym = "pseudonym"
+	AliasSpelling  = "spelling" // альтернативное написание
+	AliasTranslit  = "translit" // вариант транслитерации
+)
+
+type AuthorAlias struct {
+	ID       int    `json:"id"`
+	AuthorID int    `json:"author_id"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+}
+
+// CoAuthor — автор, с которым есть общие книги.
+type CoAuthor struct {
+	Author
+	SharedBooks int `json:"shared_books"`
+}
+
+// AuthorWorks — книги автора в одной роли (например, переводы).
+type AuthorWorks struct {
+	Role  string `json:"role"`
+	Count int    `json:"count"`
+	Books []Book `json:"books"`
+}
+
+// AuthorDetails — страница автора: профиль, библиография по ролям и соавторы.
+type AuthorDetails struct {
+	Author
+	BooksCount int           `json:"books_count"`
+	Works      []AuthorWorks `json:"works"`
+	CoAuthors  []CoAuthor    `json:"co_authors"`
+}
+
+// AuthorMerge — запись журнала: source-автор был слит в target.
+type AuthorMerge struct {
+	ID             int       `json:"id"`
+	TargetAuthorID *int      `json:"target_author_id"`
+	SourceAuthorID int       `json:"source_author_id"`
+	SourceNameRU   string    `json:"source_name_ru"`
+	SourceNameEN   string    `json:"source_name_en"`
+	BooksMoved     int       `json:"books_moved"`
+	MergedBy       *int      `json:"merged_by"`
+	MergedAt       time.Time `json:"merged_at"`
+}
+
+// DuplicateAuthors — пара авторов с похожими именами.
+type DuplicateAuthors struct {
+	First      Author  `json:"first"`
+	Second     Author  `json:"second"`
+	Similarity float64 `json:"similarity"`
 }
@@ -11,9 +11,35 @@ type Book struct {
 	Language    *string   `json:"language,omitempty"`
 	Publisher   *string   `json:"publisher,omitempty"`
 	Type        *string   `json:"type,omitempty"`
-	Rating      int       `json:"rating"`
+	RatingAvg   float64   `json:"rating_avg"` // считается по book_ratings, не редактируется
+	RatingCount int       `json:"rating_count"`
 	CoverURL    *string   `json:"cover_url,omitempty"`
-	Status      string    `json:"status"` // "visible", "archived", "quarantine", "adult"
+	Status      string    `json:"status"` // см. book_status.go
 	CreatedBy   int       `json:"created_by"`
 	CreatedAt   time.Time `json:"created_at"`
+	Version     int       `json:"version"` // растёт с каждой правкой, отдаётся как ETag
+
+	Contributors []Contributor `json:"contributors,omitempty"` // заполняется только в карточке книги
 }
+
+// Типы изданий, как в CHECK на books.type
+const (
+	BookTypeBook    = "book"
+	BookTypeJournal = "journal"
+	BookTypeArticle = "article"
+	BookTypeOther   = "other"
+)
+
+// BookRelations — связи, которые создаются вместе с книгой; nil — не задавать.
+type BookRelations struct {
+	Authors     []BookContributor
+	Tags        []BookTag
+	CategoryIDs []int
+}
+
+// Варианты сортировки выдачи книг
+const (
+	BookSortNewest    = "newest"
+	BookSortRating    = "rating"
+	BookSortRelevance = "relevance" // совпадение в названии, затем по тегам с учётом веса
+)
@@ -0,0 +1,35 @@
+package models
+
+// Роли участников книги
+const (
+	ContributorAuthor      = "author"
+	ContributorTranslator  = "translator"
+	ContributorEditor      = "editor"
+	ContributorIllustrator = "illustrator"
+)
+
+// ContributorRoles — все роли в порядке вывода на странице автора.
+var ContributorRoles = []string{ContributorAuthor, ContributorTranslator, ContributorEditor, ContributorIllustrator}
+
+func IsContributorRole(role string) bool {
+	for _, r := range ContributorRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// BookContributor — связь книги с автором: роль и позиция на обложке.
+type BookContributor struct {
+	AuthorID int    `json:"author_id" binding:"required,min=1"`
+	Role     string `json:"role" binding:"omitempty,oneof=author translator editor illustrator"` // по умолчанию author
+	Position int    `json:"position" binding:"min=0"`
+}
+
+// Contributor — участник книги для вывода вместе с карточкой автора.
+type Contributor struct {
+	Author
+	Role     string `json:"role"`
+	Position int    `json:"position"`
+}
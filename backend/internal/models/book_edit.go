@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Статусы предложенных правок
+const (
+	EditStatusPending  = "pending"
+	EditStatusAccepted = "accepted"
+	EditStatusRejected = "rejected"
+)
+
+// BookPatch — частичное изменение книги: nil-поле остаётся как есть.
+type BookPatch struct {
+	Title       *string `json:"title,omitempty" binding:"omitempty,min=1,max=255"`
+	Description *string `json:"description,omitempty"`
+	PublishYear *int    `json:"publish_year,omitempty" binding:"omitempty,year"`
+	Pages       *int    `json:"pages,omitempty" binding:"omitempty,min=1"`
+	Language    *string `json:"language,omitempty" binding:"omitempty,max=100"`
+	Publisher   *string `json:"publisher,omitempty" binding:"omitempty,max=255"`
+	Type        *string `json:"type,omitempty" binding:"omitempty,oneof=book journal article other"`
+	CoverURL    *string `json:"cover_url,omitempty" binding:"omitempty,url,max=512"`
+}
+
+// BookEditChanges — предлагаемые значения полей книги. Пустое поле не меняется,
+// authors и tags, если указаны, заменяют текущий список целиком; указатели
+// отличают отсутствующий список от пустого («убрать всех»).
+type BookEditChanges struct {
+	BookPatch
+	Authors *[]BookContributor `json:"authors,omitempty" binding:"omitempty,dive"`
+	Tags    *[]BookTag         `json:"tags,omitempty" binding:"omitempty,dive"`
+}
+
+// FieldDiff — отличие одного поля: текущее значение и предложенное.
+type FieldDiff struct {
+	Field    string      `json:"field"`
+	Current  interface{} `json:"current"`
+	Proposed interface{} `json:"proposed"`
+}
+
+type BookEditProposal struct {
+	ID            int             `json:"id"`
+	BookID        int             `json:"book_id"`
+	BookTitle     string          `json:"book_title"`
+	ProposedBy    *int            `json:"proposed_by,omitempty"`
+	ProposerName  *string         `json:"proposer_name,omitempty"`
+	Changes       BookEditChanges `json:"changes"`
+	Comment       *string         `json:"comment,omitempty"`
+	Status        string          `json:"status"`
+	ReviewedBy    *int            `json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time      `json:"reviewed_at,omitempty"`
+	ReviewComment *string         `json:"review_comment,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+
+	Diff []FieldDiff `json:"diff,omitempty"` // сравнение с текущей книгой, только для ожидающих
+}
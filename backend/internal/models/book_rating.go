@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+const (
+	RatingMinScore = 1
+	RatingMaxScore = 5
+)
+
+type BookRating struct {
+	UserID    int       `json:"user_id"`
+	BookID    int       `json:"book_id"`
+	Score     int       `json:"score"` // 1–5
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type RatingSummary struct {
+	BookID         int         `json:"book_id"`
+	Average        float64     `json:"average"`
+	Count          int         `json:"count"`
+	WeightedRating float64     `json:"weighted_rating"`    // байесовская оценка, используется для сортировки
+	Distribution   map[int]int `json:"distribution"`       // количество оценок по звёздам
+	MyScore        *int        `json:"my_score,omitempty"` // оценка текущего пользователя
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type BookReview struct {
+	ID        int       `json:"id"`
+	BookID    int       `json:"book_id"`
+	UserID    int       `json:"user_id"`
+	Text      string    `json:"text"`
+	Score     *int      `json:"score,omitempty"` // оценка автора рецензии, если она выставлена
+	Status    string    `json:"status"`          // те же статусы, что и у комментариев
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
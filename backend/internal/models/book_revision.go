@@ -0,0 +1,110 @@
+package models
+
+import "time"
+
+// BookSnapshot — редактируемые поля книги на момент ревизии.
+type BookSnapshot struct {
+	Title       string  `json:"title"`
+	Description *string `json:"description,omitempty"`
+	PublishYear *int    `json:"publish_year,omitempty"`
+	Pages       *int    `json:"pages,omitempty"`
+	Language    *string `json:"language,omitempty"`
+	Publisher   *string `json:"publisher,omitempty"`
+	Type        *string `json:"type,omitempty"`
+	CoverURL    *string `json:"cover_url,omitempty"`
+}
+
+func SnapshotOf(b *Book) BookSnapshot {
+	return BookSnapshot{
+		Title:       b.Title,
+		Description: b.Description,
+		PublishYear: b.PublishYear,
+		Pages:       b.Pages,
+		Language:    b.Language,
+		Publisher:   b.Publisher,
+		Type:        b.Type,
+		CoverURL:    b.CoverURL,
+	}
+}
+
+// With — снимок после частичного изменения: nil-поля патча остаются как были.
+func (s BookSnapshot) With(p BookPatch) BookSnapshot {
+	if p.Title != nil {
+		s.Title = *p.Title
+	}
+	if p.Description != nil {
+		s.Description = p.Description
+	}
+	if p.PublishYear != nil {
+		s.PublishYear = p.PublishYear
+	}
+	if p.Pages != nil {
+		s.Pages = p.Pages
+	}
+	if p.Language != nil {
+		s.Language = p.Language
+	}
+	if p.Publisher != nil {
+		s.Publisher = p.Publisher
+	}
+	if p.Type != nil {
+		s.Type = p.Type
+	}
+	if p.CoverURL != nil {
+		s.CoverURL = p.CoverURL
+	}
+	return s
+}
+
+// ApplyTo записывает в книгу все поля снимка, в том числе пустые (NULL).
+func (s BookSnapshot) ApplyTo(b *Book) {
+	b.Title = s.Title
+	b.Description = s.Description
+	b.PublishYear = s.PublishYear
+	b.Pages = s.Pages
+	b.Language = s.Language
+	b.Publisher = s.Publisher
+	b.Type = s.Type
+	b.CoverURL = s.CoverURL
+}
+
+// Diff — поля, которыми target отличается от s; очищенное поле тоже изменение.
+func (s BookSnapshot) Diff(target BookSnapshot) []FieldDiff {
+	var diff []FieldDiff
+	diff = appendFieldDiff(diff, "title", &s.Title, &target.Title)
+	diff = appendFieldDiff(diff, "description", s.Description, target.Description)
+	diff = appendFieldDiff(diff, "publish_year", s.PublishYear, target.PublishYear)
+	diff = appendFieldDiff(diff, "pages", s.Pages, target.Pages)
+	diff = appendFieldDiff(diff, "language", s.Language, target.Language)
+	diff = appendFieldDiff(diff, "publisher", s.Publisher, target.Publisher)
+	diff = appendFieldDiff(diff, "type", s.Type, target.Type)
+	diff = appendFieldDiff(diff, "cover_url", s.CoverURL, target.CoverURL)
+	return diff
+}
+
+func appendFieldDiff[T comparable](diff []FieldDiff, field string, current, target *T) []FieldDiff {
+	if current == nil && target == nil || current != nil && target != nil && *current == *target {
+		return diff
+	}
+	return append(diff, FieldDiff{Field: field, Current: valueOf(current), Proposed: valueOf(target)})
+}
+
+func valueOf[T any](p *T) interface{} {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+// BookRevision — версия книги: кто и что изменил, и полный снимок после изменения.
+type BookRevision struct {
+	ID          int          `json:"id"`
+	BookID      int          `json:"book_id"`
+	Version     int          `json:"version"`
+	ChangedBy   *int         `json:"changed_by,omitempty"`
+	ChangerName *string      `json:"changer_name,omitempty"`
+	ChangedAt   time.Time    `json:"changed_at"`
+	Changes     []FieldDiff  `json:"changes"`
+	Note        *string      `json:"note,omitempty"` // "откат к версии 3", "правка #12"
+	Snapshot    BookSnapshot `json:"snapshot"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "testing"
+
+func TestRollbackToSnapshotWithNullFields(t *testing.T) {
+	desc, year, lang := "Роман", 1866, "ru"
+	book := &Book{Title: "Преступление и наказание", Description: &desc, PublishYear: &year, Language: &lang}
+	revision := BookSnapshot{Title: "Преступление и наказание", Language: &lang}
+
+	diff := SnapshotOf(book).Diff(revision)
+	if len(diff) != 2 {
+		t.Fatalf("diff = %+v, want description and publish_year", diff)
+	}
+	for _, d := range diff {
+		if d.Proposed != nil {
+			t.Errorf("%s: proposed = %v, want nil", d.Field, d.Proposed)
+		}
+	}
+
+	revision.ApplyTo(book)
+	if book.Description != nil || book.PublishYear != nil {
+		t.Errorf("fields not cleared: description=%v publish_year=%v", book.Description, book.PublishYear)
+	}
+	if book.Language == nil || *book.Language != lang {
+		t.Errorf("language = %v, want %q", book.Language, lang)
+	}
+}
+
+func TestSnapshotWithKeepsOmittedFields(t *testing.T) {
+	desc, pages := "Роман", 500
+	before := BookSnapshot{Title: "Идиот", Description: &desc}
+
+	after := before.With(BookPatch{Pages: &pages})
+	if after.Description == nil || *after.Description != desc {
+		t.Errorf("description = %v, want %q", after.Description, desc)
+	}
+	diff := before.Diff(after)
+	if len(diff) != 1 || diff[0].Field != "pages" || diff[0].Current != nil || diff[0].Proposed != pages {
+		t.Errorf("diff = %+v, want only pages: nil → %d", diff, pages)
+	}
+}
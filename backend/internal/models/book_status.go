@@ -1,8 +1,71 @@
 package models
 
+import "time"
+
 const (
+	StatusBookDraft      = "draft"      // Черновик, виден только автору записи
+	StatusBookQuarantine = "quarantine" // Отправлена на модерацию
 	StatusBookVisible    = "visible"    // Отображается пользователям
+	StatusBookRejected   = "rejected"   // Отклонена модератором, можно доработать и отправить снова
 	StatusBookArchived   = "archived"   // Устаревшее издание, удалено из выдачи, но не удалено из БД
-	StatusBookQuarantine = "quarantine" // Временная блокировка на публикацию
 	StatusBookPrivate    = "private"    // Непубличный контент
 )
+
+// BookStatusTransition — допустимый переход между статусами книги.
+type BookStatusTransition struct {
+	From           string `json:"from"`
+	To             string `json:"to"`
+	AdminOnly      bool   `json:"admin_only"`      // владельцу книги переход недоступен
+	ReasonRequired bool   `json:"reason_required"` // причина сохраняется в истории и уходит автору
+}
+
+// BookStatusTransitions — конечный автомат публикации:
+// draft → quarantine → visible/rejected → archived.
+var BookStatusTransitions = []BookStatusTransition{
+	{From: StatusBookDraft, To: StatusBookQuarantine},
+	{From: StatusBookQuarantine, To: StatusBookDraft},
+	{From: StatusBookQuarantine, To: StatusBookVisible, AdminOnly: true},
+	{From: StatusBookQuarantine, To: StatusBookRejected, AdminOnly: true, ReasonRequired: true},
+	{From: StatusBookRejected, To: StatusBookDraft},
+	{From: StatusBookRejected, To: StatusBookQuarantine},
+	{From: StatusBookVisible, To: StatusBookQuarantine, AdminOnly: true},
+	{From: StatusBookVisible, To: StatusBookArchived, AdminOnly: true},
+	{From: StatusBookVisible, To: StatusBookPrivate, AdminOnly: true},
+	{From: StatusBookPrivate, To: StatusBookVisible, AdminOnly: true},
+	{From: StatusBookArchived, To: StatusBookVisible, AdminOnly: true},
+}
+
+// FindBookStatusTransition ищет переход from → to в автомате.
+func FindBookStatusTransition(from, to string) (BookStatusTransition, bool) {
+	for _, t := range BookStatusTransitions {
+		if t.From == from && t.To == to {
+			return t, true
+		}
+	}
+	return BookStatusTransition{}, false
+}
+
+// BookStatusChange — запись истории статусов книги.
+type BookStatusChange struct {
+	ID         int       `json:"id"`
+	BookID     int       `json:"book_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     *string   `json:"reason,omitempty"`
+	ChangedBy  *int      `json:"changed_by,omitempty"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// Submitter — кто прислал книгу на модерацию.
+type Submitter struct {
+	ID    int     `json:"id"`
+	Email string  `json:"email"`
+	Name  *string `json:"name,omitempty"`
+}
+
+// ReviewQueueItem — книга в очереди модерации.
+type ReviewQueueItem struct {
+	Book
+	Submitter   *Submitter `json:"submitter,omitempty"`
+	SubmittedAt time.Time  `json:"submitted_at"` // последняя отправка на модерацию
+}
@@ -1,7 +1,14 @@
 package models
 
+// Вес тега у книги
+const (
+	TagWeightSecondary = 0
+	TagWeightPrimary   = 1
+)
+
 type BookTag struct {
-	BookID int `json:"book_id"`
-	TagID  int `json:"tag_id"`
-	Weight int `json:"weight"`
+	BookID  int    `json:"book_id"`
+	TagID   int    `json:"tag_id" binding:"omitempty,min=1"`
+	TagName string `json:"tag_name,omitempty" binding:"omitempty,max=100"` // вместо tag_id можно указать название или синоним
+	Weight  int    `json:"weight" binding:"oneof=0 1"`
 }
@@ -1,25 +1,40 @@
 package models
 
-type CreateBookRequest struct {
-	Title       string  `json:"title" binding:"required"`
-	Description *string `json:"description,omitempty"`
-	PublishYear *int    `json:"publish_year,omitempty"`
-	Pages       *int    `json:"pages,omitempty"`
-	Language    *string `json:"language,omitempty"`
-	Publisher   *string `json:"publisher,omitempty"`
-	Type        *string `json:"type,omitempty"`
-	CoverURL    *string `json:"cover_url,omitempty"`
+// Запросы на запись. Клиент задаёт только перечисленные здесь поля: id, статус,
+// рейтинг, автор записи и даты выставляет сервис. Правила в тегах binding
+// повторяют ограничения схемы БД.
+
+// BookInput — поля книги при создании и полном обновлении.
+type BookInput struct {
+	Title       string  `json:"title" binding:"required,max=255"`
+	Description *string `json:"description"`
+	PublishYear *int    `json:"publish_year" binding:"omitempty,year"`
+	Pages       *int    `json:"pages" binding:"omitempty,min=1"`
+	Language    *string `json:"language" binding:"omitempty,max=100"`
+	Publisher   *string `json:"publisher" binding:"omitempty,max=255"`
+	Type        *string `json:"type" binding:"omitempty,oneof=book journal article other"`
+	CoverURL    *string `json:"cover_url" binding:"omitempty,url,max=512"`
+}
+
+// CreateBookInput — новая книга; черновик создаётся только по явному status=draft,
+// иначе статус выбирает сервис.
+type CreateBookInput struct {
+	BookInput
+	Status string `json:"status" binding:"omitempty,oneof=draft"`
+}
+
+// CategoryInput — поля категории при создании и обновлении.
+type CategoryInput struct {
+	Name        string  `json:"name" binding:"required,max=255"`
+	ParentID    *int    `json:"parent_id" binding:"omitempty,min=1"`
+	Slug        *string `json:"slug" binding:"omitempty,max=255"`
+	Description *string `json:"description"`
 }
 
-type BookResponse struct {
-	ID          int     `json:"id"`
-	Title       string  `json:"title"`
-	Description *string `json:"description,omitempty"`
-	PublishYear *int    `json:"publish_year,omitempty"`
-	Pages       *int    `json:"pages,omitempty"`
-	Language    *string `json:"language,omitempty"`
-	Publisher   *string `json:"publisher,omitempty"`
-	Type        *string `json:"type,omitempty"`
-	CoverURL    *string `json:"cover_url,omitempty"`
-	CreatedAt   string  `json:"created_at"`
+// CategoryPatch — частичное изменение категории: nil-поле остаётся как есть.
+type CategoryPatch struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=255"`
+	ParentID    *int    `json:"parent_id" binding:"omitempty,min=1"`
+	Slug        *string `json:"slug" binding:"omitempty,max=255"`
+	Description *string `json:"description"`
 }
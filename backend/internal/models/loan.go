@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+const (
+	LoanStatusActive   = "active"
+	LoanStatusReturned = "returned"
+	LoanStatusExpired  = "expired"
+
+	HoldStatusWaiting   = "waiting"
+	HoldStatusFulfilled = "fulfilled"
+	HoldStatusCancelled = "cancelled"
+)
+
+const (
+	LoanPeriod      = 14 * 24 * time.Hour // срок выдачи и продления
+	LoanMaxRenewals = 2
+)
+
+type Loan struct {
+	ID           int        `json:"id"`
+	BookID       int        `json:"book_id"`
+	UserID       int        `json:"user_id"`
+	Status       string     `json:"status"`
+	CheckedOutAt time.Time  `json:"checked_out_at"`
+	DueAt        time.Time  `json:"due_at"`
+	ReturnedAt   *time.Time `json:"returned_at,omitempty"`
+	Renewals     int        `json:"renewals"`
+}
+
+type LoanHold struct {
+	ID          int        `json:"id"`
+	BookID      int        `json:"book_id"`
+	UserID      int        `json:"user_id"`
+	Status      string     `json:"status"`
+	Position    int        `json:"position,omitempty"` // место в очереди для ожидающих
+	CreatedAt   time.Time  `json:"created_at"`
+	FulfilledAt *time.Time `json:"fulfilled_at,omitempty"`
+}
+
+type BookAvailability struct {
+	BookID       int       `json:"book_id"`
+	Copies       *int      `json:"copies"` // nil — книга в открытом доступе, выдача не нужна
+	ActiveLoans  int       `json:"active_loans"`
+	Available    int       `json:"available"`
+	HoldsWaiting int       `json:"holds_waiting"`
+	MyLoan       *Loan     `json:"my_loan,omitempty"`
+	MyHold       *LoanHold `json:"my_hold,omitempty"`
+}
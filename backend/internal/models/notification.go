@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Виды уведомлений
+const (
+	NotificationTagApproved   = "tag_approved"
+	NotificationTagRejected   = "tag_rejected"
+	NotificationBookPublished = "book_published"
+	NotificationBookRejected  = "book_rejected"
+	NotificationEditProposed  = "book_edit_proposed"
+	NotificationEditAccepted  = "book_edit_accepted"
+	NotificationEditRejected  = "book_edit_rejected"
+)
+
+type Notification struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	Kind       string    `json:"kind"`
+	Message    string    `json:"message"`
+	EntityType *string   `json:"entity_type,omitempty"` // к чему относится: "tag", "book", ...
+	EntityID   *int      `json:"entity_id,omitempty"`
+	IsRead     bool      `json:"is_read"`
+	CreatedAt  time.Time `json:"created_at"`
+}
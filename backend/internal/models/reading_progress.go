@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Типы локаторов позиции чтения
+const (
+	LocatorCFI        = "cfi"        // EPUB CFI
+	LocatorXPointer   = "xpointer"   // KOReader (crengine)
+	LocatorPage       = "page"       // номер страницы PDF/DjVu
+	LocatorPercentage = "percentage" // только процент прочитанного
+)
+
+type ReadingProgress struct {
+	ID          int       `json:"id,omitempty"` // заполняется только для записей истории
+	UserID      int       `json:"user_id"`
+	BookFileID  *int      `json:"book_file_id,omitempty"`
+	Document    string    `json:"document"`
+	Locator     string    `json:"locator"`
+	LocatorType string    `json:"locator_type"`
+	Percentage  float64   `json:"percentage"` // 0..1
+	Device      string    `json:"device,omitempty"`
+	DeviceID    string    `json:"device_id,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
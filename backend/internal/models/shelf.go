@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Виды полок: статусы чтения (по одной на пользователя) и пользовательские
+const (
+	ShelfWantToRead = "want_to_read"
+	ShelfReading    = "reading"
+	ShelfFinished   = "finished"
+	ShelfAbandoned  = "abandoned"
+	ShelfCustom     = "custom"
+)
+
+// ReadingStatusShelves — полки-статусы в порядке отображения с названиями по умолчанию.
+var ReadingStatusShelves = []struct {
+	Kind string
+	Name string
+}{
+	{ShelfWantToRead, "Хочу прочитать"},
+	{ShelfReading, "Читаю"},
+	{ShelfFinished, "Прочитано"},
+	{ShelfAbandoned, "Брошено"},
+}
+
+// IsReadingStatusShelf — книга может находиться только на одной полке-статусе.
+func IsReadingStatusShelf(kind string) bool {
+	return kind != ShelfCustom
+}
+
+type Shelf struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	BookCount int       `json:"book_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ShelfEntry struct {
+	ShelfID   int       `json:"shelf_id"`
+	Book      Book      `json:"book"`
+	AddedAt   time.Time `json:"added_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Archived  bool      `json:"archived"` // книга снята администратором с выдачи, но остаётся на полке
+}
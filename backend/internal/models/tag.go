@@ -1,7 +1,59 @@
 package models
 
+import (
+	"regexp"
+	"time"
+)
+
+const (
+	TagStatusApproved = "approved"
+	TagStatusProposed = "proposed" // создан пользователем, виден автору и админам до одобрения
+)
+
+var hexColorRegex = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// IsHexColor — цвет вида #FFAA00, как он хранится у тегов и пометок.
+func IsHexColor(s string) bool {
+	return hexColorRegex.MatchString(s)
+}
+
 type Tag struct {
+	ID        int          `json:"id"`
+	Name      string       `json:"name"`
+	Color     string       `json:"color,omitempty"`
+	Status    string       `json:"status,omitempty"`
+	CreatedBy *int         `json:"created_by,omitempty"`
+	CreatedAt *time.Time   `json:"created_at,omitempty"`
+	Synonyms  []TagSynonym `json:"synonyms,omitempty"`
+}
+
+// TagSynonym — альтернативное название, которое разрешается в канонический тег.
+type TagSynonym struct {
 	ID    int    `json:"id"`
+	TagID int    `json:"tag_id"`
 	Name  string `json:"name"`
-	Color string `json:"color,omitempty"`
+}
+
+// TagUsage — тег с числом видимых книг; для автодополнения также
+// указывается синоним, по которому тег был найден.
+type TagUsage struct {
+	Tag
+	UsageCount int     `json:"usage_count"`
+	MatchedBy  *string `json:"matched_by,omitempty"`
+}
+
+// TagCloudEntry — тег в облаке: популярность с учётом веса и уровень для отрисовки (1–5).
+type TagCloudEntry struct {
+	Tag
+	UsageCount int     `json:"usage_count"`
+	Score      float64 `json:"score"`
+	Level      int     `json:"level"`
+}
+
+// TagBlock — запрещённый фрагмент названия тега.
+type TagBlock struct {
+	ID        int       `json:"id"`
+	Pattern   string    `json:"pattern"`
+	CreatedBy *int      `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
 }
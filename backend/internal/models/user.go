@@ -11,19 +11,20 @@ type User struct {
 	Bio          *string   `json:"bio,omitempty"`
 	RegisteredAt time.Time `json:"registered_at"`
 	TokenVersion int       `json:"-"`
+	SyncKeyHash  *string   `json:"-"` // bcrypt от md5 пароля синхронизации (KOReader)
 	Is_active    bool      `json:"is_active"`
 }
 
 type UserInput struct {
-	Email    string `json:"email"`
-	Name     string `json:"name"`
+	Email    string `json:"email" binding:"required,email,max=255"`
+	Name     string `json:"name" binding:"required,max=255"`
 	Bio      string `json:"bio"`
-	Password string `json:"password"`
+	Password string `json:"password" binding:"omitempty,max=72"` // нужен только при создании; bcrypt учитывает первые 72 байта
 }
 
 type AdminUserUpdateInput struct {
-	Email string `json:"email"`
-	Name  string `json:"name"`
+	Email string `json:"email" binding:"required,email,max=255"`
+	Name  string `json:"name" binding:"required,max=255"`
 	Bio   string `json:"bio"`
-	Role  string `json:"role"` // new-user, user, admin, superadmin
+	Role  string `json:"role" binding:"required,oneof=new-user user admin superadmin"`
 }
@@ -0,0 +1,160 @@
+// Package apperr — доменные ошибки. Сервисы и репозитории возвращают *Error
+// с видом (Kind), по которому выбирается HTTP-статус, и стабильным кодом, по
+// которому клиент различает ошибки и выбирается перевод сообщения.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type Kind string
+
+const (
+	KindValidation           Kind = "validation"
+	KindUnauthorized         Kind = "unauthorized"
+	KindForbidden            Kind = "forbidden"
+	KindNotFound             Kind = "not_found"
+	KindConflict             Kind = "conflict"
+	KindPreconditionFailed   Kind = "precondition_failed"
+	KindPreconditionRequired Kind = "precondition_required"
+	KindTooManyRequests      Kind = "too_many_requests"
+	KindInternal             Kind = "internal"
+	KindTimeout              Kind = "timeout"
+	KindCanceled             Kind = "canceled"
+)
+
+// statusClientClosedRequest — нестандартный 499 (как у nginx): клиент не дождался ответа.
+const statusClientClosedRequest = 499
+
+var statuses = map[Kind]int{
+	KindValidation:           http.StatusBadRequest,
+	KindUnauthorized:         http.StatusUnauthorized,
+	KindForbidden:            http.StatusForbidden,
+	KindNotFound:             http.StatusNotFound,
+	KindConflict:             http.StatusConflict,
+	KindPreconditionFailed:   http.StatusPreconditionFailed,
+	KindPreconditionRequired: http.StatusPreconditionRequired,
+	KindTooManyRequests:      http.StatusTooManyRequests,
+	KindInternal:             http.StatusInternalServerError,
+	KindTimeout:              http.StatusGatewayTimeout,
+	KindCanceled:             statusClientClosedRequest,
+}
+
+// Status — HTTP-статус для вида ошибки.
+func (k Kind) Status() int {
+	if s, ok := statuses[k]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
+// FieldError — ошибка в конкретном поле запроса. Code — нарушенное правило
+// (required, max_length, one_of, ...), Param — его параметр, например длина.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Kind    Kind
+	Code    string        // стабильный код, например "book_not_found"
+	Message string        // сообщение по-английски с подставленными Args
+	Args    []interface{} // подставляются в переведённый шаблон из каталога
+	Fields  []FieldError
+	Err     error // исходная ошибка, наружу не отдаётся
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is сравнивает по коду, поэтому errors.Is(err, ErrX) срабатывает и для
+// ошибки с тем же кодом, но своими подробностями.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// Newf — ошибка с подстановкой; шаблон из каталога переводов получает те же args.
+func Newf(kind Kind, code, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Code: code, Message: fmt.Sprintf(format, args...), Args: args}
+}
+
+func Validation(code, message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message, Fields: fields}
+}
+
+func Validationf(code, format string, args ...interface{}) *Error {
+	return Newf(KindValidation, code, format, args...)
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func Forbiddenf(code, format string, args ...interface{}) *Error {
+	return Newf(KindForbidden, code, format, args...)
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func Conflictf(code, format string, args ...interface{}) *Error {
+	return Newf(KindConflict, code, format, args...)
+}
+
+// WithKind возвращает копию с другим видом — когда та же ошибка в другом
+// запросе означает другой HTTP-статус.
+func (e *Error) WithKind(kind Kind) *Error {
+	c := *e
+	c.Kind = kind
+	return &c
+}
+
+// WithCause возвращает копию с исходной ошибкой — для журнала и errors.Is.
+func (e *Error) WithCause(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// As достаёт доменную ошибку из цепочки.
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// KindOf — вид ошибки; не доменная ошибка считается внутренней.
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+	return KindInternal
+}
@@ -0,0 +1,85 @@
+package apperr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Коды нарушенных правил в FieldError.Code.
+const (
+	FieldRequired  = "required"
+	FieldEmail     = "email"
+	FieldURL       = "url"
+	FieldColor     = "color"
+	FieldYear      = "year"
+	FieldOneOf     = "one_of"
+	FieldMinLength = "min_length"
+	FieldMaxLength = "max_length"
+	FieldMin       = "min"
+	FieldMax       = "max"
+	FieldMinItems  = "min_items"
+	FieldInvalid   = "invalid"
+)
+
+// Шаблоны сообщений по полям; %s — Param правила.
+var fieldMessages = map[string]map[string]string{
+	LangEN: {
+		FieldRequired:  "is required",
+		FieldEmail:     "must be a valid email address",
+		FieldURL:       "must be a valid URL",
+		FieldColor:     "must be a hex color like #FFAA00",
+		FieldYear:      "must be a year between 1 and %s",
+		FieldOneOf:     "must be one of: %s",
+		FieldMinLength: "must be at least %s characters long",
+		FieldMaxLength: "must be at most %s characters long",
+		FieldMin:       "must be at least %s",
+		FieldMax:       "must be at most %s",
+		FieldMinItems:  "must contain at least %s items",
+		FieldInvalid:   "is invalid",
+	},
+	LangRU: {
+		FieldRequired:  "обязательное поле",
+		FieldEmail:     "должен быть корректным email",
+		FieldURL:       "должен быть корректным URL",
+		FieldColor:     "должен быть hex-кодом цвета вида #FFAA00",
+		FieldYear:      "должен быть годом от 1 до %s",
+		FieldOneOf:     "допустимые значения: %s",
+		FieldMinLength: "должно быть не короче %s символов",
+		FieldMaxLength: "должно быть не длиннее %s символов",
+		FieldMin:       "должно быть не меньше %s",
+		FieldMax:       "должно быть не больше %s",
+		FieldMinItems:  "должно содержать не меньше %s элементов",
+		FieldInvalid:   "некорректное значение",
+	},
+}
+
+// NewFieldError — ошибка поля с английским сообщением по коду правила.
+func NewFieldError(field, code, param string) FieldError {
+	return FieldError{Field: field, Code: code, Param: param, Message: fieldMessage(code, param, LangEN)}
+}
+
+// LocalizeFields — ошибки полей с сообщениями на языке lang.
+func (e *Error) LocalizeFields(lang string) []FieldError {
+	if len(e.Fields) == 0 || lang == LangEN {
+		return e.Fields
+	}
+	fields := make([]FieldError, len(e.Fields))
+	for i, f := range e.Fields {
+		fields[i] = f
+		if m := fieldMessage(f.Code, f.Param, lang); m != "" {
+			fields[i].Message = m
+		}
+	}
+	return fields
+}
+
+func fieldMessage(code, param, lang string) string {
+	t, ok := fieldMessages[lang][code]
+	if !ok {
+		t = fieldMessages[lang][FieldInvalid]
+	}
+	if strings.Contains(t, "%s") {
+		return fmt.Sprintf(t, param)
+	}
+	return t
+}
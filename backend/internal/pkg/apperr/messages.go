@@ -0,0 +1,205 @@
+package apperr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Поддерживаемые языки сообщений. Исходные сообщения в коде — английские.
+const (
+	LangEN = "en"
+	LangRU = "ru"
+)
+
+var titles = map[string]map[Kind]string{
+	LangEN: {
+		KindValidation:           "Invalid request",
+		KindUnauthorized:         "Unauthorized",
+		KindForbidden:            "Forbidden",
+		KindNotFound:             "Not found",
+		KindConflict:             "Conflict",
+		KindPreconditionFailed:   "Precondition failed",
+		KindPreconditionRequired: "Precondition required",
+		KindTooManyRequests:      "Too many requests",
+		KindInternal:             "Internal server error",
+		KindTimeout:              "Request timed out",
+		KindCanceled:             "Request canceled",
+	},
+	LangRU: {
+		KindValidation:           "Некорректный запрос",
+		KindUnauthorized:         "Требуется авторизация",
+		KindForbidden:            "Доступ запрещён",
+		KindNotFound:             "Не найдено",
+		KindConflict:             "Конфликт",
+		KindPreconditionFailed:   "Условие запроса не выполнено",
+		KindPreconditionRequired: "Требуется условие запроса",
+		KindTooManyRequests:      "Слишком много запросов",
+		KindInternal:             "Внутренняя ошибка сервера",
+		KindTimeout:              "Время ожидания истекло",
+		KindCanceled:             "Запрос отменён",
+	},
+}
+
+// ru — переводы по коду ошибки. Шаблон для ошибки с Args содержит те же
+// глаголы форматирования в том же порядке, что и английский.
+var ru = map[string]string{
+	// общие
+	"unauthorized":        "требуется авторизация",
+	"missing_token":       "токен отсутствует или недействителен",
+	"invalid_credentials": "неверный email или пароль",
+	"access_denied":       "доступ запрещён",
+	"admin_required":      "требуются права администратора",
+	"superadmin_required": "требуются права суперадминистратора",
+	"internal_error":      "внутренняя ошибка сервера",
+	"not_found":           "ресурс не найден",
+	"request_timeout":     "время ожидания запроса истекло",
+	"request_canceled":    "запрос отменён клиентом",
+	"invalid_parameter":   "некорректный параметр %s",
+	"invalid_body":        "некорректное тело запроса",
+	"validation_failed":   "запрос не прошёл проверку",
+	"invalid_format":      "формат должен быть markdown или json",
+	"already_exists":      "запись уже существует",
+	"related_not_found":   "связанная запись не найдена: %s %d",
+
+	// не найдено
+	"book_not_found":         "книга не найдена",
+	"revision_not_found":     "ревизия не найдена",
+	"author_not_found":       "автор не найден",
+	"alias_not_found":        "псевдоним не найден",
+	"category_not_found":     "категория не найдена",
+	"comment_not_found":      "комментарий не найден",
+	"annotation_not_found":   "заметка не найдена",
+	"edit_not_found":         "предложенная правка не найдена",
+	"file_not_found":         "файл не найден",
+	"loan_not_found":         "выдача не найдена",
+	"hold_not_found":         "бронь не найдена",
+	"notification_not_found": "уведомление не найдено",
+	"progress_not_found":     "прогресс чтения не найден",
+	"rating_not_found":       "оценка не найдена",
+	"review_not_found":       "рецензия не найдена",
+	"shelf_not_found":        "полка не найдена",
+	"tag_not_found":          "тег не найден",
+	"synonym_not_found":      "синоним не найден",
+	"block_not_found":        "запись в чёрном списке не найдена",
+	"user_not_found":         "пользователь не найден",
+
+	// права
+	"not_book_creator":  "недостаточно прав: вы не создатель книги",
+	"not_owner":         "доступ запрещён: вы не владелец и не администратор",
+	"admin_only":        "недостаточно прав: действие «%s» доступно только администратору",
+	"admin_only_status": "недостаточно прав: перевести книгу в статус %s может только администратор",
+	"superadmin_only":   "недостаточно прав: менять роли администраторов может только суперадминистратор",
+	"loan_required":     "чтобы скачать книгу, её нужно взять",
+
+	// книги и правки
+	"book_status_changed":       "статус книги уже изменён другим пользователем",
+	"book_version_conflict":     "книга изменилась с указанной версии",
+	"invalid_status_transition": "переход статуса не разрешён: %s → %s",
+	"status_reason_required":    "для такой смены статуса нужна причина",
+	"version_required":          "для частичного обновления нужна версия книги",
+	"title_empty":               "название не может быть пустым",
+	"unknown_contributor_role":  "неизвестная роль участника: %s",
+	"edit_not_pending":          "правка уже рассмотрена",
+	"edit_no_changes":           "правка ничего не меняет",
+
+	// выдачи
+	"lending_disabled":    "книга не выдаётся: она доступна без выдачи",
+	"no_copies_available": "нет свободных экземпляров",
+	"already_borrowed":    "книга уже у вас",
+	"loan_not_active":     "выдача не активна",
+	"holds_waiting":       "книгу ждут другие читатели",
+	"renewal_limit":       "достигнут предел продлений",
+	"copies_available":    "есть свободные экземпляры, возьмите книгу",
+	"hold_already_placed": "бронь уже оформлена",
+	"negative_copies":     "число экземпляров не может быть отрицательным",
+
+	// авторы
+	"author_has_books":        "у автора есть книги, объедините его с другим автором",
+	"merge_into_itself":       "нельзя объединить запись с самой собой",
+	"author_name_required":    "нужно указать имя по-русски или по-английски",
+	"author_exists":           "автор с таким именем уже есть",
+	"unknown_translit_scheme": "неизвестная схема транслитерации",
+	"alias_name_required":     "нужно указать псевдоним",
+	"unknown_alias_kind":      "неизвестный вид псевдонима: %s",
+	"alias_taken":             "это имя уже занято другим автором",
+	"invalid_threshold":       "порог должен быть в пределах (0, 1]",
+
+	// теги
+	"tag_not_proposed":      "тег не ждёт модерации",
+	"tag_not_approved":      "в правке можно указать только одобренные теги",
+	"tag_rate_limited":      "слишком много предложенных тегов, попробуйте позже",
+	"tag_blocked":           "такое название тега запрещено",
+	"tag_pending":           "тег с таким названием ждёт модерации",
+	"tag_id_required":       "нужно указать tag_id",
+	"book_tag_ids_required": "нужно указать ID книги и тега",
+	"invalid_tag_weight":    "вес тега должен быть %d (основной) или %d (дополнительный)",
+	"tag_name_required":     "нужно указать название тега",
+	"synonym_name_required": "нужно указать синоним",
+	"tag_name_taken":        "название уже занято тегом или синонимом",
+	"pattern_required":      "нужно указать шаблон",
+	"source_ids_required":   "source_ids не может быть пустым",
+	"invalid_color":         "цвет должен быть hex-кодом вида #FFAA00",
+
+	// пользователи
+	"email_taken":             "email уже зарегистрирован",
+	"password_required":       "нужно указать пароль",
+	"unknown_role":            "неизвестная роль: %s",
+	"sync_password_too_short": "пароль синхронизации должен быть не короче 6 символов",
+
+	// полки, оценки, заметки, прогресс
+	"shelf_name_required":     "нужно указать название полки",
+	"reading_shelf_protected": "полки статусов чтения нельзя удалить",
+	"invalid_score":           "оценка должна быть от %d до %d",
+	"review_text_required":    "нужно написать текст рецензии",
+	"unknown_annotation_kind": "неизвестный вид заметки: %s",
+	"unknown_locator_type":    "неизвестный тип позиции: %s",
+	"locator_required":        "нужно указать позицию",
+	"excerpt_required":        "для выделения нужен фрагмент текста",
+	"note_required":           "нужно написать текст заметки",
+	"negative_position":       "позиция не может быть отрицательной",
+	"invalid_percentage":      "процент должен быть от 0 до 1",
+	"document_required":       "нужно указать документ",
+}
+
+// Localize — сообщение на языке lang. Если перевода нет или шаблону не хватает
+// аргументов, возвращается исходное сообщение.
+func (e *Error) Localize(lang string) string {
+	if lang != LangRU {
+		return e.Message
+	}
+	t, ok := ru[e.Code]
+	if !ok {
+		return e.Message
+	}
+	if len(e.Args) > 0 {
+		return fmt.Sprintf(t, e.Args...)
+	}
+	if strings.Contains(t, "%") {
+		return e.Message
+	}
+	return t
+}
+
+// Title — краткое название вида ошибки для поля title.
+func Title(kind Kind, lang string) string {
+	if t, ok := titles[lang][kind]; ok {
+		return t
+	}
+	if t, ok := titles[LangEN][kind]; ok {
+		return t
+	}
+	return titles[LangEN][KindInternal]
+}
+
+// PreferredLanguage выбирает язык по заголовку Accept-Language: первый из
+// поддерживаемых по порядку, веса q не учитываются. По умолчанию — английский.
+func PreferredLanguage(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		base := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if base == LangRU || base == LangEN {
+			return base
+		}
+	}
+	return LangEN
+}
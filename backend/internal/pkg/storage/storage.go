@@ -0,0 +1,59 @@
+// Package storage читает файлы книг из хранилища. Адреса в хранилище клиентам
+// не отдаются: файл проходит через сервер после проверки доступа, поэтому
+// доступ заканчивается вместе с выдачей.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var ErrNotFound = errors.New("storage: file not found")
+
+// Object — открытый файл. Size равен -1, если хранилище не сообщило размер.
+type Object struct {
+	Body        io.ReadCloser
+	Size        int64
+	ContentType string
+}
+
+type Storage interface {
+	Open(ctx context.Context, url string) (*Object, error)
+}
+
+// HTTP — хранилище, отдающее файлы по HTTP(S) (S3-совместимое, статика nginx).
+type HTTP struct {
+	client *http.Client
+}
+
+// NewHTTP — timeout ограничивает установку соединения и ожидание заголовков
+// ответа; само тело читается, пока вызывающий его не закроет или не отменит ctx.
+func NewHTTP(timeout time.Duration) *HTTP {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = timeout
+	return &HTTP{client: &http.Client{Transport: transport}}
+}
+
+func (s *HTTP) Open(ctx context.Context, url string) (*Object, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		resp.Body.Close()
+		return nil, fmt.Errorf("storage: unexpected status %d", resp.StatusCode)
+	}
+	return &Object{Body: resp.Body, Size: resp.ContentLength, ContentType: resp.Header.Get("Content-Type")}, nil
+}
@@ -0,0 +1,127 @@
+// Package tracing настраивает OpenTelemetry: провайдер трасс, экспортёр и
+// инструментированное подключение к базе.
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/XSAM/otelsql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+
+	DefaultServiceName = "online-library"
+)
+
+type Config struct {
+	Exporter    string // none, otlp или stdout
+	ServiceName string
+}
+
+// ConfigFromEnv читает OTEL_TRACES_EXPORTER и OTEL_SERVICE_NAME; адрес коллектора
+// экспортёр otlp сам берёт из OTEL_EXPORTER_OTLP_ENDPOINT (по умолчанию localhost:4318).
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Exporter:    strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")),
+		ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+	}
+	if cfg.Exporter == "" {
+		cfg.Exporter = ExporterNone
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = DefaultServiceName
+	}
+	return cfg
+}
+
+// Setup устанавливает глобальный провайдер трасс. Возвращаемую функцию нужно
+// вызвать при остановке, чтобы отправить накопленные спаны. При exporter=none
+// трассы не собираются, но контекст W3C traceparent всё равно пробрасывается.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(),
+		resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// OpenDB открывает базу через otelsql: каждый запрос становится спаном с текстом
+// SQL, названным по методу репозитория (см. WithQueryName).
+func OpenDB(driverName, dsn string) (*sql.DB, error) {
+	return otelsql.Open(driverName, dsn,
+		otelsql.WithAttributes(attribute.String("db.system", driverName)),
+		otelsql.WithSpanNameFormatter(spanName),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
+}
+
+func spanName(ctx context.Context, method otelsql.Method, _ string) string {
+	name := QueryName(ctx)
+	if name == "" {
+		return string(method)
+	}
+	switch method {
+	case otelsql.MethodConnQuery, otelsql.MethodConnExec, otelsql.MethodStmtQuery, otelsql.MethodStmtExec:
+		return name
+	default:
+		return name + " " + string(method)
+	}
+}
+
+type queryNameKey struct{}
+
+// WithQueryName задаёт имя спанов для запросов, выполненных с этим контекстом.
+// Методы репозитория вызывают его первым делом: ctx = tracing.WithQueryName(ctx,
+// "bookRepository.GetBookByID").
+func WithQueryName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, queryNameKey{}, name)
+}
+
+// QueryName — имя, заданное WithQueryName; пустая строка, если его нет.
+func QueryName(ctx context.Context) string {
+	name, _ := ctx.Value(queryNameKey{}).(string)
+	return name
+}
@@ -0,0 +1,136 @@
+package translit
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Обратная таблица: сочетания из всех поддерживаемых схем и привычных
+// «бытовых» написаний. Длинные сочетания проверяются раньше коротких.
+var latinToRu = []struct {
+	latin string
+	ru    string
+}{
+	{"shch", "щ"}, {"shh", "щ"},
+	{"zh", "ж"}, {"kh", "х"}, {"ts", "ц"}, {"cz", "ц"}, {"ch", "ч"}, {"sh", "ш"},
+	{"yu", "ю"}, {"iu", "ю"}, {"ya", "я"}, {"ia", "я"}, {"yo", "ё"}, {"ye", "е"}, {"yë", "ё"},
+	{"a", "а"}, {"b", "б"}, {"c", "к"}, {"d", "д"}, {"e", "е"}, {"f", "ф"},
+	{"g", "г"}, {"h", "х"}, {"i", "и"}, {"j", "й"}, {"k", "к"}, {"l", "л"},
+	{"m", "м"}, {"n", "н"}, {"o", "о"}, {"p", "п"}, {"q", "к"}, {"r", "р"},
+	{"s", "с"}, {"t", "т"}, {"u", "у"}, {"v", "в"}, {"w", "в"}, {"x", "кс"},
+	{"z", "з"},
+	// ISO 9
+	{"ë", "ё"}, {"ž", "ж"}, {"č", "ч"}, {"š", "ш"}, {"ŝ", "щ"}, {"è", "э"},
+	{"û", "ю"}, {"â", "я"}, {"ʹ", "ь"}, {"ʺ", "ъ"},
+}
+
+var cyrVowels = "аеёиоуыэюя"
+
+// ToCyrillic — обратная транслитерация латиницы в кириллицу для поиска.
+// Схема не угадывается: понимаются написания всех поддерживаемых схем,
+// поэтому результат — правдоподобный вариант, а не точное обращение.
+// "Tolstoy" → "Толстой", "Dostoyevsky" → "Достоевский".
+func ToCyrillic(input string) string {
+	runes := []rune(input)
+	var result strings.Builder
+	var prev rune // последняя записанная кириллическая буква (строчная)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if !unicode.IsLetter(r) {
+			result.WriteRune(r)
+			prev = 0
+			i++
+			continue
+		}
+
+		if unicode.ToLower(r) == 'y' {
+			ru := yToCyrillic(runes, i, prev)
+			if ru != "" {
+				result.WriteString(applyCase(ru, runes, i, 1))
+				prev = lastRune(ru)
+				i++
+				continue
+			}
+		}
+
+		matched := false
+		for _, m := range latinToRu {
+			n := len([]rune(m.latin))
+			if i+n > len(runes) || strings.ToLower(string(runes[i:i+n])) != m.latin {
+				continue
+			}
+			result.WriteString(applyCase(m.ru, runes, i, n))
+			prev = lastRune(m.ru)
+			i += n
+			matched = true
+			break
+		}
+		if !matched {
+			result.WriteRune(r)
+			prev = 0
+			i++
+		}
+	}
+	return result.String()
+}
+
+// yToCyrillic разбирает одиночную y: пустая строка означает, что y входит
+// в сочетание (ya, yu, ye, yo) и обрабатывается общей таблицей.
+func yToCyrillic(runes []rune, i int, prev rune) string {
+	var next rune
+	if i+1 < len(runes) {
+		next = unicode.ToLower(runes[i+1])
+	}
+	if strings.ContainsRune("aueoë", next) {
+		return ""
+	}
+
+	endOfWord := next == 0 || !unicode.IsLetter(next)
+	afterVowel := strings.ContainsRune(cyrVowels, prev)
+	switch {
+	case afterVowel:
+		return "й" // Tolstoy, Nikolay
+	case endOfWord && prev != 0:
+		return "ий" // Dostoyevsky, Gorky
+	default:
+		return "ы" // Bykov
+	}
+}
+
+// applyCase переносит регистр исходного латинского фрагмента на кириллицу.
+func applyCase(ru string, runes []rune, i, n int) string {
+	if !unicode.IsUpper(runes[i]) {
+		return ru
+	}
+	allCaps := n > 1 && unicode.IsUpper(runes[i+n-1])
+	if n == 1 {
+		if i+1 < len(runes) && unicode.IsLetter(runes[i+1]) {
+			allCaps = unicode.IsUpper(runes[i+1])
+		} else if i > 0 && unicode.IsLetter(runes[i-1]) {
+			allCaps = unicode.IsUpper(runes[i-1])
+		}
+	}
+	if allCaps {
+		return strings.ToUpper(ru)
+	}
+	rs := []rune(ru)
+	rs[0] = unicode.ToUpper(rs[0])
+	return string(rs)
+}
+
+func lastRune(s string) rune {
+	rs := []rune(s)
+	return unicode.ToLower(rs[len(rs)-1])
+}
+
+// HasLatin сообщает, есть ли в строке латинские буквы — только такие
+// запросы имеет смысл дополнительно искать в кириллическом написании.
+func HasLatin(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Latin, r) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,28 +2,169 @@ package translit
 
 import (
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
-// Таблица транслитерации
-var ruToEn = map[rune]string{
-	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d",
-	'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z", 'и': "i",
-	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n",
-	'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
-	'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch",
-	'ш': "sh", 'щ': "shch", 'ы': "y", 'э': "e", 'ю': "yu", 'я': "ya",
-	'ь': "", 'ъ': "",
+// Scheme — стандарт транслитерации кириллицы в латиницу.
+type Scheme string
+
+const (
+	Practical Scheme = "practical" // бытовая, только ASCII: ё → yo, ь и ъ опускаются
+	GOST779   Scheme = "gost779"   // ГОСТ 7.79-2000, система Б (только ASCII)
+	ISO9      Scheme = "iso9"      // ISO 9:1995 (ГОСТ 7.79 система А), однозначная, с диакритикой
+	BGN       Scheme = "bgn"       // BGN/PCGN 1947
+	ICAO      Scheme = "icao"      // ICAO Doc 9303, загранпаспорта РФ с 2013 года
+)
+
+// DefaultScheme используется в ToLatin, а значит в slug и name_en. Схемы
+// со знаками (ë, ʹ) для slug не годятся, их выбирают явно через Transliterate.
+const DefaultScheme = Practical
+
+// Таблицы для строчных букв; регистр восстанавливается при выводе.
+// Кроме русских букв есть украинские (ґ, є, і, ї) и белорусская ў.
+var tables = map[Scheme]map[rune]string{
+	Practical: {
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d",
+		'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z", 'и': "i",
+		'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n",
+		'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+		'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch",
+		'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "",
+		'э': "e", 'ю': "yu", 'я': "ya",
+		'ґ': "g", 'є': "ye", 'і': "i", 'ї': "yi", 'ў': "u",
+	},
+	GOST779: {
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d",
+		'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z", 'и': "i",
+		'й': "j", 'к': "k", 'л': "l", 'м': "m", 'н': "n",
+		'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+		'у': "u", 'ф': "f", 'х': "x", 'ц': "cz", 'ч': "ch",
+		'ш': "sh", 'щ': "shh", 'ъ': "``", 'ы': "y`", 'ь': "`",
+		'э': "e`", 'ю': "yu", 'я': "ya",
+		'ґ': "g`", 'є': "ye", 'і': "i", 'ї': "yi", 'ў': "u`",
+	},
+	ISO9: {
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d",
+		'е': "e", 'ё': "ë", 'ж': "ž", 'з': "z", 'и': "i",
+		'й': "j", 'к': "k", 'л': "l", 'м': "m", 'н': "n",
+		'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+		'у': "u", 'ф': "f", 'х': "h", 'ц': "c", 'ч': "č",
+		'ш': "š", 'щ': "ŝ", 'ъ': "ʺ", 'ы': "y", 'ь': "ʹ",
+		'э': "è", 'ю': "û", 'я': "â",
+		'ґ': "g̀", 'є': "ê", 'і': "ì", 'ї': "ï", 'ў': "ŭ",
+	},
+	BGN: {
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d",
+		'е': "e", 'ё': "ë", 'ж': "zh", 'з': "z", 'и': "i",
+		'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n",
+		'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+		'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch",
+		'ш': "sh", 'щ': "shch", 'ъ': "ʺ", 'ы': "y", 'ь': "ʹ",
+		'э': "e", 'ю': "yu", 'я': "ya",
+		'ґ': "g", 'є': "ye", 'і': "i", 'ї': "yi", 'ў': "w",
+	},
+	ICAO: {
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d",
+		'е': "e", 'ё': "e", 'ж': "zh", 'з': "z", 'и': "i",
+		'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n",
+		'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+		'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch",
+		'ш': "sh", 'щ': "shch", 'ъ': "ie", 'ы': "y", 'ь': "",
+		'э': "e", 'ю': "iu", 'я': "ia",
+		'ґ': "g", 'є': "ie", 'і': "i", 'ї': "i", 'ў': "u",
+	},
+}
+
+// Гласные и знаки, после которых BGN/PCGN пишет е/ё как ye/yë
+var bgnIotatingAfter = map[rune]bool{
+	'а': true, 'е': true, 'ё': true, 'и': true, 'о': true, 'у': true,
+	'ы': true, 'э': true, 'ю': true, 'я': true, 'й': true, 'ъ': true,
+	'ь': true, 'і': true, 'ї': true, 'є': true,
+}
+
+// IsScheme проверяет, что схема поддерживается.
+func IsScheme(s Scheme) bool {
+	_, ok := tables[s]
+	return ok
 }
 
-// ToLatin — простая транслитерация русского текста в латиницу
+// ToLatin — транслитерация по схеме по умолчанию с сохранением регистра.
 func ToLatin(input string) string {
+	return Transliterate(input, DefaultScheme)
+}
+
+// Transliterate переводит кириллицу в латиницу по выбранной схеме; пустая
+// или неизвестная схема — DefaultScheme. Регистр сохраняется: "Щука" →
+// "Shchuka", "ЩИ" → "SHCHI". Символы, которых нет в таблице, копируются как есть.
+func Transliterate(input string, scheme Scheme) string {
+	table, ok := tables[scheme]
+	if !ok {
+		table = tables[DefaultScheme]
+		scheme = DefaultScheme
+	}
+
+	runes := []rune(input)
 	var result strings.Builder
-	for _, r := range strings.ToLower(input) {
-		if val, ok := ruToEn[r]; ok {
-			result.WriteString(val)
-		} else {
+	for i, r := range runes {
+		lower := unicode.ToLower(r)
+		val, ok := table[lower]
+		if !ok {
 			result.WriteRune(r)
+			continue
+		}
+
+		var prev, next rune
+		if i > 0 {
+			prev = unicode.ToLower(runes[i-1])
 		}
+		if i+1 < len(runes) {
+			next = unicode.ToLower(runes[i+1])
+		}
+		val = contextual(scheme, table, lower, val, prev, next)
+
+		if unicode.IsUpper(r) {
+			val = restoreCase(val, runes, i)
+		}
+		result.WriteString(val)
 	}
 	return result.String()
 }
+
+// contextual применяет правила схем, зависящие от соседних букв.
+func contextual(scheme Scheme, table map[rune]string, r rune, val string, prev, next rune) string {
+	switch scheme {
+	case BGN:
+		// е/ё в начале слова и после гласных, й, ъ, ь: "Евгений" → "Yevgeniy"
+		if (r == 'е' || r == 'ё') && (prev == 0 || !unicode.IsLetter(prev) || bgnIotatingAfter[prev]) {
+			return "y" + val
+		}
+	case GOST779:
+		// ц перед i, e, y, j пишется как c: "Цыган" → "Cy`gan", "Царь" → "Czar`"
+		if r == 'ц' {
+			if nextVal, ok := table[next]; ok && nextVal != "" && strings.ContainsRune("ieyj", rune(nextVal[0])) {
+				return "c"
+			}
+		}
+	}
+	return val
+}
+
+// restoreCase поднимает регистр транслита заглавной буквы: целиком, если слово
+// набрано капсом, иначе только первую букву.
+func restoreCase(val string, runes []rune, i int) string {
+	if val == "" {
+		return val
+	}
+	allCaps := false
+	if i+1 < len(runes) && unicode.IsLetter(runes[i+1]) {
+		allCaps = unicode.IsUpper(runes[i+1])
+	} else if i > 0 && unicode.IsLetter(runes[i-1]) {
+		allCaps = unicode.IsUpper(runes[i-1])
+	}
+	if allCaps {
+		return strings.ToUpper(val)
+	}
+	first, size := utf8.DecodeRuneInString(val)
+	return string(unicode.ToUpper(first)) + val[size:]
+}
@@ -0,0 +1,114 @@
+package translit
+
+import "testing"
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		name   string
+		scheme Scheme
+		input  string
+		want   string
+	}{
+		{"bgn name", BGN, "Лев Толстой", "Lev Tolstoy"},
+		{"bgn initial ye", BGN, "Евгений Евтушенко", "Yevgeniy Yevtushenko"},
+		{"bgn yo", BGN, "Фёдор Достоевский", "Fëdor Dostoyevskiy"},
+		{"bgn soft sign", BGN, "Максим Горький", "Maksim Gorʹkiy"},
+		{"bgn all caps", BGN, "МХАТ", "MKHAT"},
+		{"bgn ukrainian", BGN, "Їжак", "Yizhak"},
+
+		{"icao passport", ICAO, "Юрий Гагарин", "Iurii Gagarin"},
+		{"icao hard sign", ICAO, "Подъезд", "Podieezd"},
+		{"icao shch", ICAO, "Щукин", "Shchukin"},
+
+		{"gost shh", GOST779, "Щука", "Shhuka"},
+		{"gost c before y", GOST779, "Цыган", "Cy`gan"},
+		{"gost cz", GOST779, "Царь", "Czar`"},
+		{"gost x", GOST779, "Чехов", "Chexov"},
+
+		{"iso9 hacek", ISO9, "Чехов", "Čehov"},
+		{"iso9 shch", ISO9, "Щука", "Ŝuka"},
+		{"iso9 yu", ISO9, "Юрий", "Ûrij"},
+		{"iso9 ukrainian", ISO9, "Київ", "Kiïv"},
+
+		{"practical yo", Practical, "Фёдор Достоевский", "Fyodor Dostoevskiy"},
+		{"practical drops signs", Practical, "Максим Горький", "Maksim Gorkiy"},
+		{"practical hard sign", Practical, "Подъезд", "Podezd"},
+
+		{"keeps non-cyrillic", BGN, "Глава 1: Start", "Glava 1: Start"},
+		{"unknown scheme falls back", Scheme("nope"), "Фёдор", "Fyodor"},
+		{"empty scheme is default", "", "Горький", "Gorkiy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Transliterate(tt.input, tt.scheme); got != tt.want {
+				t.Errorf("Transliterate(%q, %s) = %q, want %q", tt.input, tt.scheme, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToLatinPreservesCase(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Толстой", "Tolstoy"},
+		{"толстой", "tolstoy"},
+		{"ЩИ", "SHCHI"},
+		{"Щи", "Shchi"},
+	}
+
+	for _, tt := range tests {
+		if got := ToLatin(tt.input); got != tt.want {
+			t.Errorf("ToLatin(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestToCyrillic(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Tolstoy", "Толстой"},
+		{"Lev Tolstoy", "Лев Толстой"},
+		{"Dostoyevsky", "Достоевский"},
+		{"Chekhov", "Чехов"},
+		{"Pushkin", "Пушкин"},
+		{"Mayakovsky", "Маяковский"},
+		{"Yevgeniy", "Евгений"},
+		{"Bykov", "Быков"},
+		{"Čehov", "Чехов"},
+		{"MKHAT", "МХАТ"},
+		{"voyna i mir", "война и мир"},
+		{"Толстой", "Толстой"},
+	}
+
+	for _, tt := range tests {
+		if got := ToCyrillic(tt.input); got != tt.want {
+			t.Errorf("ToCyrillic(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestToSlug(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Война и мир", "voyna-i-mir"},
+		{"  Преступление   и наказание! ", "prestuplenie-i-nakazanie"},
+		{"Горький — Мать", "gorkiy-mat"},
+		{"Фёдор Достоевский", "fyodor-dostoevskiy"},
+		{"Ёлка", "yolka"},
+		{"Подъезд", "podezd"},
+		{"Мальчик с пальчик", "malchik-s-palchik"},
+	}
+
+	for _, tt := range tests {
+		if got := ToSlug(tt.input); got != tt.want {
+			t.Errorf("ToSlug(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"log/slog"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/tracing"
+)
+
+type AnnotationRepository interface {
+	Create(ctx context.Context, a *models.Annotation) error
+	Update(ctx context.Context, a *models.Annotation) error
+	Delete(ctx context.Context, id int) error
+
+	GetByID(ctx context.Context, id int) (*models.Annotation, error)
+	GetByUserAndBook(ctx context.Context, userID, bookID int) ([]models.Annotation, error)
+	GetPublicByBook(ctx context.Context, bookID int, statuses []string, limit, offset int) ([]models.Annotation, error)
+	SetStatus(ctx context.Context, id int, status string) error
+}
+
+type annotationRepo struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func NewAnnotationRepository(db *sql.DB, log *slog.Logger) AnnotationRepository {
+	return &annotationRepo{db: db, log: log}
+}
+
+const annotationColumns = `id, user_id, book_id, book_file_id, kind, locator, locator_type,
+	excerpt, color, note, is_public, status, created_at, updated_at`
+
+func scanAnnotation(row interface{ Scan(...interface{}) error }, a *models.Annotation) error {
+	return row.Scan(&a.ID, &a.UserID, &a.BookID, &a.BookFileID, &a.Kind, &a.Locator, &a.LocatorType,
+		&a.Excerpt, &a.Color, &a.Note, &a.IsPublic, &a.Status, &a.CreatedAt, &a.UpdatedAt)
+}
+
+func (r *annotationRepo) Create(ctx context.Context, a *models.Annotation) error {
+	ctx = tracing.WithQueryName(ctx, "annotationRepo.Create")
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO annotations (user_id, book_id, book_file_id, kind, locator, locator_type,
+		                         excerpt, color, note, is_public, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`, a.UserID, a.BookID, a.BookFileID, a.Kind, a.Locator, a.LocatorType,
+		a.Excerpt, a.Color, a.Note, a.IsPublic, a.Status,
+	).Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+	return dbError(err)
+}
+
+func (r *annotationRepo) Update(ctx context.Context, a *models.Annotation) error {
+	ctx = tracing.WithQueryName(ctx, "annotationRepo.Update")
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		UPDATE annotations
+		SET color = $1, note = $2, is_public = $3, status = $4, updated_at = NOW()
+		WHERE id = $5
+		RETURNING updated_at
+	`, a.Color, a.Note, a.IsPublic, a.Status, a.ID).Scan(&a.UpdatedAt)
+}
+
+func (r *annotationRepo) Delete(ctx context.Context, id int) error {
+	ctx = tracing.WithQueryName(ctx, "annotationRepo.Delete")
+	_, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM annotations WHERE id = $1`, id)
+	return err
+}
+
+func (r *annotationRepo) GetByID(ctx context.Context, id int) (*models.Annotation, error) {
+	ctx = tracing.WithQueryName(ctx, "annotationRepo.GetByID")
+	var a models.Annotation
+	err := scanAnnotation(conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+annotationColumns+` FROM annotations WHERE id = $1`, id), &a)
+	if err != nil {
+		return nil, notFound(err, ErrAnnotationNotFound)
+	}
+	return &a, nil
+}
+
+func (r *annotationRepo) GetByUserAndBook(ctx context.Context, userID, bookID int) ([]models.Annotation, error) {
+	ctx = tracing.WithQueryName(ctx, "annotationRepo.GetByUserAndBook")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+annotationColumns+`
+		FROM annotations
+		WHERE user_id = $1 AND book_id = $2
+		ORDER BY created_at
+	`, userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var result []models.Annotation
+	for rows.Next() {
+		var a models.Annotation
+		if err := scanAnnotation(rows, &a); err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+func (r *annotationRepo) GetPublicByBook(ctx context.Context, bookID int, statuses []string, limit, offset int) ([]models.Annotation, error) {
+	ctx = tracing.WithQueryName(ctx, "annotationRepo.GetPublicByBook")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+annotationColumns+`
+		FROM annotations
+		WHERE book_id = $1 AND is_public AND status = ANY($2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`, bookID, pq.Array(statuses), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var result []models.Annotation
+	for rows.Next() {
+		var a models.Annotation
+		if err := scanAnnotation(rows, &a); err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+func (r *annotationRepo) SetStatus(ctx context.Context, id int, status string) error {
+	ctx = tracing.WithQueryName(ctx, "annotationRepo.SetStatus")
+	_, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE annotations SET status = $1, updated_at = NOW() WHERE id = $2`, status, id)
+	return err
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/tracing"
+	"time"
+)
+
+type AuditRepository interface {
+	Write(ctx context.Context, e *models.AuditEntry) error
+	List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+	DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type auditRepo struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func NewAuditRepository(db *sql.DB, log *slog.Logger) AuditRepository {
+	return &auditRepo{db: db, log: log}
+}
+
+// Write пишет запись журнала; внутри WithinTx — в общей транзакции с изменением.
+func (r *auditRepo) Write(ctx context.Context, e *models.AuditEntry) error {
+	ctx = tracing.WithQueryName(ctx, "auditRepo.Write")
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO audit_log (actor_id, actor_role, action, target_type, target_id, before, after, ip, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`, e.ActorID, e.ActorRole, e.Action, e.TargetType, e.TargetID,
+		nullJSON(e.Before), nullJSON(e.After), e.IP, e.RequestID,
+	).Scan(&e.ID, &e.CreatedAt)
+}
+
+func nullJSON(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+func (r *auditRepo) List(ctx context.Context, f models.AuditFilter) ([]models.AuditEntry, error) {
+	ctx = tracing.WithQueryName(ctx, "auditRepo.List")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, actor_id, actor_role, action, target_type, target_id, before, after, ip, request_id, created_at
+		FROM audit_log
+		WHERE ($1 = 0 OR actor_id = $1)
+		  AND ($2 = '' OR action = $2)
+		  AND ($3 = '' OR target_type = $3)
+		  AND ($4 = 0 OR target_id = $4)
+		  AND ($5 = '' OR request_id = $5)
+		  AND ($6::timestamp IS NULL OR created_at >= $6)
+		  AND ($7::timestamp IS NULL OR created_at < $7)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $8 OFFSET $9
+	`, f.ActorID, f.Action, f.TargetType, f.TargetID, f.RequestID, f.From, f.To, f.Limit, f.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		var before, after []byte
+		err := rows.Scan(&e.ID, &e.ActorID, &e.ActorRole, &e.Action, &e.TargetType, &e.TargetID,
+			&before, &after, &e.IP, &e.RequestID, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		e.Before, e.After = before, after
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// DeleteOlderThan удаляет записи старше cutoff — единственный допустимый способ
+// что-то убрать из журнала.
+func (r *auditRepo) DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	ctx = tracing.WithQueryName(ctx, "auditRepo.DeleteOlderThan")
+	res, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM audit_log WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
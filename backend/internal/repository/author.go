@@ -1,42 +1,66 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"github.com/lib/pq"
+	"log/slog"
 	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/pkg/tracing"
 	"online_library/backend/internal/pkg/translit"
 )
 
+var (
+	ErrAuthorHasBooks  = apperr.Conflict("author_has_books", "author has books, merge it into another author instead")
+	ErrMergeIntoItself = apperr.Validation("merge_into_itself", "cannot merge an author into itself")
+)
+
 type AuthorRepository interface {
-	CreateAuthor(author *models.Author) error
-	UpdateAuthor(author *models.Author) error
-	DeleteAuthor(id int) error
-	GetAuthorByID(id int) (*models.Author, error)
-	SearchAuthorByName(query string, limit, offset int) ([]*models.Author, error)
-	GetAllAuthors(offset, limit int) ([]models.Author, error)
-	CountAuthors(query string) (int, error)
-	AuthorExists(nameRu, nameEn string, excludeID int) (bool, error)
+	CreateAuthor(ctx context.Context, author *models.Author) error
+	UpdateAuthor(ctx context.Context, author *models.Author) error
+	DeleteAuthor(ctx context.Context, id int) error
+	GetAuthorByID(ctx context.Context, id int) (*models.Author, error)
+	SearchAuthorByName(ctx context.Context, query string, limit, offset int) ([]*models.Author, error)
+	GetAllAuthors(ctx context.Context, offset, limit int) ([]models.Author, error)
+	CountAuthors(ctx context.Context, query string) (int, error)
+	AuthorExists(ctx context.Context, nameRu, nameEn string, excludeID int) (bool, error)
+
+	GetAliases(ctx context.Context, authorID int) ([]models.AuthorAlias, error)
+	AddAlias(ctx context.Context, alias *models.AuthorAlias) error
+	RemoveAlias(ctx context.Context, authorID, aliasID int) (models.AuthorAlias, error)
+	CountAuthorBooks(ctx context.Context, authorID int, statuses []string) (int, error)
+	CountAuthorBooksByRole(ctx context.Context, authorID int, statuses []string) (map[string]int, error)
+	GetCoAuthors(ctx context.Context, authorID int, statuses []string) ([]models.CoAuthor, error)
+
+	MergeAuthors(ctx context.Context, targetID int, sourceIDs []int, mergedBy int) ([]models.AuthorMerge, error)
+	GetMerges(ctx context.Context, limit, offset int) ([]models.AuthorMerge, error)
+	FindDuplicates(ctx context.Context, threshold float64, limit int) ([]models.DuplicateAuthors, error)
 }
 
 type authorRepository struct {
-	db *sql.DB
+	db  *sql.DB
+	log *slog.Logger
 }
 
-func NewAuthorRepository(db *sql.DB) AuthorRepository {
-	return &authorRepository{db: db}
+func NewAuthorRepository(db *sql.DB, log *slog.Logger) AuthorRepository {
+	return &authorRepository{db: db, log: log}
 }
 
-func (r *authorRepository) GetAuthorByID(id int) (*models.Author, error) {
+func (r *authorRepository) GetAuthorByID(ctx context.Context, id int) (*models.Author, error) {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.GetAuthorByID")
 	var a models.Author
-	err := r.db.QueryRow(`SELECT id, name_ru, name_en, bio, photo_url FROM authors WHERE id = $1`, id).
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT id, name_ru, name_en, bio, photo_url FROM authors WHERE id = $1`, id).
 		Scan(&a.ID, &a.NameRU, &a.NameEN, &a.Bio, &a.PhotoURL)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, ErrAuthorNotFound)
 	}
 	return &a, nil
 }
 
-func (r *authorRepository) GetAllAuthors(offset, limit int) ([]models.Author, error) {
-	rows, err := r.db.Query(`
+func (r *authorRepository) GetAllAuthors(ctx context.Context, offset, limit int) ([]models.Author, error) {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.GetAllAuthors")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT id, name_ru, name_en, bio, photo_url
 		FROM authors
 		ORDER BY name_ru ASC
@@ -48,7 +72,7 @@ func (r *authorRepository) GetAllAuthors(offset, limit int) ([]models.Author, er
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
@@ -64,12 +88,20 @@ func (r *authorRepository) GetAllAuthors(offset, limit int) ([]models.Author, er
 	return authors, nil
 }
 
-func (r *authorRepository) AuthorExists(nameRu, nameEn string, excludeID int) (bool, error) {
+func (r *authorRepository) AuthorExists(ctx context.Context, nameRu, nameEn string, excludeID int) (bool, error) {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.AuthorExists")
 	var id int
-	err := r.db.QueryRow(`
-		SELECT id FROM authors
-		WHERE (name_ru = $1 OR name_en = $2)
-		  AND id != $3
+	// Сравниваем без учёта регистра и с псевдонимами: "Лев Толстой" и "лев толстой" — один автор
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT a.id FROM authors a
+		WHERE a.id != $3
+		  AND (LOWER(a.name_ru) IN (LOWER(NULLIF($1, '')), LOWER(NULLIF($2, '')))
+		    OR LOWER(a.name_en) IN (LOWER(NULLIF($1, '')), LOWER(NULLIF($2, '')))
+		    OR EXISTS (
+		        SELECT 1 FROM author_aliases al
+		        WHERE al.author_id = a.id
+		          AND LOWER(al.name) IN (LOWER(NULLIF($1, '')), LOWER(NULLIF($2, '')))
+		    ))
 		LIMIT 1
 	`, nameRu, nameEn, excludeID).Scan(&id)
 
@@ -82,63 +114,78 @@ func (r *authorRepository) AuthorExists(nameRu, nameEn string, excludeID int) (b
 	return true, nil
 }
 
-func (r *authorRepository) CreateAuthor(author *models.Author) error {
-	if author.NameEN == "" {
-		author.NameEN = translit.ToLatin(author.NameRU)
-	}
-
+func (r *authorRepository) CreateAuthor(ctx context.Context, author *models.Author) error {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.CreateAuthor")
 	query := `
 		INSERT INTO authors (name_ru, name_en, bio, photo_url)
 		VALUES ($1, $2, $3, $4)
 		RETURNING id
 	`
 
-	err := r.db.QueryRow(query,
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
 		author.NameRU,
 		author.NameEN,
 		author.Bio,
 		author.PhotoURL,
 	).Scan(&author.ID)
 
-	return err
+	return dbError(err)
 }
 
-func (r *authorRepository) UpdateAuthor(author *models.Author) error {
+func (r *authorRepository) UpdateAuthor(ctx context.Context, author *models.Author) error {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.UpdateAuthor")
 	query := `
 		UPDATE authors
 		SET name_ru = $1, name_en = $2, bio = $3, photo_url = $4
 		WHERE id = $5
 	`
-	_, err := r.db.Exec(query,
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
 		author.NameRU,
 		author.NameEN,
 		author.Bio,
 		author.PhotoURL,
 		author.ID,
 	)
-	return err
+	return dbError(err)
 }
 
-func (r *authorRepository) DeleteAuthor(id int) error {
-	_, err := r.db.Exec(`DELETE FROM authors WHERE id = $1`, id)
+func (r *authorRepository) DeleteAuthor(ctx context.Context, id int) error {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.DeleteAuthor")
+	var hasBooks bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM book_authors WHERE author_id = $1)`, id).Scan(&hasBooks)
+	if err != nil {
+		return err
+	}
+	if hasBooks {
+		return ErrAuthorHasBooks
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, `DELETE FROM authors WHERE id = $1`, id)
 	return err
 }
 
-func (r *authorRepository) SearchAuthorByName(query string, limit, offset int) ([]*models.Author, error) {
-	rows, err := r.db.Query(`
+func (r *authorRepository) SearchAuthorByName(ctx context.Context, query string, limit, offset int) ([]*models.Author, error) {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.SearchAuthorByName")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT id, name_ru, name_en, bio, photo_url
 		FROM authors
 		WHERE name_ru ILIKE '%' || $1 || '%' OR name_en ILIKE '%' || $1 || '%'
+		   OR name_ru ILIKE '%' || $2 || '%'
+		   OR EXISTS (
+		       SELECT 1 FROM author_aliases al
+		       WHERE al.author_id = authors.id
+		         AND (al.name ILIKE '%' || $1 || '%' OR al.name ILIKE '%' || $2 || '%')
+		   )
 		ORDER BY name_ru ASC
-		LIMIT $2 OFFSET $3
-	`, query, limit, offset)
+		LIMIT $3 OFFSET $4
+	`, query, cyrillicVariant(query), limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
@@ -154,15 +201,329 @@ func (r *authorRepository) SearchAuthorByName(query string, limit, offset int) (
 	return authors, nil
 }
 
-func (r *authorRepository) CountAuthors(query string) (int, error) {
+func (r *authorRepository) CountAuthors(ctx context.Context, query string) (int, error) {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.CountAuthors")
 	var count int
-	err := r.db.QueryRow(`
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT COUNT(*)
 		FROM authors
 		WHERE name_ru ILIKE '%' || $1 || '%' OR name_en ILIKE '%' || $1 || '%'
-	`, query).Scan(&count)
+		   OR name_ru ILIKE '%' || $2 || '%'
+		   OR EXISTS (
+		       SELECT 1 FROM author_aliases al
+		       WHERE al.author_id = authors.id
+		         AND (al.name ILIKE '%' || $1 || '%' OR al.name ILIKE '%' || $2 || '%')
+		   )
+	`, query, cyrillicVariant(query)).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
 	return count, nil
 }
+
+func (r *authorRepository) GetAliases(ctx context.Context, authorID int) ([]models.AuthorAlias, error) {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.GetAliases")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, author_id, name, kind
+		FROM author_aliases
+		WHERE author_id = $1
+		ORDER BY name ASC
+	`, authorID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var aliases []models.AuthorAlias
+	for rows.Next() {
+		var a models.AuthorAlias
+		if err := rows.Scan(&a.ID, &a.AuthorID, &a.Name, &a.Kind); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, a)
+	}
+	return aliases, nil
+}
+
+func (r *authorRepository) AddAlias(ctx context.Context, alias *models.AuthorAlias) error {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.AddAlias")
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO author_aliases (author_id, name, kind)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, alias.AuthorID, alias.Name, alias.Kind).Scan(&alias.ID)
+	return dbError(err)
+}
+
+// RemoveAlias удаляет псевдоним и возвращает удалённую строку.
+func (r *authorRepository) RemoveAlias(ctx context.Context, authorID, aliasID int) (models.AuthorAlias, error) {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.RemoveAlias")
+	var a models.AuthorAlias
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		DELETE FROM author_aliases WHERE id = $1 AND author_id = $2
+		RETURNING id, author_id, name, kind
+	`, aliasID, authorID).Scan(&a.ID, &a.AuthorID, &a.Name, &a.Kind)
+	if err != nil {
+		return a, notFound(err, ErrAliasNotFound)
+	}
+	return a, nil
+}
+
+func (r *authorRepository) CountAuthorBooks(ctx context.Context, authorID int, statuses []string) (int, error) {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.CountAuthorBooks")
+	var count int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT b.id)
+		FROM books b
+		JOIN book_authors ba ON ba.book_id = b.id
+		WHERE ba.author_id = $1 AND b.status = ANY($2)
+	`, authorID, pq.Array(statuses)).Scan(&count)
+	return count, err
+}
+
+// CountAuthorBooksByRole возвращает число видимых книг автора в каждой из его ролей.
+func (r *authorRepository) CountAuthorBooksByRole(ctx context.Context, authorID int, statuses []string) (map[string]int, error) {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.CountAuthorBooksByRole")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT ba.role, COUNT(*)
+		FROM books b
+		JOIN book_authors ba ON ba.book_id = b.id
+		WHERE ba.author_id = $1 AND b.status = ANY($2)
+		GROUP BY ba.role
+	`, authorID, pq.Array(statuses))
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var role string
+		var count int
+		if err := rows.Scan(&role, &count); err != nil {
+			return nil, err
+		}
+		counts[role] = count
+	}
+	return counts, nil
+}
+
+// GetCoAuthors возвращает авторов, с которыми у автора есть общие книги среди видимых.
+func (r *authorRepository) GetCoAuthors(ctx context.Context, authorID int, statuses []string) ([]models.CoAuthor, error) {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.GetCoAuthors")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT a.id, a.name_ru, a.name_en, a.bio, a.photo_url, COUNT(DISTINCT b.id) AS shared
+		FROM book_authors own
+		JOIN books b ON b.id = own.book_id AND b.status = ANY($2)
+		JOIN book_authors other ON other.book_id = b.id AND other.author_id != own.author_id
+		JOIN authors a ON a.id = other.author_id
+		WHERE own.author_id = $1
+		GROUP BY a.id
+		ORDER BY shared DESC, a.name_ru ASC
+	`, authorID, pq.Array(statuses))
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var coAuthors []models.CoAuthor
+	for rows.Next() {
+		var a models.CoAuthor
+		err := rows.Scan(&a.ID, &a.NameRU, &a.NameEN, &a.Bio, &a.PhotoURL, &a.SharedBooks)
+		if err != nil {
+			return nil, err
+		}
+		coAuthors = append(coAuthors, a)
+	}
+	return coAuthors, nil
+}
+
+// MergeAuthors в одной транзакции переносит книги source-авторов на target,
+// сохраняет их имена и псевдонимы как псевдонимы target, пишет журнал и удаляет source.
+func (r *authorRepository) MergeAuthors(ctx context.Context, targetID int, sourceIDs []int, mergedBy int) ([]models.AuthorMerge, error) {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.MergeAuthors")
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
+	// Блокируем target, чтобы его не удалили и не слили параллельно
+	var targetRU, targetEN string
+	err = tx.QueryRowContext(ctx, `SELECT name_ru, name_en FROM authors WHERE id = $1 FOR UPDATE`, targetID).
+		Scan(&targetRU, &targetEN)
+	if err != nil {
+		return nil, notFound(err, ErrAuthorNotFound)
+	}
+
+	var merges []models.AuthorMerge
+	for _, sourceID := range sourceIDs {
+		if sourceID == targetID {
+			return nil, ErrMergeIntoItself
+		}
+
+		m := models.AuthorMerge{TargetAuthorID: &targetID, SourceAuthorID: sourceID, MergedBy: &mergedBy}
+		err = tx.QueryRowContext(ctx, `SELECT name_ru, name_en FROM authors WHERE id = $1 FOR UPDATE`, sourceID).
+			Scan(&m.SourceNameRU, &m.SourceNameEN)
+		if err != nil {
+			return nil, notFound(err, ErrAuthorNotFound)
+		}
+
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO book_authors (book_id, author_id, role, position)
+			SELECT book_id, $1, role, position FROM book_authors WHERE author_id = $2
+			ON CONFLICT DO NOTHING
+		`, targetID, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		moved, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		m.BooksMoved = int(moved)
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM book_authors WHERE author_id = $1`, sourceID); err != nil {
+			return nil, err
+		}
+
+		// Имена проигравшего автора и его псевдонимы становятся псевдонимами target
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO author_aliases (author_id, name, kind)
+			SELECT $1, name, kind FROM (
+				SELECT $3::varchar AS name, $5::varchar AS kind
+				UNION ALL SELECT $4::varchar, $5::varchar
+				UNION ALL SELECT name, kind FROM author_aliases WHERE author_id = $2
+			) src
+			WHERE name <> '' AND LOWER(name) NOT IN (LOWER($6), LOWER($7))
+			ON CONFLICT (author_id, LOWER(name)) DO NOTHING
+		`, targetID, sourceID, m.SourceNameRU, m.SourceNameEN, models.AliasSpelling, targetRU, targetEN)
+		if err != nil {
+			return nil, err
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO author_merges (target_author_id, source_author_id, source_name_ru, source_name_en, books_moved, merged_by)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, merged_at
+		`, targetID, sourceID, m.SourceNameRU, m.SourceNameEN, m.BooksMoved, mergedBy).Scan(&m.ID, &m.MergedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM authors WHERE id = $1`, sourceID); err != nil {
+			return nil, err
+		}
+		merges = append(merges, m)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return merges, nil
+}
+
+func (r *authorRepository) GetMerges(ctx context.Context, limit, offset int) ([]models.AuthorMerge, error) {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.GetMerges")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, target_author_id, source_author_id, source_name_ru, source_name_en,
+		       books_moved, merged_by, merged_at
+		FROM author_merges
+		ORDER BY merged_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var merges []models.AuthorMerge
+	for rows.Next() {
+		var m models.AuthorMerge
+		err := rows.Scan(&m.ID, &m.TargetAuthorID, &m.SourceAuthorID, &m.SourceNameRU, &m.SourceNameEN,
+			&m.BooksMoved, &m.MergedBy, &m.MergedAt)
+		if err != nil {
+			return nil, err
+		}
+		merges = append(merges, m)
+	}
+	return merges, nil
+}
+
+// FindDuplicates ищет пары авторов с похожими именами (pg_trgm), сравнивая
+// name_ru и name_en в том числе перекрёстно — транслитерация даёт разные варианты.
+func (r *authorRepository) FindDuplicates(ctx context.Context, threshold float64, limit int) ([]models.DuplicateAuthors, error) {
+	ctx = tracing.WithQueryName(ctx, "authorRepository.FindDuplicates")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT * FROM (
+			SELECT a1.id, a1.name_ru, a1.name_en, a2.id, a2.name_ru, a2.name_en,
+			       GREATEST(
+			           similarity(LOWER(a1.name_ru), LOWER(a2.name_ru)),
+			           similarity(LOWER(a1.name_en), LOWER(a2.name_en)),
+			           similarity(LOWER(a1.name_ru), LOWER(a2.name_en)),
+			           similarity(LOWER(a1.name_en), LOWER(a2.name_ru))
+			       ) AS score
+			FROM authors a1
+			JOIN authors a2 ON a1.id < a2.id
+			  AND (a1.name_ru % a2.name_ru OR a1.name_en % a2.name_en
+			    OR a1.name_ru % a2.name_en OR a1.name_en % a2.name_ru)
+		) pairs
+		WHERE score >= $1
+		ORDER BY score DESC
+		LIMIT $2
+	`, threshold, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var result []models.DuplicateAuthors
+	for rows.Next() {
+		var d models.DuplicateAuthors
+		err := rows.Scan(&d.First.ID, &d.First.NameRU, &d.First.NameEN,
+			&d.Second.ID, &d.Second.NameRU, &d.Second.NameEN, &d.Similarity)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, nil
+}
+
+// cyrillicVariant — кириллическое написание латинского запроса для поиска по name_ru.
+func cyrillicVariant(query string) string {
+	if !translit.HasLatin(query) {
+		return query
+	}
+	return translit.ToCyrillic(query)
+}
@@ -1,82 +1,242 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/lib/pq"
+	"log/slog"
 	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/pkg/tracing"
 	"strings"
 )
 
+var (
+	ErrBookStatusChanged   = apperr.Conflict("book_status_changed", "book status has been changed by someone else")
+	ErrBookVersionConflict = apperr.Conflict("book_version_conflict", "book has been changed since the given version")
+	ErrRelatedNotFound     = apperr.Validation("related_not_found", "referenced record does not exist")
+)
+
 type BookRepository interface {
-	CreateBook(book *models.Book) (int, error)
-	UpdateBook(book *models.Book) error
-	DeleteBook(id int) error
-	GetBookByID(id int, allowedStatuses []string) (*models.Book, error)
-	GetBooksByStatuses(statuses []string, offset, limit int) ([]models.Book, error)
-	GetBooksByAuthor(authorID int, statuses []string, limit, offset int) ([]models.Book, error)
-	GetBooksByTag(tagID int, statuses []string, limit, offset int) ([]models.Book, error)
-	SetBookAuthors(bookID int, authorIDs []int) error
-	AddBookAuthor(bookID, authorID int) error
-	RemoveBookAuthor(bookID, authorID int) error
-	SetBookTags(bookID int, tagIDs []int) error
-	AddBookTag(bookID, tagID int) error
-	RemoveBookTag(bookID, tagID int) error
-	SearchBooks(query string, allowedStatuses []string, limit, offset int) ([]*models.Book, error)
-	GetDuplicateBooks(title string) ([]*models.Book, error)
-	GetUserBooks(userID int) ([]*models.Book, error)
-	GetUserFavoriteBooks(userID int, statuses []string) ([]*models.Book, error)
-	AddBookToFavorites(userID, bookID int) error
-	RemoveBookFromFavorites(userID, bookID int) error
-	UpdateBookStatus(bookID int, status string) error
-	GetBookMeta(bookID int) (*models.Book, error) // Только базовые данные: id, created_by
+	CreateBook(ctx context.Context, book *models.Book) (int, error)
+	UpdateBook(ctx context.Context, book *models.Book, rev *models.BookRevision, expectedVersion int) error
+	GetRevisions(ctx context.Context, bookID int, limit, offset int) ([]models.BookRevision, error)
+	GetRevision(ctx context.Context, bookID, revisionID int) (*models.BookRevision, error)
+	DeleteBook(ctx context.Context, id int) error
+	GetBookByID(ctx context.Context, id int, allowedStatuses []string) (*models.Book, error)
+	GetBooksByStatuses(ctx context.Context, statuses []string, offset, limit int) ([]models.Book, error)
+	GetBooksByAuthor(ctx context.Context, authorID int, role string, statuses []string, limit, offset int) ([]models.Book, error)
+	GetBooksByTag(ctx context.Context, tagID int, statuses []string, limit, offset int) ([]models.Book, error)
+	SetBookAuthors(ctx context.Context, bookID int, contributors []models.BookContributor) error
+	AddBookAuthor(ctx context.Context, bookID int, contributor models.BookContributor) error
+	RemoveBookAuthor(ctx context.Context, bookID, authorID int, role string) error
+	GetBookContributors(ctx context.Context, bookID int) ([]models.Contributor, error)
+	SetBookTags(ctx context.Context, bookID int, tags []models.BookTag) error
+	SetBookCategories(ctx context.Context, bookID int, categoryIDs []int) error
+	GetBookTags(ctx context.Context, bookID int) ([]models.BookTag, error)
+	AddBookTag(ctx context.Context, bookID, tagID, weight int) error
+	RemoveBookTag(ctx context.Context, bookID, tagID int) error
+	SearchBooks(ctx context.Context, query string, allowedStatuses []string, sortBy string, limit, offset int) ([]*models.Book, error)
+	GetDuplicateBooks(ctx context.Context, title string) ([]*models.Book, error)
+	GetUserBooks(ctx context.Context, userID int) ([]*models.Book, error)
+	GetUserFavoriteBooks(ctx context.Context, userID int, statuses []string) ([]*models.Book, error)
+	AddBookToFavorites(ctx context.Context, userID, bookID int) error
+	RemoveBookFromFavorites(ctx context.Context, userID, bookID int) error
+	ChangeBookStatus(ctx context.Context, bookID int, from, to string, changedBy int, reason *string) error
+	GetStatusHistory(ctx context.Context, bookID int) ([]models.BookStatusChange, error)
+	GetReviewQueue(ctx context.Context, limit, offset int) ([]models.ReviewQueueItem, error)
+	GetBookMeta(ctx context.Context, bookID int) (*models.Book, error) // Только базовые данные: id, title, status, created_by
 }
 
 type bookRepository struct {
-	db *sql.DB
+	db  *sql.DB
+	log *slog.Logger
 }
 
-func NewBookRepository(db *sql.DB) BookRepository {
-	return &bookRepository{db: db}
+func NewBookRepository(db *sql.DB, log *slog.Logger) BookRepository {
+	return &bookRepository{db: db, log: log}
 }
 
-func (r *bookRepository) CreateBook(book *models.Book) (int, error) {
+func (r *bookRepository) CreateBook(ctx context.Context, book *models.Book) (int, error) {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.CreateBook")
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
 	query := `
-		INSERT INTO books (title, description, publish_year, pages, language, publisher, type, rating, cover_url, status, created_at)
+		INSERT INTO books (title, description, publish_year, pages, language, publisher, type, cover_url, status, created_by, created_at)
 		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,NOW())
-		RETURNING id
+		RETURNING id, version
 	`
-	var id int
-	err := r.db.QueryRow(query,
+	err = tx.QueryRowContext(ctx, query,
 		book.Title, book.Description, book.PublishYear, book.Pages,
-		book.Language, book.Publisher, book.Type, book.Rating,
-		book.CoverURL, book.Status,
-	).Scan(&id)
-	return id, err
+		book.Language, book.Publisher, book.Type,
+		book.CoverURL, book.Status, book.CreatedBy,
+	).Scan(&book.ID, &book.Version)
+	if err != nil {
+		return 0, dbError(err)
+	}
+
+	// Первая ревизия — исходное состояние, к нему тоже можно откатиться
+	note := "создание"
+	rev := &models.BookRevision{ChangedBy: &book.CreatedBy, Note: &note}
+	if err := insertRevision(ctx, tx, book, rev); err != nil {
+		return 0, err
+	}
+	return book.ID, tx.Commit()
+}
+
+// UpdateBook сохраняет редактируемые поля и ревизию с изменениями rev.
+// expectedVersion = 0 — без проверки версии, иначе при расхождении
+// возвращается ErrBookVersionConflict.
+func (r *bookRepository) UpdateBook(ctx context.Context, book *models.Book, rev *models.BookRevision, expectedVersion int) error {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.UpdateBook")
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
+	if err := updateBook(ctx, tx, book, expectedVersion); err != nil {
+		return err
+	}
+	if err := insertRevision(ctx, tx, book, rev); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (r *bookRepository) UpdateBook(book *models.Book) error {
+// updateBook записывает поля книги и увеличивает версию, новая версия — в book.Version.
+func updateBook(ctx context.Context, db dbtx, book *models.Book, expectedVersion int) error {
+	// Статус меняется только через ChangeBookStatus, чтобы не обходить модерацию
 	query := `
 		UPDATE books
 		SET title=$1, description=$2, publish_year=$3, pages=$4, language=$5,
-		    publisher=$6, type=$7, rating=$8, cover_url=$9, status=$10
-		WHERE id=$11
+		    publisher=$6, type=$7, cover_url=$8, version = version + 1
+		WHERE id=$9 AND ($10 = 0 OR version = $10)
+		RETURNING version
 	`
-	_, err := r.db.Exec(query,
+	err := db.QueryRowContext(ctx, query,
 		book.Title, book.Description, book.PublishYear, book.Pages,
-		book.Language, book.Publisher, book.Type, book.Rating,
-		book.CoverURL, book.Status, book.ID,
-	)
-	return err
+		book.Language, book.Publisher, book.Type,
+		book.CoverURL, book.ID, expectedVersion,
+	).Scan(&book.Version)
+	if errors.Is(err, sql.ErrNoRows) && expectedVersion != 0 {
+		return ErrBookVersionConflict
+	}
+	if err != nil {
+		return notFound(err, ErrBookNotFound)
+	}
+	return nil
+}
+
+// insertRevision пишет ревизию для текущей версии книги со снимком её полей.
+func insertRevision(ctx context.Context, db dbtx, book *models.Book, rev *models.BookRevision) error {
+	if rev.Changes == nil {
+		rev.Changes = []models.FieldDiff{}
+	}
+	changes, err := json.Marshal(rev.Changes)
+	if err != nil {
+		return err
+	}
+	rev.BookID = book.ID
+	rev.Version = book.Version
+	rev.Snapshot = models.SnapshotOf(book)
+	snapshot, err := json.Marshal(rev.Snapshot)
+	if err != nil {
+		return err
+	}
+	return db.QueryRowContext(ctx, `
+		INSERT INTO book_revisions (book_id, version, changed_by, changes, note, snapshot)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, changed_at
+	`, rev.BookID, rev.Version, rev.ChangedBy, changes, rev.Note, snapshot).Scan(&rev.ID, &rev.ChangedAt)
+}
+
+const revisionColumns = `
+	SELECT rv.id, rv.book_id, rv.version, rv.changed_by, u.name, rv.changed_at, rv.changes, rv.note, rv.snapshot
+	FROM book_revisions rv
+	LEFT JOIN users u ON u.id = rv.changed_by`
+
+func scanRevision(row interface{ Scan(...interface{}) error }) (*models.BookRevision, error) {
+	var rev models.BookRevision
+	var changes, snapshot []byte
+	err := row.Scan(&rev.ID, &rev.BookID, &rev.Version, &rev.ChangedBy, &rev.ChangerName,
+		&rev.ChangedAt, &changes, &rev.Note, &snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(changes, &rev.Changes); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(snapshot, &rev.Snapshot); err != nil {
+		return nil, err
+	}
+	return &rev, nil
 }
 
-func (r *bookRepository) DeleteBook(id int) error {
-	_, err := r.db.Exec("DELETE FROM books WHERE id=$1", id)
+// GetRevisions — ревизии книги, новые первыми.
+func (r *bookRepository) GetRevisions(ctx context.Context, bookID int, limit, offset int) ([]models.BookRevision, error) {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.GetRevisions")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, revisionColumns+`
+		WHERE rv.book_id = $1
+		ORDER BY rv.version DESC
+		LIMIT $2 OFFSET $3`, bookID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var revisions []models.BookRevision
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *rev)
+	}
+	return revisions, rows.Err()
+}
+
+func (r *bookRepository) GetRevision(ctx context.Context, bookID, revisionID int) (*models.BookRevision, error) {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.GetRevision")
+	rev, err := scanRevision(conn(ctx, r.db).QueryRowContext(ctx, revisionColumns+` WHERE rv.book_id = $1 AND rv.id = $2`, bookID, revisionID))
+	if err != nil {
+		return nil, notFound(err, ErrRevisionNotFound)
+	}
+	return rev, nil
+}
+
+func (r *bookRepository) DeleteBook(ctx context.Context, id int) error {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.DeleteBook")
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM books WHERE id=$1", id)
 	return err
 }
 
-func (r *bookRepository) GetBookByID(id int, allowedStatuses []string) (*models.Book, error) {
+func (r *bookRepository) GetBookByID(ctx context.Context, id int, allowedStatuses []string) (*models.Book, error) {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.GetBookByID")
 	if len(allowedStatuses) == 0 {
-		return nil, fmt.Errorf("no statuses allowed")
+		return nil, ErrBookNotFound
 	}
 
 	placeholders := make([]string, len(allowedStatuses))
@@ -89,24 +249,25 @@ func (r *bookRepository) GetBookByID(id int, allowedStatuses []string) (*models.
 	}
 
 	query := "SELECT id, title, description, publish_year, pages, language, " +
-		"publisher, type, rating, cover_url, status, created_at " +
+		"publisher, type, rating_avg, rating_count, cover_url, status, COALESCE(created_by, 0), created_at, version " +
 		"FROM books " +
 		"WHERE id = $1 AND status IN (" + strings.Join(placeholders, ", ") + ")"
 
-	row := r.db.QueryRow(query, args...)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, args...)
 	var b models.Book
 	err := row.Scan(
 		&b.ID, &b.Title, &b.Description, &b.PublishYear, &b.Pages,
-		&b.Language, &b.Publisher, &b.Type, &b.Rating,
-		&b.CoverURL, &b.Status, &b.CreatedAt,
+		&b.Language, &b.Publisher, &b.Type, &b.RatingAvg, &b.RatingCount,
+		&b.CoverURL, &b.Status, &b.CreatedBy, &b.CreatedAt, &b.Version,
 	)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, ErrBookNotFound)
 	}
 	return &b, nil
 }
 
-func (r *bookRepository) GetBooksByStatuses(statuses []string, offset, limit int) ([]models.Book, error) {
+func (r *bookRepository) GetBooksByStatuses(ctx context.Context, statuses []string, offset, limit int) ([]models.Book, error) {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.GetBooksByStatuses")
 	if len(statuses) == 0 {
 		return nil, fmt.Errorf("no statuses provided")
 	}
@@ -122,20 +283,20 @@ func (r *bookRepository) GetBooksByStatuses(statuses []string, offset, limit int
 	args[len(statuses)+1] = offset
 
 	query := "SELECT id, title, description, publish_year, pages, language, " +
-		"publisher, type, rating, cover_url, status, created_at " +
+		"publisher, type, rating_avg, rating_count, cover_url, status, created_at " +
 		"FROM books " +
 		"WHERE status IN (" + strings.Join(placeholders, ", ") + ") " +
 		"ORDER BY created_at DESC " +
 		"LIMIT $" + fmt.Sprint(len(statuses)+1) + " OFFSET $" + fmt.Sprint(len(statuses)+2)
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
@@ -144,7 +305,7 @@ func (r *bookRepository) GetBooksByStatuses(statuses []string, offset, limit int
 		var b models.Book
 		err := rows.Scan(
 			&b.ID, &b.Title, &b.Description, &b.PublishYear, &b.Pages,
-			&b.Language, &b.Publisher, &b.Type, &b.Rating,
+			&b.Language, &b.Publisher, &b.Type, &b.RatingAvg, &b.RatingCount,
 			&b.CoverURL, &b.Status, &b.CreatedAt,
 		)
 		if err != nil {
@@ -155,38 +316,30 @@ func (r *bookRepository) GetBooksByStatuses(statuses []string, offset, limit int
 	return books, nil
 }
 
-func (r *bookRepository) GetBooksByAuthor(authorID int, statuses []string, limit, offset int) ([]models.Book, error) {
+// GetBooksByAuthor возвращает книги, где автор участвует в роли role (пустая роль — в любой).
+func (r *bookRepository) GetBooksByAuthor(ctx context.Context, authorID int, role string, statuses []string, limit, offset int) ([]models.Book, error) {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.GetBooksByAuthor")
 	if len(statuses) == 0 {
 		return nil, fmt.Errorf("no statuses provided")
 	}
 
-	placeholders := make([]string, len(statuses))
-	args := make([]interface{}, len(statuses)+3)
-	args[0] = authorID
-
-	for i, status := range statuses {
-		placeholders[i] = fmt.Sprintf("$%d", i+2)
-		args[i+1] = status
-	}
-	args[len(statuses)+1] = limit
-	args[len(statuses)+2] = offset
-
 	query := "SELECT b.id, b.title, b.description, b.publish_year, b.pages, b.language, " +
-		"b.publisher, b.type, b.rating, b.cover_url, b.status, b.created_at " +
+		"b.publisher, b.type, b.rating_avg, b.rating_count, b.cover_url, b.status, b.created_at " +
 		"FROM books b " +
-		"JOIN book_authors ba ON b.id = ba.book_id " +
-		"WHERE ba.author_id = $1 AND b.status IN (" + strings.Join(placeholders, ", ") + ") " +
+		"WHERE EXISTS (SELECT 1 FROM book_authors ba " +
+		"WHERE ba.book_id = b.id AND ba.author_id = $1 AND ($2 = '' OR ba.role = $2)) " +
+		"AND b.status = ANY($3) " +
 		"ORDER BY b.created_at DESC " +
-		"LIMIT $" + fmt.Sprint(len(statuses)+2) + " OFFSET $" + fmt.Sprint(len(statuses)+3)
+		"LIMIT $4 OFFSET $5"
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, authorID, role, pq.Array(statuses), limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
@@ -195,7 +348,7 @@ func (r *bookRepository) GetBooksByAuthor(authorID int, statuses []string, limit
 		var b models.Book
 		err := rows.Scan(
 			&b.ID, &b.Title, &b.Description, &b.PublishYear, &b.Pages,
-			&b.Language, &b.Publisher, &b.Type, &b.Rating,
+			&b.Language, &b.Publisher, &b.Type, &b.RatingAvg, &b.RatingCount,
 			&b.CoverURL, &b.Status, &b.CreatedAt,
 		)
 		if err != nil {
@@ -206,38 +359,29 @@ func (r *bookRepository) GetBooksByAuthor(authorID int, statuses []string, limit
 	return books, nil
 }
 
-func (r *bookRepository) GetBooksByTag(tagID int, statuses []string, limit, offset int) ([]models.Book, error) {
+// GetBooksByTag возвращает книги с тегом: сначала те, где он основной.
+func (r *bookRepository) GetBooksByTag(ctx context.Context, tagID int, statuses []string, limit, offset int) ([]models.Book, error) {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.GetBooksByTag")
 	if len(statuses) == 0 {
 		return nil, fmt.Errorf("no statuses provided")
 	}
 
-	placeholders := make([]string, len(statuses))
-	args := make([]interface{}, len(statuses)+3)
-	args[0] = tagID
-
-	for i, status := range statuses {
-		placeholders[i] = fmt.Sprintf("$%d", i+2)
-		args[i+1] = status
-	}
-	args[len(statuses)+1] = limit
-	args[len(statuses)+2] = offset
-
 	query := "SELECT b.id, b.title, b.description, b.publish_year, b.pages, b.language, " +
-		"b.publisher, b.type, b.rating, b.cover_url, b.status, b.created_at " +
+		"b.publisher, b.type, b.rating_avg, b.rating_count, b.cover_url, b.status, b.created_at " +
 		"FROM books b " +
 		"JOIN book_tags bt ON b.id = bt.book_id " +
-		"WHERE bt.tag_id = $1 AND b.status IN (" + strings.Join(placeholders, ", ") + ") " +
-		"ORDER BY b.created_at DESC" +
-		"LIMIT $" + fmt.Sprint(len(statuses)+2) + " OFFSET $" + fmt.Sprint(len(statuses)+3)
+		"WHERE bt.tag_id = $1 AND b.status = ANY($2) " +
+		"ORDER BY bt.weight DESC, b.created_at DESC " +
+		"LIMIT $3 OFFSET $4"
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, tagID, pq.Array(statuses), limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
@@ -246,7 +390,7 @@ func (r *bookRepository) GetBooksByTag(tagID int, statuses []string, limit, offs
 		var b models.Book
 		err := rows.Scan(
 			&b.ID, &b.Title, &b.Description, &b.PublishYear, &b.Pages,
-			&b.Language, &b.Publisher, &b.Type, &b.Rating,
+			&b.Language, &b.Publisher, &b.Type, &b.RatingAvg, &b.RatingCount,
 			&b.CoverURL, &b.Status, &b.CreatedAt,
 		)
 		if err != nil {
@@ -257,61 +401,152 @@ func (r *bookRepository) GetBooksByTag(tagID int, statuses []string, limit, offs
 	return books, nil
 }
 
-func (r *bookRepository) SetBookAuthors(bookID int, authorIDs []int) error {
-	tx, err := r.db.Begin()
+func (r *bookRepository) SetBookAuthors(ctx context.Context, bookID int, contributors []models.BookContributor) error {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.SetBookAuthors")
+	tx, err := beginTx(ctx, r.db)
 	if err != nil {
 		return err
 	}
-	defer func(tx *sql.Tx) {
+	defer func(tx *txn) {
 		err := tx.Rollback()
 		if err != nil {
 
 		}
 	}(tx)
 
-	_, err = tx.Exec("DELETE FROM book_authors WHERE book_id = $1", bookID)
+	if err := replaceBookAuthors(ctx, tx, bookID, contributors); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceBookAuthors(ctx context.Context, tx dbtx, bookID int, contributors []models.BookContributor) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM book_authors WHERE book_id = $1", bookID)
 	if err != nil {
 		return err
 	}
 
-	for _, authorID := range authorIDs {
-		_, err := tx.Exec("INSERT INTO book_authors (book_id, author_id) VALUES ($1, $2)", bookID, authorID)
+	for _, c := range contributors {
+		_, err := tx.ExecContext(ctx, "INSERT INTO book_authors (book_id, author_id, role, position) VALUES ($1, $2, $3, $4)",
+			bookID, c.AuthorID, c.Role, c.Position)
+		if isForeignKeyViolation(err) {
+			return relatedNotFound(err, "author", c.AuthorID)
+		}
 		if err != nil {
 			return err
 		}
 	}
-	return tx.Commit()
+	return nil
 }
 
-func (r *bookRepository) AddBookAuthor(bookID, authorID int) error {
-	_, err := r.db.Exec("INSERT INTO book_authors (book_id, author_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", bookID, authorID)
-	return err
+func (r *bookRepository) AddBookAuthor(ctx context.Context, bookID int, c models.BookContributor) error {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.AddBookAuthor")
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO book_authors (book_id, author_id, role, position) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (book_id, author_id, role) DO UPDATE SET position = EXCLUDED.position
+	`, bookID, c.AuthorID, c.Role, c.Position)
+	return dbError(err)
 }
 
-func (r *bookRepository) RemoveBookAuthor(bookID, authorID int) error {
-	_, err := r.db.Exec("DELETE FROM book_authors WHERE book_id = $1 AND author_id = $2", bookID, authorID)
+// RemoveBookAuthor убирает автора из книги в роли role, а при пустой роли — во всех ролях.
+func (r *bookRepository) RemoveBookAuthor(ctx context.Context, bookID, authorID int, role string) error {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.RemoveBookAuthor")
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM book_authors WHERE book_id = $1 AND author_id = $2 AND ($3 = '' OR role = $3)",
+		bookID, authorID, role)
 	return err
 }
 
-func (r *bookRepository) SetBookTags(bookID int, tagIDs []int) error {
-	tx, err := r.db.Begin()
+// GetBookContributors возвращает участников книги в порядке обложки: сначала по роли, затем по позиции.
+func (r *bookRepository) GetBookContributors(ctx context.Context, bookID int) ([]models.Contributor, error) {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.GetBookContributors")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT a.id, a.name_ru, a.name_en, ba.role, ba.position
+		FROM book_authors ba
+		JOIN authors a ON a.id = ba.author_id
+		WHERE ba.book_id = $1
+		ORDER BY array_position($2::text[], ba.role::text), ba.position, a.name_ru
+	`, bookID, pq.Array(models.ContributorRoles))
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var contributors []models.Contributor
+	for rows.Next() {
+		var c models.Contributor
+		if err := rows.Scan(&c.ID, &c.NameRU, &c.NameEN, &c.Role, &c.Position); err != nil {
+			return nil, err
+		}
+		contributors = append(contributors, c)
+	}
+	return contributors, nil
+}
+
+func (r *bookRepository) SetBookTags(ctx context.Context, bookID int, tags []models.BookTag) error {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.SetBookTags")
+	tx, err := beginTx(ctx, r.db)
 	if err != nil {
 		return err
 	}
-	defer func(tx *sql.Tx) {
+	defer func(tx *txn) {
 		err := tx.Rollback()
 		if err != nil {
 
 		}
 	}(tx)
 
-	_, err = tx.Exec("DELETE FROM book_tags WHERE book_id = $1", bookID)
+	if err := replaceBookTags(ctx, tx, bookID, tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceBookTags(ctx context.Context, tx dbtx, bookID int, tags []models.BookTag) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM book_tags WHERE book_id = $1", bookID)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tags {
+		_, err := tx.ExecContext(ctx, "INSERT INTO book_tags (book_id, tag_id, weight) VALUES ($1, $2, $3)", bookID, t.TagID, t.Weight)
+		if isForeignKeyViolation(err) {
+			return relatedNotFound(err, "tag", t.TagID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetBookCategories заменяет категории книги.
+func (r *bookRepository) SetBookCategories(ctx context.Context, bookID int, categoryIDs []int) error {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.SetBookCategories")
+	tx, err := beginTx(ctx, r.db)
 	if err != nil {
 		return err
 	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
 
-	for _, tagID := range tagIDs {
-		_, err := tx.Exec("INSERT INTO book_tags (book_id, tag_id, weight) VALUES ($1, $2, 1)", bookID, tagID)
+	if _, err := tx.ExecContext(ctx, "DELETE FROM book_categories WHERE book_id = $1", bookID); err != nil {
+		return err
+	}
+	for _, id := range categoryIDs {
+		_, err := tx.ExecContext(ctx, "INSERT INTO book_categories (book_id, category_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+			bookID, id)
+		if isForeignKeyViolation(err) {
+			return relatedNotFound(err, "category", id)
+		}
 		if err != nil {
 			return err
 		}
@@ -319,50 +554,214 @@ func (r *bookRepository) SetBookTags(bookID int, tagIDs []int) error {
 	return tx.Commit()
 }
 
-func (r *bookRepository) AddBookTag(bookID, tagID int) error {
-	_, err := r.db.Exec("INSERT INTO book_tags (book_id, tag_id, weight) VALUES ($1, $2, 1) ON CONFLICT DO NOTHING", bookID, tagID)
-	return err
+// GetBookTags — теги книги с весами, основные первыми.
+func (r *bookRepository) GetBookTags(ctx context.Context, bookID int) ([]models.BookTag, error) {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.GetBookTags")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT bt.book_id, bt.tag_id, t.name, bt.weight
+		FROM book_tags bt
+		JOIN tags t ON t.id = bt.tag_id
+		WHERE bt.book_id = $1
+		ORDER BY bt.weight DESC, t.name`, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var tags []models.BookTag
+	for rows.Next() {
+		var t models.BookTag
+		if err := rows.Scan(&t.BookID, &t.TagID, &t.TagName, &t.Weight); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
 }
 
-func (r *bookRepository) RemoveBookTag(bookID, tagID int) error {
-	_, err := r.db.Exec("DELETE FROM book_tags WHERE book_id = $1 AND tag_id = $2", bookID, tagID)
-	return err
+func (r *bookRepository) AddBookTag(ctx context.Context, bookID, tagID, weight int) error {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.AddBookTag")
+	_, err := conn(ctx, r.db).ExecContext(ctx, "INSERT INTO book_tags (book_id, tag_id, weight) VALUES ($1, $2, $3) "+
+		"ON CONFLICT (book_id, tag_id) DO UPDATE SET weight = EXCLUDED.weight", bookID, tagID, weight)
+	return dbError(err)
 }
 
-func (r *bookRepository) UpdateBookStatus(bookID int, status string) error {
-	_, err := r.db.Exec("UPDATE books SET status = $1 WHERE id = $2", status, bookID)
+func (r *bookRepository) RemoveBookTag(ctx context.Context, bookID, tagID int) error {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.RemoveBookTag")
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM book_tags WHERE book_id = $1 AND tag_id = $2", bookID, tagID)
 	return err
 }
 
-func (r *bookRepository) SearchBooks(query string, allowedStatuses []string, limit, offset int) ([]*models.Book, error) {
+// ChangeBookStatus переводит книгу из статуса from в to и пишет запись в историю.
+// Если статус успел смениться, возвращает ErrBookStatusChanged.
+func (r *bookRepository) ChangeBookStatus(ctx context.Context, bookID int, from, to string, changedBy int, reason *string) error {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.ChangeBookStatus")
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
+	res, err := tx.ExecContext(ctx, `UPDATE books SET status = $1 WHERE id = $2 AND status = $3`, to, bookID, from)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrBookStatusChanged
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO book_status_history (book_id, from_status, to_status, reason, changed_by)
+		VALUES ($1, $2, $3, $4, $5)`, bookID, from, to, reason, changedBy)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *bookRepository) GetStatusHistory(ctx context.Context, bookID int) ([]models.BookStatusChange, error) {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.GetStatusHistory")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, book_id, from_status, to_status, reason, changed_by, changed_at
+		FROM book_status_history
+		WHERE book_id = $1
+		ORDER BY changed_at DESC, id DESC`, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var history []models.BookStatusChange
+	for rows.Next() {
+		var h models.BookStatusChange
+		if err := rows.Scan(&h.ID, &h.BookID, &h.FromStatus, &h.ToStatus, &h.Reason, &h.ChangedBy, &h.ChangedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+// GetReviewQueue — книги на модерации, сначала дольше всех ожидающие.
+// Время отправки берётся из истории, для старых записей — created_at.
+func (r *bookRepository) GetReviewQueue(ctx context.Context, limit, offset int) ([]models.ReviewQueueItem, error) {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.GetReviewQueue")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT b.id, b.title, b.description, b.publish_year, b.pages, b.language,
+		       b.publisher, b.type, b.rating_avg, b.rating_count, b.cover_url, b.status,
+		       COALESCE(b.created_by, 0), b.created_at,
+		       u.id, u.email, u.name,
+		       COALESCE(
+		           (SELECT MAX(h.changed_at) FROM book_status_history h
+		            WHERE h.book_id = b.id AND h.to_status = $1),
+		           b.created_at) AS submitted_at
+		FROM books b
+		LEFT JOIN users u ON u.id = b.created_by
+		WHERE b.status = $1
+		ORDER BY submitted_at ASC, b.id ASC
+		LIMIT $2 OFFSET $3`, models.StatusBookQuarantine, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var queue []models.ReviewQueueItem
+	for rows.Next() {
+		var item models.ReviewQueueItem
+		var submitterID sql.NullInt64
+		var submitterEmail sql.NullString
+		var submitterName *string
+		err := rows.Scan(&item.ID, &item.Title, &item.Description, &item.PublishYear, &item.Pages,
+			&item.Language, &item.Publisher, &item.Type, &item.RatingAvg, &item.RatingCount,
+			&item.CoverURL, &item.Status, &item.CreatedBy, &item.CreatedAt,
+			&submitterID, &submitterEmail, &submitterName, &item.SubmittedAt)
+		if err != nil {
+			return nil, err
+		}
+		if submitterID.Valid {
+			item.Submitter = &models.Submitter{
+				ID:    int(submitterID.Int64),
+				Email: submitterEmail.String,
+				Name:  submitterName,
+			}
+		}
+		queue = append(queue, item)
+	}
+	return queue, rows.Err()
+}
+
+// bookTagMatch — теги книги, чьё название подходит под запрос ($1 или его кириллический вариант $2)
+const bookTagMatch = "FROM book_tags bt JOIN tags t ON t.id = bt.tag_id " +
+	"WHERE bt.book_id = books.id AND (t.name ILIKE $1 OR t.name ILIKE $2)"
+
+func (r *bookRepository) SearchBooks(ctx context.Context, query string, allowedStatuses []string, sortBy string, limit, offset int) ([]*models.Book, error) {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.SearchBooks")
 	if len(allowedStatuses) == 0 {
 		return nil, fmt.Errorf("no allowed statuses")
 	}
 
+	// Латинский запрос дополнительно ищем в кириллице: "Tolstoy" найдёт "Толстой"
+	alt := cyrillicVariant(query)
+
 	placeholders := make([]string, len(allowedStatuses))
 	args := make([]interface{}, 0)
-	args = append(args, "%"+query+"%")
+	args = append(args, "%"+query+"%", "%"+alt+"%")
 	for i, status := range allowedStatuses {
-		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		placeholders[i] = fmt.Sprintf("$%d", i+3)
 		args = append(args, status)
 	}
 	args = append(args, limit, offset)
 
-	q := "SELECT id, title, description, publish_year, pages, language, publisher, type, rating, cover_url, status, created_at " +
+	orderBy := "created_at DESC"
+	switch sortBy {
+	case models.BookSortRating:
+		orderBy = weightedRatingExpr("books") + " DESC, rating_count DESC, created_at DESC"
+	case models.BookSortRelevance:
+		orderBy = "(CASE WHEN title ILIKE $1 OR title ILIKE $2 THEN 2 ELSE 0 END) + " +
+			"COALESCE((SELECT MAX(CASE WHEN bt.weight = 1 THEN 1.0 ELSE 0.5 END) " + bookTagMatch + "), 0) DESC, " +
+			"created_at DESC"
+	}
+
+	q := "SELECT id, title, description, publish_year, pages, language, publisher, type, rating_avg, rating_count, cover_url, status, created_at " +
 		"FROM books " +
-		"WHERE (title ILIKE $1 OR description ILIKE $1) " +
+		"WHERE (title ILIKE $1 OR description ILIKE $1 OR title ILIKE $2 OR description ILIKE $2 " +
+		"OR EXISTS (SELECT 1 " + bookTagMatch + ")) " +
 		"AND status IN (" + strings.Join(placeholders, ", ") + ") " +
-		"ORDER BY created_at DESC " +
+		"ORDER BY " + orderBy + " " +
 		"LIMIT $" + fmt.Sprint(len(args)-1) + " OFFSET $" + fmt.Sprint(len(args))
 
-	rows, err := r.db.Query(q, args...)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
@@ -370,7 +769,7 @@ func (r *bookRepository) SearchBooks(query string, allowedStatuses []string, lim
 	for rows.Next() {
 		var b models.Book
 		err := rows.Scan(&b.ID, &b.Title, &b.Description, &b.PublishYear, &b.Pages,
-			&b.Language, &b.Publisher, &b.Type, &b.Rating,
+			&b.Language, &b.Publisher, &b.Type, &b.RatingAvg, &b.RatingCount,
 			&b.CoverURL, &b.Status, &b.CreatedAt)
 		if err != nil {
 			return nil, err
@@ -380,10 +779,11 @@ func (r *bookRepository) SearchBooks(query string, allowedStatuses []string, lim
 	return books, nil
 }
 
-func (r *bookRepository) GetDuplicateBooks(title string) ([]*models.Book, error) {
-	rows, err := r.db.Query(`
+func (r *bookRepository) GetDuplicateBooks(ctx context.Context, title string) ([]*models.Book, error) {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.GetDuplicateBooks")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT id, title, description, publish_year, pages, language,
-		       publisher, type, rating, cover_url, status, created_at
+		       publisher, type, rating_avg, rating_count, cover_url, status, created_at
 		FROM books
 		WHERE LOWER(title) LIKE LOWER($1)`, "%"+title+"%")
 	if err != nil {
@@ -392,7 +792,7 @@ func (r *bookRepository) GetDuplicateBooks(title string) ([]*models.Book, error)
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
@@ -400,7 +800,7 @@ func (r *bookRepository) GetDuplicateBooks(title string) ([]*models.Book, error)
 	for rows.Next() {
 		var b models.Book
 		err := rows.Scan(&b.ID, &b.Title, &b.Description, &b.PublishYear, &b.Pages,
-			&b.Language, &b.Publisher, &b.Type, &b.Rating,
+			&b.Language, &b.Publisher, &b.Type, &b.RatingAvg, &b.RatingCount,
 			&b.CoverURL, &b.Status, &b.CreatedAt)
 		if err != nil {
 			return nil, err
@@ -410,10 +810,11 @@ func (r *bookRepository) GetDuplicateBooks(title string) ([]*models.Book, error)
 	return books, nil
 }
 
-func (r *bookRepository) GetUserBooks(userID int) ([]*models.Book, error) {
-	rows, err := r.db.Query(`
+func (r *bookRepository) GetUserBooks(ctx context.Context, userID int) ([]*models.Book, error) {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.GetUserBooks")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT id, title, description, publish_year, pages, language,
-		       publisher, type, rating, cover_url, status, created_at
+		       publisher, type, rating_avg, rating_count, cover_url, status, created_at
 		FROM books
 		WHERE created_by = $1
 		ORDER BY created_at DESC`, userID)
@@ -423,7 +824,7 @@ func (r *bookRepository) GetUserBooks(userID int) ([]*models.Book, error) {
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
@@ -431,7 +832,7 @@ func (r *bookRepository) GetUserBooks(userID int) ([]*models.Book, error) {
 	for rows.Next() {
 		var b models.Book
 		err := rows.Scan(&b.ID, &b.Title, &b.Description, &b.PublishYear, &b.Pages,
-			&b.Language, &b.Publisher, &b.Type, &b.Rating,
+			&b.Language, &b.Publisher, &b.Type, &b.RatingAvg, &b.RatingCount,
 			&b.CoverURL, &b.Status, &b.CreatedAt)
 		if err != nil {
 			return nil, err
@@ -441,7 +842,8 @@ func (r *bookRepository) GetUserBooks(userID int) ([]*models.Book, error) {
 	return books, nil
 }
 
-func (r *bookRepository) GetUserFavoriteBooks(userID int, statuses []string) ([]*models.Book, error) {
+func (r *bookRepository) GetUserFavoriteBooks(ctx context.Context, userID int, statuses []string) ([]*models.Book, error) {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.GetUserFavoriteBooks")
 	if len(statuses) == 0 {
 		return []*models.Book{}, nil // Возвращаем пустой список, если доступных статусов нет
 	}
@@ -457,20 +859,20 @@ func (r *bookRepository) GetUserFavoriteBooks(userID int, statuses []string) ([]
 
 	query :=
 		"SELECT b.id, b.title, b.description, b.publish_year, b.pages, b.language, " +
-			"b.publisher, b.type, b.rating, b.cover_url, b.status, b.created_at " +
+			"b.publisher, b.type, b.rating_avg, b.rating_count, b.cover_url, b.status, b.created_at " +
 			"FROM books b " +
 			"JOIN book_favorites f ON b.id = f.book_id " +
 			"WHERE f.user_id = $1 AND b.status IN (" + strings.Join(placeholders, ", ") + ") " +
 			"ORDER BY f.created_at DESC"
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
@@ -478,7 +880,7 @@ func (r *bookRepository) GetUserFavoriteBooks(userID int, statuses []string) ([]
 	for rows.Next() {
 		var b models.Book
 		err := rows.Scan(&b.ID, &b.Title, &b.Description, &b.PublishYear, &b.Pages,
-			&b.Language, &b.Publisher, &b.Type, &b.Rating,
+			&b.Language, &b.Publisher, &b.Type, &b.RatingAvg, &b.RatingCount,
 			&b.CoverURL, &b.Status, &b.CreatedAt)
 		if err != nil {
 			return nil, err
@@ -488,30 +890,33 @@ func (r *bookRepository) GetUserFavoriteBooks(userID int, statuses []string) ([]
 	return books, nil
 }
 
-func (r *bookRepository) AddBookToFavorites(userID, bookID int) error {
-	_, err := r.db.Exec(`
+func (r *bookRepository) AddBookToFavorites(ctx context.Context, userID, bookID int) error {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.AddBookToFavorites")
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
 		INSERT INTO book_favorites (user_id, book_id, created_at)
 		VALUES ($1, $2, NOW())
 		ON CONFLICT DO NOTHING
 	`, userID, bookID)
-	return err
+	return dbError(err)
 }
 
-func (r *bookRepository) RemoveBookFromFavorites(userID, bookID int) error {
-	_, err := r.db.Exec(`
+func (r *bookRepository) RemoveBookFromFavorites(ctx context.Context, userID, bookID int) error {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.RemoveBookFromFavorites")
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
 		DELETE FROM book_favorites
 		WHERE user_id = $1 AND book_id = $2
 	`, userID, bookID)
 	return err
 }
 
-func (r *bookRepository) GetBookMeta(bookID int) (*models.Book, error) {
-	query := `SELECT id, created_by FROM books WHERE id = $1`
-	row := r.db.QueryRow(query, bookID)
+func (r *bookRepository) GetBookMeta(ctx context.Context, bookID int) (*models.Book, error) {
+	ctx = tracing.WithQueryName(ctx, "bookRepository.GetBookMeta")
+	query := `SELECT id, title, status, COALESCE(created_by, 0) FROM books WHERE id = $1`
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, bookID)
 	var book models.Book
-	err := row.Scan(&book.ID, &book.CreatedBy)
+	err := row.Scan(&book.ID, &book.Title, &book.Status, &book.CreatedBy)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, ErrBookNotFound)
 	}
 	return &book, nil
 }
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/pkg/tracing"
+)
+
+var ErrEditNotPending = apperr.Conflict("edit_not_pending", "edit proposal has already been reviewed")
+
+type BookEditRepository interface {
+	Create(ctx context.Context, p *models.BookEditProposal) error
+	GetByID(ctx context.Context, id int) (*models.BookEditProposal, error)
+	GetByBook(ctx context.Context, bookID int, status string, limit, offset int) ([]models.BookEditProposal, error)
+	GetPending(ctx context.Context, ownerID int, limit, offset int) ([]models.BookEditProposal, error)
+	GetByUser(ctx context.Context, userID int, limit, offset int) ([]models.BookEditProposal, error)
+	Apply(ctx context.Context, p *models.BookEditProposal, book *models.Book, rev *models.BookRevision, reviewerID int, comment *string) error
+	Reject(ctx context.Context, id, reviewerID int, comment *string) error
+}
+
+type bookEditRepo struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func NewBookEditRepository(db *sql.DB, log *slog.Logger) BookEditRepository {
+	return &bookEditRepo{db: db, log: log}
+}
+
+const bookEditColumns = `
+	p.id, p.book_id, b.title, p.proposed_by, u.name, p.changes, p.comment, p.status,
+	p.reviewed_by, p.reviewed_at, p.review_comment, p.created_at`
+
+const bookEditFrom = `
+	FROM book_edit_proposals p
+	JOIN books b ON b.id = p.book_id
+	LEFT JOIN users u ON u.id = p.proposed_by`
+
+func scanBookEdit(row interface{ Scan(...interface{}) error }) (*models.BookEditProposal, error) {
+	var p models.BookEditProposal
+	var changes []byte
+	err := row.Scan(&p.ID, &p.BookID, &p.BookTitle, &p.ProposedBy, &p.ProposerName, &changes, &p.Comment,
+		&p.Status, &p.ReviewedBy, &p.ReviewedAt, &p.ReviewComment, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(changes, &p.Changes); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *bookEditRepo) queryBookEdits(ctx context.Context, query string, args ...interface{}) ([]models.BookEditProposal, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var result []models.BookEditProposal
+	for rows.Next() {
+		p, err := scanBookEdit(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *p)
+	}
+	return result, rows.Err()
+}
+
+func (r *bookEditRepo) Create(ctx context.Context, p *models.BookEditProposal) error {
+	ctx = tracing.WithQueryName(ctx, "bookEditRepo.Create")
+	changes, err := json.Marshal(p.Changes)
+	if err != nil {
+		return err
+	}
+	err = conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO book_edit_proposals (book_id, proposed_by, changes, comment)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, status, created_at
+	`, p.BookID, p.ProposedBy, changes, p.Comment).Scan(&p.ID, &p.Status, &p.CreatedAt)
+	return dbError(err)
+}
+
+func (r *bookEditRepo) GetByID(ctx context.Context, id int) (*models.BookEditProposal, error) {
+	ctx = tracing.WithQueryName(ctx, "bookEditRepo.GetByID")
+	row := conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+bookEditColumns+bookEditFrom+` WHERE p.id = $1`, id)
+	p, err := scanBookEdit(row)
+	if err != nil {
+		return nil, notFound(err, ErrEditNotFound)
+	}
+	return p, nil
+}
+
+// GetByBook — правки книги; пустой status — все.
+func (r *bookEditRepo) GetByBook(ctx context.Context, bookID int, status string, limit, offset int) ([]models.BookEditProposal, error) {
+	ctx = tracing.WithQueryName(ctx, "bookEditRepo.GetByBook")
+	return r.queryBookEdits(ctx, `SELECT `+bookEditColumns+bookEditFrom+`
+		WHERE p.book_id = $1 AND ($2 = '' OR p.status = $2)
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $3 OFFSET $4`, bookID, status, limit, offset)
+}
+
+// GetPending — очередь ожидающих правок, старые первыми.
+// ownerID = 0 — по всем книгам (для админа), иначе только по книгам владельца.
+func (r *bookEditRepo) GetPending(ctx context.Context, ownerID int, limit, offset int) ([]models.BookEditProposal, error) {
+	ctx = tracing.WithQueryName(ctx, "bookEditRepo.GetPending")
+	return r.queryBookEdits(ctx, `SELECT `+bookEditColumns+bookEditFrom+`
+		WHERE p.status = $1 AND ($2 = 0 OR b.created_by = $2)
+		ORDER BY p.created_at ASC, p.id ASC
+		LIMIT $3 OFFSET $4`, models.EditStatusPending, ownerID, limit, offset)
+}
+
+func (r *bookEditRepo) GetByUser(ctx context.Context, userID int, limit, offset int) ([]models.BookEditProposal, error) {
+	ctx = tracing.WithQueryName(ctx, "bookEditRepo.GetByUser")
+	return r.queryBookEdits(ctx, `SELECT `+bookEditColumns+bookEditFrom+`
+		WHERE p.proposed_by = $1
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $2 OFFSET $3`, userID, limit, offset)
+}
+
+// Apply принимает правку: поля книги, ревизия, авторы, теги и статус предложения
+// меняются в одной транзакции. book — уже собранная итоговая книга; если книгу
+// успели изменить после её чтения, возвращается ErrBookVersionConflict.
+func (r *bookEditRepo) Apply(ctx context.Context, p *models.BookEditProposal, book *models.Book, rev *models.BookRevision, reviewerID int, comment *string) error {
+	ctx = tracing.WithQueryName(ctx, "bookEditRepo.Apply")
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
+	if err := markReviewed(ctx, tx, p.ID, models.EditStatusAccepted, reviewerID, comment); err != nil {
+		return err
+	}
+	if err := updateBook(ctx, tx, book, book.Version); err != nil {
+		return err
+	}
+	if err := insertRevision(ctx, tx, book, rev); err != nil {
+		return err
+	}
+	if p.Changes.Authors != nil {
+		if err := replaceBookAuthors(ctx, tx, p.BookID, *p.Changes.Authors); err != nil {
+			return err
+		}
+	}
+	if p.Changes.Tags != nil {
+		if err := replaceBookTags(ctx, tx, p.BookID, *p.Changes.Tags); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *bookEditRepo) Reject(ctx context.Context, id, reviewerID int, comment *string) error {
+	ctx = tracing.WithQueryName(ctx, "bookEditRepo.Reject")
+	return markReviewed(ctx, conn(ctx, r.db), id, models.EditStatusRejected, reviewerID, comment)
+}
+
+// markReviewed закрывает только ожидающую правку, иначе ErrEditNotPending.
+func markReviewed(ctx context.Context, db dbtx, id int, status string, reviewerID int, comment *string) error {
+	res, err := db.ExecContext(ctx, `
+		UPDATE book_edit_proposals
+		SET status = $1, reviewed_by = $2, reviewed_at = NOW(), review_comment = $3
+		WHERE id = $4 AND status = $5
+	`, status, reviewerID, comment, id, models.EditStatusPending)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrEditNotPending
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/tracing"
+)
+
+type BookFileRepository interface {
+	GetBookFileByID(ctx context.Context, id int) (*models.BookFile, error)
+	GetBookFileByHash(ctx context.Context, hash string) (*models.BookFile, error)
+	GetFilesByBook(ctx context.Context, bookID int) ([]models.BookFile, error)
+}
+
+type bookFileRepo struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func NewBookFileRepository(db *sql.DB, log *slog.Logger) BookFileRepository {
+	return &bookFileRepo{db: db, log: log}
+}
+
+func (r *bookFileRepo) GetBookFileByID(ctx context.Context, id int) (*models.BookFile, error) {
+	ctx = tracing.WithQueryName(ctx, "bookFileRepo.GetBookFileByID")
+	var f models.BookFile
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, book_id, format, url, file_size, hash, created_at
+		FROM book_files
+		WHERE id = $1
+	`, id).Scan(&f.ID, &f.BookID, &f.Format, &f.URL, &f.FileSize, &f.Hash, &f.CreatedAt)
+	if err != nil {
+		return nil, notFound(err, ErrFileNotFound)
+	}
+	return &f, nil
+}
+
+func (r *bookFileRepo) GetBookFileByHash(ctx context.Context, hash string) (*models.BookFile, error) {
+	ctx = tracing.WithQueryName(ctx, "bookFileRepo.GetBookFileByHash")
+	var f models.BookFile
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, book_id, format, url, file_size, hash, created_at
+		FROM book_files
+		WHERE hash = $1
+		LIMIT 1
+	`, hash).Scan(&f.ID, &f.BookID, &f.Format, &f.URL, &f.FileSize, &f.Hash, &f.CreatedAt)
+	if err != nil {
+		return nil, notFound(err, ErrFileNotFound)
+	}
+	return &f, nil
+}
+
+func (r *bookFileRepo) GetFilesByBook(ctx context.Context, bookID int) ([]models.BookFile, error) {
+	ctx = tracing.WithQueryName(ctx, "bookFileRepo.GetFilesByBook")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, book_id, format, url, file_size, hash, created_at
+		FROM book_files
+		WHERE book_id = $1
+		ORDER BY format
+	`, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var files []models.BookFile
+	for rows.Next() {
+		var f models.BookFile
+		if err := rows.Scan(&f.ID, &f.BookID, &f.Format, &f.URL, &f.FileSize, &f.Hash, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
@@ -1,38 +1,43 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"log/slog"
 	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/tracing"
 )
 
 type CategoryRepository interface {
-	GetAllCategories() ([]models.Category, error)
-	GetRootCategories() ([]*models.Category, error)
-	GetCategoryByID(id int) (*models.Category, error)
-	GetCategoryChildren(parentID int) ([]*models.Category, error)
-	GetBooksByCategoryIDRecursive(categoryID int) ([]*models.Book, error)
-	CreateCategory(category *models.Category) (int, error)
-	UpdateCategory(category *models.Category) error
-	DeleteCategory(id int) error
+	GetAllCategories(ctx context.Context) ([]models.Category, error)
+	GetRootCategories(ctx context.Context) ([]*models.Category, error)
+	GetCategoryByID(ctx context.Context, id int) (*models.Category, error)
+	GetCategoryChildren(ctx context.Context, parentID int) ([]*models.Category, error)
+	GetBooksByCategoryIDRecursive(ctx context.Context, categoryID int) ([]*models.Book, error)
+	CreateCategory(ctx context.Context, category *models.Category) (int, error)
+	UpdateCategory(ctx context.Context, category *models.Category) error
+	DeleteCategory(ctx context.Context, id int) error
 }
 
 type categoryRepository struct {
-	db *sql.DB
+	db  *sql.DB
+	log *slog.Logger
 }
 
-func NewCategoryRepository(db *sql.DB) CategoryRepository {
-	return &categoryRepository{db}
+func NewCategoryRepository(db *sql.DB, log *slog.Logger) CategoryRepository {
+	return &categoryRepository{db: db, log: log}
 }
 
-func (r *categoryRepository) GetAllCategories() ([]models.Category, error) {
-	rows, err := r.db.Query(`SELECT id, name, parent_id, slug, description FROM categories`)
+func (r *categoryRepository) GetAllCategories(ctx context.Context) ([]models.Category, error) {
+	ctx = tracing.WithQueryName(ctx, "categoryRepository.GetAllCategories")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT id, name, parent_id, slug, description FROM categories`)
 	if err != nil {
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
@@ -48,14 +53,16 @@ func (r *categoryRepository) GetAllCategories() ([]models.Category, error) {
 	return categories, nil
 }
 
-func (r *categoryRepository) GetRootCategories() ([]*models.Category, error) {
-	rows, err := r.db.Query(`SELECT id, name, parent_id, slug, description FROM categories WHERE parent_id IS NULL`)
+func (r *categoryRepository) GetRootCategories(ctx context.Context) ([]*models.Category, error) {
+	ctx = tracing.WithQueryName(ctx, "categoryRepository.GetRootCategories")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT id, name, parent_id, slug, description FROM categories WHERE parent_id IS NULL`)
 	if err != nil {
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
@@ -70,21 +77,23 @@ func (r *categoryRepository) GetRootCategories() ([]*models.Category, error) {
 	return result, nil
 }
 
-func (r *categoryRepository) GetCategoryByID(id int) (*models.Category, error) {
+func (r *categoryRepository) GetCategoryByID(ctx context.Context, id int) (*models.Category, error) {
+	ctx = tracing.WithQueryName(ctx, "categoryRepository.GetCategoryByID")
 	var category models.Category
-	err := r.db.QueryRow(`
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT id, name, parent_id, slug, description 
 		FROM categories 
 		WHERE id = $1`, id).
 		Scan(&category.ID, &category.Name, &category.ParentID, &category.Slug, &category.Description)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, ErrCategoryNotFound)
 	}
 	return &category, nil
 }
 
-func (r *categoryRepository) GetCategoryChildren(parentID int) ([]*models.Category, error) {
-	rows, err := r.db.Query(`
+func (r *categoryRepository) GetCategoryChildren(ctx context.Context, parentID int) ([]*models.Category, error) {
+	ctx = tracing.WithQueryName(ctx, "categoryRepository.GetCategoryChildren")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT id, name, parent_id, slug, description 
 		FROM categories 
 		WHERE parent_id = $1`, parentID)
@@ -94,7 +103,7 @@ func (r *categoryRepository) GetCategoryChildren(parentID int) ([]*models.Catego
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
@@ -109,7 +118,8 @@ func (r *categoryRepository) GetCategoryChildren(parentID int) ([]*models.Catego
 	return categories, nil
 }
 
-func (r *categoryRepository) GetBooksByCategoryIDRecursive(categoryID int) ([]*models.Book, error) {
+func (r *categoryRepository) GetBooksByCategoryIDRecursive(ctx context.Context, categoryID int) ([]*models.Book, error) {
+	ctx = tracing.WithQueryName(ctx, "categoryRepository.GetBooksByCategoryIDRecursive")
 	query := `
 	WITH RECURSIVE subcategories AS (
 		SELECT id FROM categories WHERE id = $1
@@ -118,26 +128,26 @@ func (r *categoryRepository) GetBooksByCategoryIDRecursive(categoryID int) ([]*m
 		FROM categories c
 		INNER JOIN subcategories sc ON sc.id = c.parent_id
 	)
-	SELECT b.id, b.title, b.rating, b.cover_url
+	SELECT b.id, b.title, b.rating_avg, b.rating_count, b.cover_url
 	FROM books b
 	JOIN book_categories bc ON b.id = bc.book_id
 	WHERE bc.category_id IN (SELECT id FROM subcategories);`
 
-	rows, err := r.db.Query(query, categoryID)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, categoryID)
 	if err != nil {
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
 	var books []*models.Book
 	for rows.Next() {
 		var book models.Book
-		err := rows.Scan(&book.ID, &book.Title, &book.Rating, &book.CoverURL)
+		err := rows.Scan(&book.ID, &book.Title, &book.RatingAvg, &book.RatingCount, &book.CoverURL)
 		if err != nil {
 			return nil, err
 		}
@@ -146,26 +156,41 @@ func (r *categoryRepository) GetBooksByCategoryIDRecursive(categoryID int) ([]*m
 	return books, nil
 }
 
-func (r *categoryRepository) CreateCategory(category *models.Category) (int, error) {
+func (r *categoryRepository) CreateCategory(ctx context.Context, category *models.Category) (int, error) {
+	ctx = tracing.WithQueryName(ctx, "categoryRepository.CreateCategory")
 	var id int
-	err := r.db.QueryRow(`
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
 		INSERT INTO categories (name, parent_id, slug, description) 
 		VALUES ($1, $2, $3, $4) RETURNING id`,
 		category.Name, category.ParentID, category.Slug, category.Description,
 	).Scan(&id)
-	return id, err
+	return id, dbError(err)
 }
 
-func (r *categoryRepository) UpdateCategory(category *models.Category) error {
-	_, err := r.db.Exec(`
+func (r *categoryRepository) UpdateCategory(ctx context.Context, category *models.Category) error {
+	ctx = tracing.WithQueryName(ctx, "categoryRepository.UpdateCategory")
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
 		UPDATE categories SET name = $1, parent_id = $2, slug = $3, description = $4 
 		WHERE id = $5`,
 		category.Name, category.ParentID, category.Slug, category.Description, category.ID,
 	)
-	return err
+	if err != nil {
+		return dbError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrCategoryNotFound.WithCause(sql.ErrNoRows)
+	}
+	return nil
 }
 
-func (r *categoryRepository) DeleteCategory(id int) error {
-	_, err := r.db.Exec(`DELETE FROM categories WHERE id = $1`, id)
-	return err
+func (r *categoryRepository) DeleteCategory(ctx context.Context, id int) error {
+	ctx = tracing.WithQueryName(ctx, "categoryRepository.DeleteCategory")
+	res, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM categories WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrCategoryNotFound.WithCause(sql.ErrNoRows)
+	}
+	return nil
 }
@@ -1,63 +1,76 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"github.com/lib/pq"
+	"log/slog"
 	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/tracing"
 )
 
 type CommentRepository interface {
-	Create(comment *models.Comment) error
-	Update(comment *models.Comment) error
-	Delete(id int) error
+	Create(ctx context.Context, comment *models.Comment) error
+	Update(ctx context.Context, comment *models.Comment) error
+	Delete(ctx context.Context, id int) error
 
-	GetByID(id int) (*models.Comment, error)
-	GetByBookID(bookID int, limit, offset int, statuses []string) ([]models.Comment, error)
-	GetByUserID(userID int, limit, offset int) ([]models.Comment, error)
-	GetLast(limit int) ([]models.Comment, error)
-	SetStatus(id int, status string) error
+	GetByID(ctx context.Context, id int) (*models.Comment, error)
+	GetByBookID(ctx context.Context, bookID int, limit, offset int, statuses []string) ([]models.Comment, error)
+	GetByUserID(ctx context.Context, userID int, limit, offset int) ([]models.Comment, error)
+	GetLast(ctx context.Context, limit int) ([]models.Comment, error)
+	SetStatus(ctx context.Context, id int, status string) error
 
-	CountByBook(bookID int) (int, error)
+	CountByBook(ctx context.Context, bookID int) (int, error)
 }
 
 type commentRepo struct {
-	db *sql.DB
+	db  *sql.DB
+	log *slog.Logger
 }
 
-func NewCommentRepository(db *sql.DB) CommentRepository {
-	return &commentRepo{db: db}
+func NewCommentRepository(db *sql.DB, log *slog.Logger) CommentRepository {
+	return &commentRepo{db: db, log: log}
 }
 
-func (r *commentRepo) Create(comment *models.Comment) error {
+func (r *commentRepo) Create(ctx context.Context, comment *models.Comment) error {
+	ctx = tracing.WithQueryName(ctx, "commentRepo.Create")
 	query := `
 		INSERT INTO comments (book_id, user_id, text, created_at, updated_at, status)
 		VALUES ($1, $2, $3, NOW(), NOW(), $4)
 		RETURNING id`
-	return r.db.QueryRow(query, comment.BookID, comment.UserID, comment.Text, comment.Status).Scan(&comment.ID)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, comment.BookID, comment.UserID, comment.Text, comment.Status).Scan(&comment.ID)
+	return dbError(err)
 }
 
-func (r *commentRepo) Update(comment *models.Comment) error {
+func (r *commentRepo) Update(ctx context.Context, comment *models.Comment) error {
+	ctx = tracing.WithQueryName(ctx, "commentRepo.Update")
 	query := `
 		UPDATE comments
 		SET text = $1, updated_at = NOW(), status = $2
 		WHERE id = $3`
-	_, err := r.db.Exec(query, comment.Text, comment.Status, comment.ID)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, comment.Text, comment.Status, comment.ID)
 	return err
 }
 
-func (r *commentRepo) Delete(id int) error {
-	_, err := r.db.Exec(`DELETE FROM comments WHERE id = $1`, id)
+func (r *commentRepo) Delete(ctx context.Context, id int) error {
+	ctx = tracing.WithQueryName(ctx, "commentRepo.Delete")
+	_, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM comments WHERE id = $1`, id)
 	return err
 }
 
-func (r *commentRepo) GetByID(id int) (*models.Comment, error) {
+func (r *commentRepo) GetByID(ctx context.Context, id int) (*models.Comment, error) {
+	ctx = tracing.WithQueryName(ctx, "commentRepo.GetByID")
 	var c models.Comment
 	query := `SELECT id, book_id, user_id, text, created_at, updated_at, status FROM comments WHERE id = $1`
-	err := r.db.QueryRow(query, id).Scan(&c.ID, &c.BookID, &c.UserID, &c.Text, &c.CreatedAt, &c.UpdatedAt, &c.Status)
-	return &c, err
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&c.ID, &c.BookID, &c.UserID, &c.Text, &c.CreatedAt, &c.UpdatedAt, &c.Status)
+	if err != nil {
+		return nil, notFound(err, ErrCommentNotFound)
+	}
+	return &c, nil
 }
 
-func (r *commentRepo) GetByBookID(bookID int, limit, offset int, statuses []string) ([]models.Comment, error) {
+func (r *commentRepo) GetByBookID(ctx context.Context, bookID int, limit, offset int, statuses []string) ([]models.Comment, error) {
+	ctx = tracing.WithQueryName(ctx, "commentRepo.GetByBookID")
 	var comments []models.Comment
 
 	query := `SELECT id, book_id, user_id, text, created_at, updated_at, status
@@ -66,14 +79,14 @@ func (r *commentRepo) GetByBookID(bookID int, limit, offset int, statuses []stri
 	          ORDER BY created_at DESC
 	          LIMIT $3 OFFSET $4`
 
-	rows, err := r.db.Query(query, bookID, pq.Array(statuses), limit, offset)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, bookID, pq.Array(statuses), limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
@@ -88,7 +101,8 @@ func (r *commentRepo) GetByBookID(bookID int, limit, offset int, statuses []stri
 	return comments, nil
 }
 
-func (r *commentRepo) GetByUserID(userID int, limit, offset int) ([]models.Comment, error) {
+func (r *commentRepo) GetByUserID(ctx context.Context, userID int, limit, offset int) ([]models.Comment, error) {
+	ctx = tracing.WithQueryName(ctx, "commentRepo.GetByUserID")
 	var comments []models.Comment
 
 	query := `SELECT id, book_id, user_id, text, created_at, updated_at, status
@@ -97,14 +111,14 @@ func (r *commentRepo) GetByUserID(userID int, limit, offset int) ([]models.Comme
 	          ORDER BY created_at DESC
 	          LIMIT $2 OFFSET $3`
 
-	rows, err := r.db.Query(query, userID, limit, offset)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
@@ -119,21 +133,22 @@ func (r *commentRepo) GetByUserID(userID int, limit, offset int) ([]models.Comme
 	return comments, nil
 }
 
-func (r *commentRepo) GetLast(limit int) ([]models.Comment, error) {
+func (r *commentRepo) GetLast(ctx context.Context, limit int) ([]models.Comment, error) {
+	ctx = tracing.WithQueryName(ctx, "commentRepo.GetLast")
 	query := `SELECT id, book_id, user_id, text, created_at, updated_at, status
 	          FROM comments
 	          WHERE status = $1
 	          ORDER BY created_at DESC
 	          LIMIT $2`
 
-	rows, err := r.db.Query(query, models.CommentStatusActive, limit)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, models.CommentStatusActive, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
@@ -149,14 +164,16 @@ func (r *commentRepo) GetLast(limit int) ([]models.Comment, error) {
 	return comments, nil
 }
 
-func (r *commentRepo) SetStatus(id int, status string) error {
+func (r *commentRepo) SetStatus(ctx context.Context, id int, status string) error {
+	ctx = tracing.WithQueryName(ctx, "commentRepo.SetStatus")
 	query := `UPDATE comments SET status = $1, updated_at = NOW() WHERE id = $2`
-	_, err := r.db.Exec(query, status, id)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, status, id)
 	return err
 }
 
-func (r *commentRepo) CountByBook(bookID int) (int, error) {
+func (r *commentRepo) CountByBook(ctx context.Context, bookID int) (int, error) {
+	ctx = tracing.WithQueryName(ctx, "commentRepo.CountByBook")
 	var count int
-	err := r.db.QueryRow(`SELECT COUNT(*) FROM comments WHERE book_id = $1 AND status = $2`, bookID, models.CommentStatusActive).Scan(&count)
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM comments WHERE book_id = $1 AND status = $2`, bookID, models.CommentStatusActive).Scan(&count)
 	return count, err
 }
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"online_library/backend/internal/pkg/apperr"
+)
+
+// Отсутствие записи. notFound оставляет sql.ErrNoRows в цепочке, поэтому
+// проверки errors.Is(err, sql.ErrNoRows) в сервисах продолжают работать.
+var (
+	ErrBookNotFound         = apperr.NotFound("book_not_found", "book not found")
+	ErrRevisionNotFound     = apperr.NotFound("revision_not_found", "revision not found")
+	ErrAuthorNotFound       = apperr.NotFound("author_not_found", "author not found")
+	ErrAliasNotFound        = apperr.NotFound("alias_not_found", "alias not found")
+	ErrCategoryNotFound     = apperr.NotFound("category_not_found", "category not found")
+	ErrCommentNotFound      = apperr.NotFound("comment_not_found", "comment not found")
+	ErrAnnotationNotFound   = apperr.NotFound("annotation_not_found", "annotation not found")
+	ErrEditNotFound         = apperr.NotFound("edit_not_found", "edit proposal not found")
+	ErrFileNotFound         = apperr.NotFound("file_not_found", "file not found")
+	ErrLoanNotFound         = apperr.NotFound("loan_not_found", "loan not found")
+	ErrNotificationNotFound = apperr.NotFound("notification_not_found", "notification not found")
+	ErrProgressNotFound     = apperr.NotFound("progress_not_found", "reading progress not found")
+	ErrRatingNotFound       = apperr.NotFound("rating_not_found", "rating not found")
+	ErrReviewNotFound       = apperr.NotFound("review_not_found", "review not found")
+	ErrShelfNotFound        = apperr.NotFound("shelf_not_found", "shelf not found")
+	ErrTagNotFound          = apperr.NotFound("tag_not_found", "tag not found")
+	ErrSynonymNotFound      = apperr.NotFound("synonym_not_found", "synonym not found")
+	ErrBlockNotFound        = apperr.NotFound("block_not_found", "blocklist entry not found")
+	ErrUserNotFound         = apperr.NotFound("user_not_found", "user not found")
+
+	ErrAlreadyExists = apperr.Conflict("already_exists", "record already exists")
+)
+
+// notFound переводит sql.ErrNoRows в доменную ошибку e, остальное — как dbError.
+func notFound(err error, e *apperr.Error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return e.WithCause(err)
+	}
+	return dbError(err)
+}
+
+// dbError переводит нарушения ограничений Postgres в доменные ошибки,
+// чтобы текст SQL-ошибки не уходил клиенту.
+func dbError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code {
+	case "23505": // unique_violation
+		return ErrAlreadyExists.WithCause(err)
+	case "23503": // foreign_key_violation
+		return ErrRelatedNotFound.WithCause(err)
+	}
+	return err
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// relatedNotFound — ErrRelatedNotFound с указанием, какой записи не нашлось.
+func relatedNotFound(err error, entity string, id int) error {
+	return apperr.Validationf("related_not_found", "%s %d does not exist", entity, id).WithCause(err)
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"online_library/backend/internal/pkg/tracing"
+)
+
+type HealthRepository interface {
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (version int, dirty bool, err error)
+}
+
+type healthRepo struct {
+	db *sql.DB
+}
+
+func NewHealthRepository(db *sql.DB) HealthRepository {
+	return &healthRepo{db: db}
+}
+
+func (r *healthRepo) Ping(ctx context.Context) error {
+	ctx = tracing.WithQueryName(ctx, "healthRepo.Ping")
+	return r.db.PingContext(ctx)
+}
+
+// SchemaVersion читает версию схемы из таблицы, которую ведёт migrate.
+func (r *healthRepo) SchemaVersion(ctx context.Context) (int, bool, error) {
+	ctx = tracing.WithQueryName(ctx, "healthRepo.SchemaVersion")
+	var version int
+	var dirty bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	return version, dirty, err
+}
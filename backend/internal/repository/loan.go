@@ -0,0 +1,539 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/pkg/tracing"
+	"time"
+)
+
+var (
+	ErrLendingDisabled   = apperr.Conflict("lending_disabled", "book is not lent: it is available without a loan")
+	ErrNoCopiesAvailable = apperr.Conflict("no_copies_available", "no copies available")
+	ErrAlreadyBorrowed   = apperr.Conflict("already_borrowed", "book is already on loan to this user")
+	ErrLoanNotActive     = apperr.Conflict("loan_not_active", "loan is not active")
+	ErrHoldsWaiting      = apperr.Conflict("holds_waiting", "other readers are waiting for this book")
+	ErrRenewalLimit      = apperr.Conflict("renewal_limit", "renewal limit reached")
+	ErrCopiesAvailable   = apperr.Conflict("copies_available", "copies are available, check the book out instead")
+	ErrHoldAlreadyPlaced = apperr.Conflict("hold_already_placed", "hold already placed")
+	ErrHoldNotFound      = apperr.NotFound("hold_not_found", "hold not found")
+)
+
+type LoanRepository interface {
+	SetBookCopies(ctx context.Context, bookID int, copies *int) error
+	GetAvailability(ctx context.Context, bookID int) (*models.BookAvailability, error)
+
+	Checkout(ctx context.Context, bookID, userID int, period time.Duration) (*models.Loan, error)
+	Return(ctx context.Context, loanID int) error
+	Renew(ctx context.Context, loanID int, period time.Duration, maxRenewals int) (*models.Loan, error)
+	ExpireOverdue(ctx context.Context) (int, error)
+
+	GetLoanByID(ctx context.Context, id int) (*models.Loan, error)
+	GetActiveLoan(ctx context.Context, bookID, userID int) (*models.Loan, error)
+	HasActiveLoan(ctx context.Context, bookID, userID int) (bool, error)
+	GetUserLoans(ctx context.Context, userID int, activeOnly bool) ([]models.Loan, error)
+
+	PlaceHold(ctx context.Context, bookID, userID int) (*models.LoanHold, error)
+	CancelHold(ctx context.Context, bookID, userID int) error
+	GetWaitingHold(ctx context.Context, bookID, userID int) (*models.LoanHold, error)
+	GetUserHolds(ctx context.Context, userID int) ([]models.LoanHold, error)
+}
+
+type loanRepo struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func NewLoanRepository(db *sql.DB, log *slog.Logger) LoanRepository {
+	return &loanRepo{db: db, log: log}
+}
+
+const loanColumns = `id, book_id, user_id, status, checked_out_at, due_at, returned_at, renewals`
+
+func scanLoan(row interface{ Scan(...interface{}) error }, l *models.Loan) error {
+	return row.Scan(&l.ID, &l.BookID, &l.UserID, &l.Status, &l.CheckedOutAt, &l.DueAt, &l.ReturnedAt, &l.Renewals)
+}
+
+// Порядок блокировок везде один: сначала строка книги, потом её выдачи и
+// очередь. Иначе выдача и закрытие просроченных выдач могут взаимно
+// заблокироваться.
+
+// lockBook блокирует строку книги на время транзакции.
+func lockBook(ctx context.Context, tx dbtx, bookID int) error {
+	var id int
+	err := tx.QueryRowContext(ctx, `SELECT id FROM books WHERE id = $1 FOR UPDATE`, bookID).Scan(&id)
+	return notFound(err, ErrBookNotFound)
+}
+
+// lockBookCopies блокирует строку книги, чтобы параллельные выдачи не превысили число копий.
+func lockBookCopies(ctx context.Context, tx dbtx, bookID int) (copies sql.NullInt64, active int, err error) {
+	err = tx.QueryRowContext(ctx, `SELECT lending_copies FROM books WHERE id = $1 FOR UPDATE`, bookID).Scan(&copies)
+	if err != nil {
+		return copies, 0, err
+	}
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM loans WHERE book_id = $1 AND status = $2`,
+		bookID, models.LoanStatusActive).Scan(&active)
+	return copies, active, err
+}
+
+func insertLoan(ctx context.Context, tx dbtx, bookID, userID int, period time.Duration) (*models.Loan, error) {
+	var l models.Loan
+	err := scanLoan(tx.QueryRowContext(ctx, `
+		INSERT INTO loans (book_id, user_id, status, checked_out_at, due_at)
+		VALUES ($1, $2, $3, NOW(), NOW() + $4 * INTERVAL '1 second')
+		RETURNING `+loanColumns,
+		bookID, userID, models.LoanStatusActive, int64(period.Seconds())), &l)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// hasActiveLoan — есть ли у пользователя незакрытая выдача книги, в том числе
+// просроченная, которую ещё не закрыла задача ExpireOverdue.
+func hasActiveLoan(ctx context.Context, tx dbtx, bookID, userID int) (bool, error) {
+	var exists bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM loans WHERE book_id = $1 AND user_id = $2 AND status = $3)`,
+		bookID, userID, models.LoanStatusActive).Scan(&exists)
+	return exists, err
+}
+
+// assignHolds выдаёт освободившиеся копии первым в очереди ожидания. Бронь
+// читателя, у которого книга уже на руках, отменяется: вторая выдача нарушила
+// бы idx_loans_active_book_user.
+func assignHolds(ctx context.Context, tx dbtx, bookID int) error {
+	for {
+		copies, active, err := lockBookCopies(ctx, tx, bookID)
+		if err != nil {
+			return err
+		}
+		if !copies.Valid || int64(active) >= copies.Int64 {
+			return nil
+		}
+
+		var holdID, userID int
+		err = tx.QueryRowContext(ctx, `
+			SELECT id, user_id FROM loan_holds
+			WHERE book_id = $1 AND status = $2
+			ORDER BY created_at, id
+			LIMIT 1
+			FOR UPDATE
+		`, bookID, models.HoldStatusWaiting).Scan(&holdID, &userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		borrowed, err := hasActiveLoan(ctx, tx, bookID, userID)
+		if err != nil {
+			return err
+		}
+		if borrowed {
+			_, err = tx.ExecContext(ctx, `UPDATE loan_holds SET status = $1 WHERE id = $2`, models.HoldStatusCancelled, holdID)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := insertLoan(ctx, tx, bookID, userID, models.LoanPeriod); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE loan_holds SET status = $1, fulfilled_at = NOW() WHERE id = $2`,
+			models.HoldStatusFulfilled, holdID)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (r *loanRepo) SetBookCopies(ctx context.Context, bookID int, copies *int) error {
+	ctx = tracing.WithQueryName(ctx, "loanRepo.SetBookCopies")
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
+	res, err := tx.ExecContext(ctx, `UPDATE books SET lending_copies = $1 WHERE id = $2`, copies, bookID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrBookNotFound.WithCause(sql.ErrNoRows)
+	}
+	if err := assignHolds(ctx, tx, bookID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *loanRepo) GetAvailability(ctx context.Context, bookID int) (*models.BookAvailability, error) {
+	ctx = tracing.WithQueryName(ctx, "loanRepo.GetAvailability")
+	a := models.BookAvailability{BookID: bookID}
+	var copies sql.NullInt64
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT b.lending_copies,
+		       (SELECT COUNT(*) FROM loans l WHERE l.book_id = b.id AND l.status = $2),
+		       (SELECT COUNT(*) FROM loan_holds h WHERE h.book_id = b.id AND h.status = $3)
+		FROM books b
+		WHERE b.id = $1
+	`, bookID, models.LoanStatusActive, models.HoldStatusWaiting).Scan(&copies, &a.ActiveLoans, &a.HoldsWaiting)
+	if err != nil {
+		return nil, err
+	}
+
+	if copies.Valid {
+		n := int(copies.Int64)
+		a.Copies = &n
+		if n > a.ActiveLoans {
+			a.Available = n - a.ActiveLoans
+		}
+	}
+	return &a, nil
+}
+
+func (r *loanRepo) Checkout(ctx context.Context, bookID, userID int, period time.Duration) (*models.Loan, error) {
+	ctx = tracing.WithQueryName(ctx, "loanRepo.Checkout")
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
+	copies, active, err := lockBookCopies(ctx, tx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if !copies.Valid {
+		return nil, ErrLendingDisabled
+	}
+
+	borrowed, err := hasActiveLoan(ctx, tx, bookID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if borrowed {
+		return nil, ErrAlreadyBorrowed
+	}
+	if int64(active) >= copies.Int64 {
+		return nil, ErrNoCopiesAvailable
+	}
+
+	loan, err := insertLoan(ctx, tx, bookID, userID, period)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE loan_holds SET status = $1, fulfilled_at = NOW() WHERE book_id = $2 AND user_id = $3 AND status = $4`,
+		models.HoldStatusFulfilled, bookID, userID, models.HoldStatusWaiting)
+	if err != nil {
+		return nil, err
+	}
+	return loan, tx.Commit()
+}
+
+func (r *loanRepo) Return(ctx context.Context, loanID int) error {
+	ctx = tracing.WithQueryName(ctx, "loanRepo.Return")
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
+	var bookID int
+	err = tx.QueryRowContext(ctx, `SELECT book_id FROM loans WHERE id = $1`, loanID).Scan(&bookID)
+	if err != nil {
+		return notFound(err, ErrLoanNotFound)
+	}
+	if err := lockBook(ctx, tx, bookID); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE loans SET status = $1, returned_at = NOW()
+		WHERE id = $2 AND status = $3
+	`, models.LoanStatusReturned, loanID, models.LoanStatusActive)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrLoanNotActive
+	}
+	if err := assignHolds(ctx, tx, bookID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *loanRepo) Renew(ctx context.Context, loanID int, period time.Duration, maxRenewals int) (*models.Loan, error) {
+	ctx = tracing.WithQueryName(ctx, "loanRepo.Renew")
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
+	var l models.Loan
+	if err := scanLoan(tx.QueryRowContext(ctx, `SELECT `+loanColumns+` FROM loans WHERE id = $1 FOR UPDATE`, loanID), &l); err != nil {
+		return nil, err
+	}
+	if l.Status != models.LoanStatusActive {
+		return nil, ErrLoanNotActive
+	}
+	if l.Renewals >= maxRenewals {
+		return nil, ErrRenewalLimit
+	}
+
+	var waiting int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM loan_holds WHERE book_id = $1 AND status = $2`,
+		l.BookID, models.HoldStatusWaiting).Scan(&waiting)
+	if err != nil {
+		return nil, err
+	}
+	if waiting > 0 {
+		return nil, ErrHoldsWaiting
+	}
+
+	err = scanLoan(tx.QueryRowContext(ctx, `
+		UPDATE loans
+		SET due_at = GREATEST(due_at, NOW()) + $1 * INTERVAL '1 second', renewals = renewals + 1
+		WHERE id = $2
+		RETURNING `+loanColumns,
+		int64(period.Seconds()), loanID), &l)
+	if err != nil {
+		return nil, err
+	}
+	return &l, tx.Commit()
+}
+
+// ExpireOverdue закрывает просроченные выдачи и передаёт копии очереди ожидания.
+// Каждая книга обрабатывается своей транзакцией, книга блокируется первой.
+func (r *loanRepo) ExpireOverdue(ctx context.Context) (int, error) {
+	ctx = tracing.WithQueryName(ctx, "loanRepo.ExpireOverdue")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT DISTINCT book_id FROM loans
+		WHERE status = $1 AND due_at < NOW()
+		ORDER BY book_id
+	`, models.LoanStatusActive)
+	if err != nil {
+		return 0, err
+	}
+	var bookIDs []int
+	for rows.Next() {
+		var bookID int
+		if err := rows.Scan(&bookID); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		bookIDs = append(bookIDs, bookID)
+	}
+	if err := rows.Close(); err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, bookID := range bookIDs {
+		n, err := r.expireBookLoans(ctx, bookID)
+		if err != nil {
+			return expired, err
+		}
+		expired += n
+	}
+	return expired, nil
+}
+
+func (r *loanRepo) expireBookLoans(ctx context.Context, bookID int) (int, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
+	if err := lockBook(ctx, tx, bookID); err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE loans SET status = $1, returned_at = NOW()
+		WHERE book_id = $2 AND status = $3 AND due_at < NOW()
+	`, models.LoanStatusExpired, bookID, models.LoanStatusActive)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if err := assignHolds(ctx, tx, bookID); err != nil {
+		return 0, err
+	}
+	return int(n), tx.Commit()
+}
+
+func (r *loanRepo) GetLoanByID(ctx context.Context, id int) (*models.Loan, error) {
+	ctx = tracing.WithQueryName(ctx, "loanRepo.GetLoanByID")
+	var l models.Loan
+	if err := scanLoan(conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+loanColumns+` FROM loans WHERE id = $1`, id), &l); err != nil {
+		return nil, notFound(err, ErrLoanNotFound)
+	}
+	return &l, nil
+}
+
+func (r *loanRepo) GetActiveLoan(ctx context.Context, bookID, userID int) (*models.Loan, error) {
+	ctx = tracing.WithQueryName(ctx, "loanRepo.GetActiveLoan")
+	var l models.Loan
+	err := scanLoan(conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT `+loanColumns+` FROM loans
+		WHERE book_id = $1 AND user_id = $2 AND status = $3 AND due_at >= NOW()
+	`, bookID, userID, models.LoanStatusActive), &l)
+	if err != nil {
+		return nil, notFound(err, ErrLoanNotFound)
+	}
+	return &l, nil
+}
+
+func (r *loanRepo) HasActiveLoan(ctx context.Context, bookID, userID int) (bool, error) {
+	ctx = tracing.WithQueryName(ctx, "loanRepo.HasActiveLoan")
+	return hasActiveLoan(ctx, conn(ctx, r.db), bookID, userID)
+}
+
+func (r *loanRepo) GetUserLoans(ctx context.Context, userID int, activeOnly bool) ([]models.Loan, error) {
+	ctx = tracing.WithQueryName(ctx, "loanRepo.GetUserLoans")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+loanColumns+` FROM loans
+		WHERE user_id = $1 AND (NOT $2 OR status = $3)
+		ORDER BY checked_out_at DESC
+		LIMIT 100
+	`, userID, activeOnly, models.LoanStatusActive)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var loans []models.Loan
+	for rows.Next() {
+		var l models.Loan
+		if err := scanLoan(rows, &l); err != nil {
+			return nil, err
+		}
+		loans = append(loans, l)
+	}
+	return loans, nil
+}
+
+func (r *loanRepo) PlaceHold(ctx context.Context, bookID, userID int) (*models.LoanHold, error) {
+	ctx = tracing.WithQueryName(ctx, "loanRepo.PlaceHold")
+	var h models.LoanHold
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO loan_holds (book_id, user_id, status, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT DO NOTHING
+		RETURNING id, book_id, user_id, status, created_at
+	`, bookID, userID, models.HoldStatusWaiting).Scan(&h.ID, &h.BookID, &h.UserID, &h.Status, &h.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrHoldAlreadyPlaced
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetWaitingHold(ctx, bookID, userID)
+}
+
+func (r *loanRepo) CancelHold(ctx context.Context, bookID, userID int) error {
+	ctx = tracing.WithQueryName(ctx, "loanRepo.CancelHold")
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE loan_holds SET status = $1
+		WHERE book_id = $2 AND user_id = $3 AND status = $4
+	`, models.HoldStatusCancelled, bookID, userID, models.HoldStatusWaiting)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrHoldNotFound
+	}
+	return nil
+}
+
+func (r *loanRepo) GetWaitingHold(ctx context.Context, bookID, userID int) (*models.LoanHold, error) {
+	ctx = tracing.WithQueryName(ctx, "loanRepo.GetWaitingHold")
+	var h models.LoanHold
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT h.id, h.book_id, h.user_id, h.status, h.created_at, h.fulfilled_at,
+		       (SELECT COUNT(*) FROM loan_holds q
+		        WHERE q.book_id = h.book_id AND q.status = h.status
+		          AND (q.created_at, q.id) <= (h.created_at, h.id))
+		FROM loan_holds h
+		WHERE h.book_id = $1 AND h.user_id = $2 AND h.status = $3
+	`, bookID, userID, models.HoldStatusWaiting).Scan(&h.ID, &h.BookID, &h.UserID, &h.Status, &h.CreatedAt, &h.FulfilledAt, &h.Position)
+	if err != nil {
+		return nil, notFound(err, ErrHoldNotFound)
+	}
+	return &h, nil
+}
+
+func (r *loanRepo) GetUserHolds(ctx context.Context, userID int) ([]models.LoanHold, error) {
+	ctx = tracing.WithQueryName(ctx, "loanRepo.GetUserHolds")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT h.id, h.book_id, h.user_id, h.status, h.created_at, h.fulfilled_at,
+		       (SELECT COUNT(*) FROM loan_holds q
+		        WHERE q.book_id = h.book_id AND q.status = h.status
+		          AND (q.created_at, q.id) <= (h.created_at, h.id))
+		FROM loan_holds h
+		WHERE h.user_id = $1 AND h.status = $2
+		ORDER BY h.created_at
+	`, userID, models.HoldStatusWaiting)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var holds []models.LoanHold
+	for rows.Next() {
+		var h models.LoanHold
+		if err := rows.Scan(&h.ID, &h.BookID, &h.UserID, &h.Status, &h.CreatedAt, &h.FulfilledAt, &h.Position); err != nil {
+			return nil, err
+		}
+		holds = append(holds, h)
+	}
+	return holds, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/tracing"
+)
+
+type NotificationRepository interface {
+	Create(ctx context.Context, n *models.Notification) error
+	GetByUser(ctx context.Context, userID int, unreadOnly bool, limit, offset int) ([]models.Notification, error)
+	CountUnread(ctx context.Context, userID int) (int, error)
+	MarkRead(ctx context.Context, id, userID int) error
+	MarkAllRead(ctx context.Context, userID int) error
+}
+
+type notificationRepo struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func NewNotificationRepository(db *sql.DB, log *slog.Logger) NotificationRepository {
+	return &notificationRepo{db: db, log: log}
+}
+
+func (r *notificationRepo) Create(ctx context.Context, n *models.Notification) error {
+	ctx = tracing.WithQueryName(ctx, "notificationRepo.Create")
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO notifications (user_id, kind, message, entity_type, entity_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, is_read, created_at
+	`, n.UserID, n.Kind, n.Message, n.EntityType, n.EntityID).Scan(&n.ID, &n.IsRead, &n.CreatedAt)
+}
+
+func (r *notificationRepo) GetByUser(ctx context.Context, userID int, unreadOnly bool, limit, offset int) ([]models.Notification, error) {
+	ctx = tracing.WithQueryName(ctx, "notificationRepo.GetByUser")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, user_id, kind, message, entity_type, entity_id, is_read, created_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR NOT is_read)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var result []models.Notification
+	for rows.Next() {
+		var n models.Notification
+		err := rows.Scan(&n.ID, &n.UserID, &n.Kind, &n.Message, &n.EntityType, &n.EntityID, &n.IsRead, &n.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
+func (r *notificationRepo) CountUnread(ctx context.Context, userID int) (int, error) {
+	ctx = tracing.WithQueryName(ctx, "notificationRepo.CountUnread")
+	var count int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND NOT is_read`, userID).Scan(&count)
+	return count, err
+}
+
+func (r *notificationRepo) MarkRead(ctx context.Context, id, userID int) error {
+	ctx = tracing.WithQueryName(ctx, "notificationRepo.MarkRead")
+	res, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE notifications SET is_read = TRUE WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (r *notificationRepo) MarkAllRead(ctx context.Context, userID int) error {
+	ctx = tracing.WithQueryName(ctx, "notificationRepo.MarkAllRead")
+	_, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE notifications SET is_read = TRUE WHERE user_id = $1 AND NOT is_read`, userID)
+	return err
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/tracing"
+)
+
+type ProgressRepository interface {
+	SaveProgress(ctx context.Context, p *models.ReadingProgress) (bool, error)
+	GetProgress(ctx context.Context, userID int, document string) (*models.ReadingProgress, error)
+	GetUserProgress(ctx context.Context, userID int, limit, offset int) ([]models.ReadingProgress, error)
+	GetProgressHistory(ctx context.Context, userID int, document string, limit, offset int) ([]models.ReadingProgress, error)
+}
+
+type progressRepo struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func NewProgressRepository(db *sql.DB, log *slog.Logger) ProgressRepository {
+	return &progressRepo{db: db, log: log}
+}
+
+// SaveProgress сохраняет позицию по правилу «побеждает последняя запись»:
+// если на сервере уже есть более свежая позиция, запись не применяется и возвращается false.
+// Применённые записи дублируются в историю в той же транзакции.
+func (r *progressRepo) SaveProgress(ctx context.Context, p *models.ReadingProgress) (bool, error) {
+	ctx = tracing.WithQueryName(ctx, "progressRepo.SaveProgress")
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return false, err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO reading_progress (user_id, book_file_id, document, locator, locator_type, percentage, device, device_id, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id, document) DO UPDATE
+		SET book_file_id = COALESCE(EXCLUDED.book_file_id, reading_progress.book_file_id),
+		    locator = EXCLUDED.locator,
+		    locator_type = EXCLUDED.locator_type,
+		    percentage = EXCLUDED.percentage,
+		    device = EXCLUDED.device,
+		    device_id = EXCLUDED.device_id,
+		    updated_at = EXCLUDED.updated_at
+		WHERE reading_progress.updated_at <= EXCLUDED.updated_at
+	`, p.UserID, p.BookFileID, p.Document, p.Locator, p.LocatorType, p.Percentage, p.Device, p.DeviceID, p.UpdatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO reading_progress_history (user_id, book_file_id, document, locator, locator_type, percentage, device, device_id, recorded_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, p.UserID, p.BookFileID, p.Document, p.Locator, p.LocatorType, p.Percentage, p.Device, p.DeviceID, p.UpdatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *progressRepo) GetProgress(ctx context.Context, userID int, document string) (*models.ReadingProgress, error) {
+	ctx = tracing.WithQueryName(ctx, "progressRepo.GetProgress")
+	var p models.ReadingProgress
+	var device, deviceID sql.NullString
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT user_id, book_file_id, document, locator, locator_type, percentage, device, device_id, updated_at
+		FROM reading_progress
+		WHERE user_id = $1 AND document = $2
+	`, userID, document).Scan(&p.UserID, &p.BookFileID, &p.Document, &p.Locator, &p.LocatorType,
+		&p.Percentage, &device, &deviceID, &p.UpdatedAt)
+	if err != nil {
+		return nil, notFound(err, ErrProgressNotFound)
+	}
+	p.Device, p.DeviceID = device.String, deviceID.String
+	return &p, nil
+}
+
+func (r *progressRepo) GetUserProgress(ctx context.Context, userID int, limit, offset int) ([]models.ReadingProgress, error) {
+	ctx = tracing.WithQueryName(ctx, "progressRepo.GetUserProgress")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT user_id, book_file_id, document, locator, locator_type, percentage, device, device_id, updated_at
+		FROM reading_progress
+		WHERE user_id = $1
+		ORDER BY updated_at DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var result []models.ReadingProgress
+	for rows.Next() {
+		var p models.ReadingProgress
+		var device, deviceID sql.NullString
+		if err := rows.Scan(&p.UserID, &p.BookFileID, &p.Document, &p.Locator, &p.LocatorType,
+			&p.Percentage, &device, &deviceID, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		p.Device, p.DeviceID = device.String, deviceID.String
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+func (r *progressRepo) GetProgressHistory(ctx context.Context, userID int, document string, limit, offset int) ([]models.ReadingProgress, error) {
+	ctx = tracing.WithQueryName(ctx, "progressRepo.GetProgressHistory")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, user_id, book_file_id, document, locator, locator_type, percentage, device, device_id, recorded_at
+		FROM reading_progress_history
+		WHERE user_id = $1 AND document = $2
+		ORDER BY recorded_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`, userID, document, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var result []models.ReadingProgress
+	for rows.Next() {
+		var p models.ReadingProgress
+		var device, deviceID sql.NullString
+		if err := rows.Scan(&p.ID, &p.UserID, &p.BookFileID, &p.Document, &p.Locator, &p.LocatorType,
+			&p.Percentage, &device, &deviceID, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		p.Device, p.DeviceID = device.String, deviceID.String
+		result = append(result, p)
+	}
+	return result, nil
+}
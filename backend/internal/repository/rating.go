@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"log/slog"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/tracing"
+)
+
+// ratingPriorVotes — число «виртуальных» голосов со средней оценкой по библиотеке,
+// которые подмешиваются в байесовский рейтинг, чтобы книга с одной пятёркой
+// не обгоняла книгу с сотней четвёрок.
+const ratingPriorVotes = 10
+
+// weightedRatingExpr возвращает SQL-выражение байесовского рейтинга
+// (v*R + m*C) / (v + m) для таблицы books с указанным алиасом.
+func weightedRatingExpr(alias string) string {
+	return fmt.Sprintf(
+		"((%[1]s.rating_count * %[1]s.rating_avg + %[2]d * (SELECT COALESCE(AVG(score), 0) FROM book_ratings)) / (%[1]s.rating_count + %[2]d))",
+		alias, ratingPriorVotes,
+	)
+}
+
+type RatingRepository interface {
+	SetRating(ctx context.Context, rating *models.BookRating) error
+	DeleteRating(ctx context.Context, userID, bookID int) error
+	GetUserRating(ctx context.Context, userID, bookID int) (*models.BookRating, error)
+	GetRatingSummary(ctx context.Context, bookID int) (*models.RatingSummary, error)
+
+	SaveReview(ctx context.Context, review *models.BookReview) error
+	GetReviewByID(ctx context.Context, id int) (*models.BookReview, error)
+	GetReviewsByBook(ctx context.Context, bookID int, statuses []string, limit, offset int) ([]models.BookReview, error)
+	SetReviewStatus(ctx context.Context, id int, status string) error
+}
+
+type ratingRepo struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func NewRatingRepository(db *sql.DB, log *slog.Logger) RatingRepository {
+	return &ratingRepo{db: db, log: log}
+}
+
+// refreshBookRating пересчитывает агрегаты книги внутри той же транзакции,
+// в которой изменилась оценка.
+func refreshBookRating(ctx context.Context, tx dbtx, bookID int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE books
+		SET rating_avg = COALESCE((SELECT AVG(score) FROM book_ratings WHERE book_id = $1), 0),
+		    rating_count = (SELECT COUNT(*) FROM book_ratings WHERE book_id = $1)
+		WHERE id = $1
+	`, bookID)
+	return err
+}
+
+func upsertRating(ctx context.Context, tx dbtx, rating *models.BookRating) error {
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO book_ratings (user_id, book_id, score, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		ON CONFLICT (user_id, book_id) DO UPDATE SET score = EXCLUDED.score, updated_at = NOW()
+		RETURNING created_at, updated_at
+	`, rating.UserID, rating.BookID, rating.Score).Scan(&rating.CreatedAt, &rating.UpdatedAt)
+	return dbError(err)
+}
+
+func (r *ratingRepo) SetRating(ctx context.Context, rating *models.BookRating) error {
+	ctx = tracing.WithQueryName(ctx, "ratingRepo.SetRating")
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
+	if err := upsertRating(ctx, tx, rating); err != nil {
+		return err
+	}
+	if err := refreshBookRating(ctx, tx, rating.BookID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *ratingRepo) DeleteRating(ctx context.Context, userID, bookID int) error {
+	ctx = tracing.WithQueryName(ctx, "ratingRepo.DeleteRating")
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM book_ratings WHERE user_id = $1 AND book_id = $2`, userID, bookID)
+	if err != nil {
+		return err
+	}
+	if err := refreshBookRating(ctx, tx, bookID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *ratingRepo) GetUserRating(ctx context.Context, userID, bookID int) (*models.BookRating, error) {
+	ctx = tracing.WithQueryName(ctx, "ratingRepo.GetUserRating")
+	var rating models.BookRating
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT user_id, book_id, score, created_at, updated_at
+		FROM book_ratings
+		WHERE user_id = $1 AND book_id = $2
+	`, userID, bookID).Scan(&rating.UserID, &rating.BookID, &rating.Score, &rating.CreatedAt, &rating.UpdatedAt)
+	if err != nil {
+		return nil, notFound(err, ErrRatingNotFound)
+	}
+	return &rating, nil
+}
+
+func (r *ratingRepo) GetRatingSummary(ctx context.Context, bookID int) (*models.RatingSummary, error) {
+	ctx = tracing.WithQueryName(ctx, "ratingRepo.GetRatingSummary")
+	summary := models.RatingSummary{BookID: bookID, Distribution: map[int]int{}}
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT b.rating_avg, b.rating_count, `+weightedRatingExpr("b")+`
+		FROM books b
+		WHERE b.id = $1
+	`, bookID).Scan(&summary.Average, &summary.Count, &summary.WeightedRating)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT score, COUNT(*) FROM book_ratings WHERE book_id = $1 GROUP BY score`, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	for score := models.RatingMinScore; score <= models.RatingMaxScore; score++ {
+		summary.Distribution[score] = 0
+	}
+	for rows.Next() {
+		var score, count int
+		if err := rows.Scan(&score, &count); err != nil {
+			return nil, err
+		}
+		summary.Distribution[score] = count
+	}
+	return &summary, nil
+}
+
+// SaveReview создаёт или обновляет рецензию пользователя на книгу.
+// Если в рецензии указана оценка, она сохраняется в той же транзакции.
+func (r *ratingRepo) SaveReview(ctx context.Context, review *models.BookReview) error {
+	ctx = tracing.WithQueryName(ctx, "ratingRepo.SaveReview")
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO book_reviews (book_id, user_id, text, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		ON CONFLICT (book_id, user_id) DO UPDATE
+		SET text = EXCLUDED.text, status = EXCLUDED.status, updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`, review.BookID, review.UserID, review.Text, review.Status).Scan(&review.ID, &review.CreatedAt, &review.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if review.Score != nil {
+		rating := models.BookRating{UserID: review.UserID, BookID: review.BookID, Score: *review.Score}
+		if err := upsertRating(ctx, tx, &rating); err != nil {
+			return err
+		}
+		if err := refreshBookRating(ctx, tx, review.BookID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *ratingRepo) GetReviewByID(ctx context.Context, id int) (*models.BookReview, error) {
+	ctx = tracing.WithQueryName(ctx, "ratingRepo.GetReviewByID")
+	var rv models.BookReview
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT rv.id, rv.book_id, rv.user_id, rv.text, br.score, rv.status, rv.created_at, rv.updated_at
+		FROM book_reviews rv
+		LEFT JOIN book_ratings br ON br.book_id = rv.book_id AND br.user_id = rv.user_id
+		WHERE rv.id = $1
+	`, id).Scan(&rv.ID, &rv.BookID, &rv.UserID, &rv.Text, &rv.Score, &rv.Status, &rv.CreatedAt, &rv.UpdatedAt)
+	if err != nil {
+		return nil, notFound(err, ErrReviewNotFound)
+	}
+	return &rv, nil
+}
+
+func (r *ratingRepo) GetReviewsByBook(ctx context.Context, bookID int, statuses []string, limit, offset int) ([]models.BookReview, error) {
+	ctx = tracing.WithQueryName(ctx, "ratingRepo.GetReviewsByBook")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT rv.id, rv.book_id, rv.user_id, rv.text, br.score, rv.status, rv.created_at, rv.updated_at
+		FROM book_reviews rv
+		LEFT JOIN book_ratings br ON br.book_id = rv.book_id AND br.user_id = rv.user_id
+		WHERE rv.book_id = $1 AND rv.status = ANY($2)
+		ORDER BY rv.created_at DESC
+		LIMIT $3 OFFSET $4
+	`, bookID, pq.Array(statuses), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var reviews []models.BookReview
+	for rows.Next() {
+		var rv models.BookReview
+		if err := rows.Scan(&rv.ID, &rv.BookID, &rv.UserID, &rv.Text, &rv.Score, &rv.Status, &rv.CreatedAt, &rv.UpdatedAt); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, rv)
+	}
+	return reviews, nil
+}
+
+func (r *ratingRepo) SetReviewStatus(ctx context.Context, id int, status string) error {
+	ctx = tracing.WithQueryName(ctx, "ratingRepo.SetReviewStatus")
+	res, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE book_reviews SET status = $1, updated_at = NOW() WHERE id = $2`, status, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrReviewNotFound.WithCause(sql.ErrNoRows)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"log/slog"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/tracing"
+)
+
+type ShelfRepository interface {
+	EnsureReadingStatusShelves(ctx context.Context, userID int) error
+	GetUserShelves(ctx context.Context, userID int) ([]models.Shelf, error)
+	GetShelfByID(ctx context.Context, id int) (*models.Shelf, error)
+	CreateShelf(ctx context.Context, shelf *models.Shelf) error
+	UpdateShelf(ctx context.Context, shelf *models.Shelf) error
+	DeleteShelf(ctx context.Context, id int) error
+
+	AddBookToShelf(ctx context.Context, shelf *models.Shelf, bookID int) error
+	RemoveBookFromShelf(ctx context.Context, shelfID, bookID int) error
+	MoveBook(ctx context.Context, from, to *models.Shelf, bookID int) error
+	GetShelfBooks(ctx context.Context, shelfID int, statuses []string, limit, offset int) ([]models.ShelfEntry, error)
+	CountShelfBooks(ctx context.Context, shelfID int, statuses []string) (int, error)
+}
+
+type shelfRepo struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func NewShelfRepository(db *sql.DB, log *slog.Logger) ShelfRepository {
+	return &shelfRepo{db: db, log: log}
+}
+
+// EnsureReadingStatusShelves создаёт недостающие полки-статусы пользователя.
+func (r *shelfRepo) EnsureReadingStatusShelves(ctx context.Context, userID int) error {
+	ctx = tracing.WithQueryName(ctx, "shelfRepo.EnsureReadingStatusShelves")
+	for _, s := range models.ReadingStatusShelves {
+		_, err := conn(ctx, r.db).ExecContext(ctx, `
+			INSERT INTO shelves (user_id, name, kind, created_at, updated_at)
+			VALUES ($1, $2, $3, NOW(), NOW())
+			ON CONFLICT DO NOTHING
+		`, userID, s.Name, s.Kind)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *shelfRepo) GetUserShelves(ctx context.Context, userID int) ([]models.Shelf, error) {
+	ctx = tracing.WithQueryName(ctx, "shelfRepo.GetUserShelves")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT s.id, s.user_id, s.name, s.kind, COUNT(sb.book_id), s.created_at, s.updated_at
+		FROM shelves s
+		LEFT JOIN shelf_books sb ON sb.shelf_id = s.id
+		WHERE s.user_id = $1
+		GROUP BY s.id
+		ORDER BY CASE s.kind
+			WHEN 'want_to_read' THEN 1
+			WHEN 'reading' THEN 2
+			WHEN 'finished' THEN 3
+			WHEN 'abandoned' THEN 4
+			ELSE 5 END, s.name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var shelves []models.Shelf
+	for rows.Next() {
+		var s models.Shelf
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Name, &s.Kind, &s.BookCount, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		shelves = append(shelves, s)
+	}
+	return shelves, nil
+}
+
+func (r *shelfRepo) GetShelfByID(ctx context.Context, id int) (*models.Shelf, error) {
+	ctx = tracing.WithQueryName(ctx, "shelfRepo.GetShelfByID")
+	var s models.Shelf
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT s.id, s.user_id, s.name, s.kind,
+		       (SELECT COUNT(*) FROM shelf_books sb WHERE sb.shelf_id = s.id),
+		       s.created_at, s.updated_at
+		FROM shelves s
+		WHERE s.id = $1
+	`, id).Scan(&s.ID, &s.UserID, &s.Name, &s.Kind, &s.BookCount, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, notFound(err, ErrShelfNotFound)
+	}
+	return &s, nil
+}
+
+func (r *shelfRepo) CreateShelf(ctx context.Context, shelf *models.Shelf) error {
+	ctx = tracing.WithQueryName(ctx, "shelfRepo.CreateShelf")
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO shelves (user_id, name, kind, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`, shelf.UserID, shelf.Name, shelf.Kind).Scan(&shelf.ID, &shelf.CreatedAt, &shelf.UpdatedAt)
+	return dbError(err)
+}
+
+func (r *shelfRepo) UpdateShelf(ctx context.Context, shelf *models.Shelf) error {
+	ctx = tracing.WithQueryName(ctx, "shelfRepo.UpdateShelf")
+	_, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE shelves SET name = $1, updated_at = NOW() WHERE id = $2`, shelf.Name, shelf.ID)
+	return dbError(err)
+}
+
+func (r *shelfRepo) DeleteShelf(ctx context.Context, id int) error {
+	ctx = tracing.WithQueryName(ctx, "shelfRepo.DeleteShelf")
+	_, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM shelves WHERE id = $1`, id)
+	return err
+}
+
+// putBook кладёт книгу на полку. Для полки-статуса книга снимается
+// с остальных полок-статусов того же пользователя.
+func putBook(ctx context.Context, tx dbtx, shelf *models.Shelf, bookID int) error {
+	if models.IsReadingStatusShelf(shelf.Kind) {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM shelf_books sb
+			USING shelves s
+			WHERE sb.shelf_id = s.id AND s.user_id = $1 AND s.kind <> $2
+			  AND s.id <> $3 AND sb.book_id = $4
+		`, shelf.UserID, models.ShelfCustom, shelf.ID, bookID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO shelf_books (shelf_id, book_id, added_at, updated_at)
+		VALUES ($1, $2, NOW(), NOW())
+		ON CONFLICT (shelf_id, book_id) DO UPDATE SET updated_at = NOW()
+	`, shelf.ID, bookID)
+	return dbError(err)
+}
+
+func (r *shelfRepo) AddBookToShelf(ctx context.Context, shelf *models.Shelf, bookID int) error {
+	ctx = tracing.WithQueryName(ctx, "shelfRepo.AddBookToShelf")
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
+	if err := putBook(ctx, tx, shelf, bookID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *shelfRepo) RemoveBookFromShelf(ctx context.Context, shelfID, bookID int) error {
+	ctx = tracing.WithQueryName(ctx, "shelfRepo.RemoveBookFromShelf")
+	_, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM shelf_books WHERE shelf_id = $1 AND book_id = $2`, shelfID, bookID)
+	return err
+}
+
+func (r *shelfRepo) MoveBook(ctx context.Context, from, to *models.Shelf, bookID int) error {
+	ctx = tracing.WithQueryName(ctx, "shelfRepo.MoveBook")
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM shelf_books WHERE shelf_id = $1 AND book_id = $2`, from.ID, bookID)
+	if err != nil {
+		return err
+	}
+	if err := putBook(ctx, tx, to, bookID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *shelfRepo) GetShelfBooks(ctx context.Context, shelfID int, statuses []string, limit, offset int) ([]models.ShelfEntry, error) {
+	ctx = tracing.WithQueryName(ctx, "shelfRepo.GetShelfBooks")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT b.id, b.title, b.description, b.publish_year, b.pages, b.language,
+		       b.publisher, b.type, b.rating_avg, b.rating_count, b.cover_url, b.status, b.created_at,
+		       sb.shelf_id, sb.added_at, sb.updated_at
+		FROM shelf_books sb
+		JOIN books b ON b.id = sb.book_id
+		WHERE sb.shelf_id = $1 AND b.status = ANY($2)
+		ORDER BY sb.updated_at DESC
+		LIMIT $3 OFFSET $4
+	`, shelfID, pq.Array(statuses), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var entries []models.ShelfEntry
+	for rows.Next() {
+		var e models.ShelfEntry
+		b := &e.Book
+		err := rows.Scan(&b.ID, &b.Title, &b.Description, &b.PublishYear, &b.Pages,
+			&b.Language, &b.Publisher, &b.Type, &b.RatingAvg, &b.RatingCount,
+			&b.CoverURL, &b.Status, &b.CreatedAt,
+			&e.ShelfID, &e.AddedAt, &e.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		e.Archived = b.Status == models.StatusBookArchived
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func (r *shelfRepo) CountShelfBooks(ctx context.Context, shelfID int, statuses []string) (int, error) {
+	ctx = tracing.WithQueryName(ctx, "shelfRepo.CountShelfBooks")
+	var count int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM shelf_books sb
+		JOIN books b ON b.id = sb.book_id
+		WHERE sb.shelf_id = $1 AND b.status = ANY($2)
+	`, shelfID, pq.Array(statuses)).Scan(&count)
+	return count, err
+}
@@ -1,47 +1,94 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"log/slog"
 	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/pkg/tracing"
+	"strings"
+	"time"
 )
 
+var ErrTagNotProposed = apperr.Conflict("tag_not_proposed", "tag is not awaiting moderation")
+
 type TagRepository interface {
-	GetAllTags() ([]models.Tag, error)
-	GetTagByID(id int) (models.Tag, error)
+	GetAllTags(ctx context.Context) ([]models.Tag, error)
+	GetTagByID(ctx context.Context, id int) (models.Tag, error)
+
+	CreateTag(ctx context.Context, tag *models.Tag) error
+	UpdateTag(ctx context.Context, tag *models.Tag) error
+	DeleteTag(ctx context.Context, id int) error
+
+	GetTagsByBookID(ctx context.Context, bookID, viewerID int, allProposed bool) ([]models.Tag, error)
+	AssignTagToBook(ctx context.Context, bookTag *models.BookTag) error
+	RemoveTagFromBook(ctx context.Context, bookID, tagID int) error
+
+	SearchTags(ctx context.Context, query string, statuses []string, viewerID int, allProposed bool, limit, offset int) ([]models.TagUsage, error)
+	GetTagsWithUsage(ctx context.Context, statuses []string, viewerID int, allProposed bool, limit, offset int) ([]models.TagUsage, error)
+	ResolveTag(ctx context.Context, name string) (models.Tag, error)
+
+	GetSynonyms(ctx context.Context, tagID int) ([]models.TagSynonym, error)
+	AddSynonym(ctx context.Context, synonym *models.TagSynonym) error
+	RemoveSynonym(ctx context.Context, tagID, synonymID int) (models.TagSynonym, error)
+	MergeTags(ctx context.Context, targetID int, sourceIDs []int) (int, error)
+	GetTagCloud(ctx context.Context, statuses []string, categoryID *int, limit int) ([]models.TagCloudEntry, error)
+
+	GetProposedTags(ctx context.Context, limit, offset int) ([]models.Tag, error)
+	ApproveTag(ctx context.Context, id, reviewerID int) error
+	CountTagsCreatedSince(ctx context.Context, userID int, since time.Time) (int, error)
 
-	CreateTag(tag *models.Tag) error
-	UpdateTag(tag *models.Tag) error
-	DeleteTag(id int) error
+	GetBlocklist(ctx context.Context) ([]models.TagBlock, error)
+	AddToBlocklist(ctx context.Context, block *models.TagBlock) error
+	RemoveFromBlocklist(ctx context.Context, id int) (models.TagBlock, error)
+	IsBlocked(ctx context.Context, name string) (bool, error)
+}
+
+// tagVisible — условие видимости тега: одобренные видят все, предложенные —
+// автор и (если allProposed) администраторы. Параметры: номера плейсхолдеров.
+func tagVisible(viewerParam, allParam int) string {
+	return fmt.Sprintf("(t.status = '%s' OR t.created_by = $%d OR $%d)", models.TagStatusApproved, viewerParam, allParam)
+}
 
-	GetTagsByBookID(bookID int) ([]models.Tag, error)
-	AssignTagToBook(bookTag *models.BookTag) error
-	RemoveTagFromBook(bookID, tagID int) error
+const tagColumns = `t.id, t.name, COALESCE(t.color, ''), t.status, t.created_by, t.created_at`
+
+func scanTag(row interface{ Scan(...interface{}) error }, tag *models.Tag) error {
+	return row.Scan(&tag.ID, &tag.Name, &tag.Color, &tag.Status, &tag.CreatedBy, &tag.CreatedAt)
 }
 
 type tagRepo struct {
-	db *sql.DB
+	db  *sql.DB
+	log *slog.Logger
 }
 
-func NewTagRepository(db *sql.DB) TagRepository {
-	return &tagRepo{db: db}
+func NewTagRepository(db *sql.DB, log *slog.Logger) TagRepository {
+	return &tagRepo{db: db, log: log}
 }
 
-func (r *tagRepo) GetAllTags() ([]models.Tag, error) {
-	rows, err := r.db.Query(`SELECT id, name, color FROM tags ORDER BY name`)
+func (r *tagRepo) GetAllTags(ctx context.Context) ([]models.Tag, error) {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.GetAllTags")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT `+tagColumns+` FROM tags t WHERE t.status = $1 ORDER BY t.name`, models.TagStatusApproved)
 	if err != nil {
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
+	return scanTags(rows)
+}
+
+func scanTags(rows *sql.Rows) ([]models.Tag, error) {
 	var tags []models.Tag
 	for rows.Next() {
 		var tag models.Tag
-		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Color); err != nil {
+		if err := scanTag(rows, &tag); err != nil {
 			return nil, err
 		}
 		tags = append(tags, tag)
@@ -49,71 +96,467 @@ func (r *tagRepo) GetAllTags() ([]models.Tag, error) {
 	return tags, nil
 }
 
-func (r *tagRepo) GetTagByID(id int) (models.Tag, error) {
+func (r *tagRepo) GetTagByID(ctx context.Context, id int) (models.Tag, error) {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.GetTagByID")
 	var tag models.Tag
-	err := r.db.QueryRow(`SELECT id, name, color FROM tags WHERE id = $1`, id).
-		Scan(&tag.ID, &tag.Name, &tag.Color)
-	return tag, err
+	err := scanTag(conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+tagColumns+` FROM tags t WHERE t.id = $1`, id), &tag)
+	if err != nil {
+		return tag, notFound(err, ErrTagNotFound)
+	}
+	return tag, nil
 }
 
-func (r *tagRepo) CreateTag(tag *models.Tag) error {
-	return r.db.QueryRow(
-		`INSERT INTO tags (name, color) VALUES ($1, $2) RETURNING id`,
-		tag.Name, tag.Color,
-	).Scan(&tag.ID)
+func (r *tagRepo) CreateTag(ctx context.Context, tag *models.Tag) error {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.CreateTag")
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		`INSERT INTO tags (name, color, status, created_by) VALUES ($1, NULLIF($2, ''), $3, $4) RETURNING id, created_at`,
+		tag.Name, tag.Color, tag.Status, tag.CreatedBy,
+	).Scan(&tag.ID, &tag.CreatedAt)
+	return dbError(err)
 }
 
-func (r *tagRepo) UpdateTag(tag *models.Tag) error {
-	_, err := r.db.Exec(
+func (r *tagRepo) UpdateTag(ctx context.Context, tag *models.Tag) error {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.UpdateTag")
+	res, err := conn(ctx, r.db).ExecContext(ctx,
 		`UPDATE tags SET name = $1, color = $2 WHERE id = $3`,
 		tag.Name, tag.Color, tag.ID,
 	)
-	return err
+	if err != nil {
+		return dbError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTagNotFound.WithCause(sql.ErrNoRows)
+	}
+	return nil
+}
+
+func (r *tagRepo) DeleteTag(ctx context.Context, id int) error {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.DeleteTag")
+	res, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM tags WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTagNotFound.WithCause(sql.ErrNoRows)
+	}
+	return nil
 }
 
-func (r *tagRepo) DeleteTag(id int) error {
-	_, err := r.db.Exec(`DELETE FROM tags WHERE id = $1`, id)
+func (r *tagRepo) GetTagsByBookID(ctx context.Context, bookID, viewerID int, allProposed bool) ([]models.Tag, error) {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.GetTagsByBookID")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+tagColumns+`
+		FROM tags t
+		JOIN book_tags bt ON bt.tag_id = t.id
+		WHERE bt.book_id = $1 AND `+tagVisible(2, 3)+`
+		ORDER BY bt.weight DESC, t.name
+	`, bookID, viewerID, allProposed)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	return scanTags(rows)
+}
+
+func (r *tagRepo) AssignTagToBook(ctx context.Context, bt *models.BookTag) error {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.AssignTagToBook")
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO book_tags (book_id, tag_id, weight) VALUES ($1, $2, $3)
+		 ON CONFLICT (book_id, tag_id) DO UPDATE SET weight = EXCLUDED.weight`,
+		bt.BookID, bt.TagID, bt.Weight,
+	)
+	return dbError(err)
+}
+
+func (r *tagRepo) RemoveTagFromBook(ctx context.Context, bookID, tagID int) error {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.RemoveTagFromBook")
+	_, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM book_tags WHERE book_id = $1 AND tag_id = $2`, bookID, tagID)
 	return err
 }
 
-func (r *tagRepo) GetTagsByBookID(bookID int) ([]models.Tag, error) {
-	rows, err := r.db.Query(`
-		SELECT t.id, t.name, t.color
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// tagUsageJoin — число видимых книг по каждому тегу
+const tagUsageJoin = `
+	LEFT JOIN (
+		SELECT bt.tag_id, COUNT(*) AS cnt
+		FROM book_tags bt
+		JOIN books b ON b.id = bt.book_id
+		WHERE b.status = ANY($2)
+		GROUP BY bt.tag_id
+	) u ON u.tag_id = t.id`
+
+func scanTagUsages(rows *sql.Rows) ([]models.TagUsage, error) {
+	var tags []models.TagUsage
+	for rows.Next() {
+		var t models.TagUsage
+		err := rows.Scan(&t.ID, &t.Name, &t.Color, &t.Status, &t.CreatedBy, &t.CreatedAt, &t.UsageCount, &t.MatchedBy)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
+
+// SearchTags — автодополнение: сначала совпадения по префиксу, затем похожие (pg_trgm).
+// Синонимы ищутся наравне с названиями, но в выдаче всегда канонический тег.
+func (r *tagRepo) SearchTags(ctx context.Context, query string, statuses []string, viewerID int, allProposed bool, limit, offset int) ([]models.TagUsage, error) {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.SearchTags")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		WITH matches AS (
+			SELECT t.id AS tag_id, NULL::varchar AS synonym,
+			       LOWER(t.name) LIKE LOWER($4) || '%' AS prefix,
+			       similarity(LOWER(t.name), LOWER($1)) AS score
+			FROM tags t
+			WHERE LOWER(t.name) LIKE LOWER($4) || '%' OR t.name % $1
+			UNION ALL
+			SELECT s.tag_id, s.name,
+			       LOWER(s.name) LIKE LOWER($4) || '%',
+			       similarity(LOWER(s.name), LOWER($1))
+			FROM tag_synonyms s
+			WHERE LOWER(s.name) LIKE LOWER($4) || '%' OR s.name % $1
+		), best AS (
+			SELECT DISTINCT ON (tag_id) tag_id, synonym, prefix, score
+			FROM matches
+			ORDER BY tag_id, prefix DESC, score DESC, synonym NULLS FIRST
+		)
+		SELECT `+tagColumns+`, COALESCE(u.cnt, 0), best.synonym
+		FROM best
+		JOIN tags t ON t.id = best.tag_id`+tagUsageJoin+`
+		WHERE `+tagVisible(5, 6)+`
+		ORDER BY best.prefix DESC, best.score DESC, COALESCE(u.cnt, 0) DESC, t.name, t.id
+		LIMIT $3 OFFSET $7
+	`, query, pq.Array(statuses), limit, likeEscaper.Replace(query), viewerID, allProposed, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	return scanTagUsages(rows)
+}
+
+// GetTagsWithUsage — все теги, популярные первыми.
+func (r *tagRepo) GetTagsWithUsage(ctx context.Context, statuses []string, viewerID int, allProposed bool, limit, offset int) ([]models.TagUsage, error) {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.GetTagsWithUsage")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+tagColumns+`, COALESCE(u.cnt, 0), NULL::varchar
+		FROM tags t`+tagUsageJoin+`
+		WHERE `+tagVisible(4, 5)+`
+		ORDER BY COALESCE(u.cnt, 0) DESC, t.name
+		LIMIT $1 OFFSET $3
+	`, limit, pq.Array(statuses), offset, viewerID, allProposed)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	return scanTagUsages(rows)
+}
+
+// ResolveTag находит канонический тег по названию или синониму без учёта регистра.
+func (r *tagRepo) ResolveTag(ctx context.Context, name string) (models.Tag, error) {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.ResolveTag")
+	var tag models.Tag
+	err := scanTag(conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT `+tagColumns+`
+		FROM tags t
+		WHERE LOWER(t.name) = LOWER($1)
+		   OR t.id = (SELECT s.tag_id FROM tag_synonyms s WHERE LOWER(s.name) = LOWER($1))
+		ORDER BY LOWER(t.name) = LOWER($1) DESC
+		LIMIT 1
+	`, strings.TrimSpace(name)), &tag)
+	return tag, err
+}
+
+func (r *tagRepo) GetSynonyms(ctx context.Context, tagID int) ([]models.TagSynonym, error) {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.GetSynonyms")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT id, tag_id, name FROM tag_synonyms WHERE tag_id = $1 ORDER BY name`, tagID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var synonyms []models.TagSynonym
+	for rows.Next() {
+		var s models.TagSynonym
+		if err := rows.Scan(&s.ID, &s.TagID, &s.Name); err != nil {
+			return nil, err
+		}
+		synonyms = append(synonyms, s)
+	}
+	return synonyms, nil
+}
+
+func (r *tagRepo) AddSynonym(ctx context.Context, synonym *models.TagSynonym) error {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.AddSynonym")
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		`INSERT INTO tag_synonyms (tag_id, name) VALUES ($1, $2) RETURNING id`,
+		synonym.TagID, synonym.Name,
+	).Scan(&synonym.ID)
+	return dbError(err)
+}
+
+// RemoveSynonym удаляет синоним и возвращает удалённую строку.
+func (r *tagRepo) RemoveSynonym(ctx context.Context, tagID, synonymID int) (models.TagSynonym, error) {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.RemoveSynonym")
+	var s models.TagSynonym
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		`DELETE FROM tag_synonyms WHERE id = $1 AND tag_id = $2 RETURNING id, tag_id, name`,
+		synonymID, tagID,
+	).Scan(&s.ID, &s.TagID, &s.Name)
+	if err != nil {
+		return s, notFound(err, ErrSynonymNotFound)
+	}
+	return s, nil
+}
+
+// MergeTags переносит связи книг с source-тегов на target, превращает их названия
+// и синонимы в синонимы target и удаляет source. Возвращает число затронутых книг.
+func (r *tagRepo) MergeTags(ctx context.Context, targetID int, sourceIDs []int) (int, error) {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.MergeTags")
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, err
+	}
+	defer func(tx *txn) {
+		err := tx.Rollback()
+		if err != nil {
+
+		}
+	}(tx)
+
+	var targetName string
+	err = tx.QueryRowContext(ctx, `SELECT name FROM tags WHERE id = $1 FOR UPDATE`, targetID).Scan(&targetName)
+	if err != nil {
+		return 0, notFound(err, ErrTagNotFound)
+	}
+
+	var books int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT book_id) FROM book_tags WHERE tag_id = ANY($1)
+	`, pq.Array(sourceIDs)).Scan(&books)
+	if err != nil {
+		return 0, err
+	}
+
+	// Основной тег (weight = 1) на любой из исходных остаётся основным
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO book_tags (book_id, tag_id, weight)
+		SELECT book_id, $1, MAX(weight) FROM book_tags WHERE tag_id = ANY($2) GROUP BY book_id
+		ON CONFLICT (book_id, tag_id) DO UPDATE SET weight = GREATEST(book_tags.weight, EXCLUDED.weight)
+	`, targetID, pq.Array(sourceIDs))
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM book_tags WHERE tag_id = ANY($1)`, pq.Array(sourceIDs)); err != nil {
+		return 0, err
+	}
+
+	// Синонимы source-тегов переходят к target, их названия становятся синонимами
+	_, err = tx.ExecContext(ctx, `UPDATE tag_synonyms SET tag_id = $1 WHERE tag_id = ANY($2)`, targetID, pq.Array(sourceIDs))
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO tag_synonyms (tag_id, name)
+		SELECT $1, name FROM tags WHERE id = ANY($2)
+		ON CONFLICT (LOWER(name)) DO NOTHING
+	`, targetID, pq.Array(sourceIDs))
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM tag_synonyms WHERE tag_id = $1 AND LOWER(name) = LOWER($2)`, targetID, targetName)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM tags WHERE id = ANY($1)`, pq.Array(sourceIDs))
+	if err != nil {
+		return 0, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return 0, err
+	} else if int(n) != len(sourceIDs) {
+		return 0, ErrTagNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return books, nil
+}
+
+// GetTagCloud считает популярность тегов: основной тег даёт книге 1, второстепенный — 0.5.
+// Если задан categoryID, учитываются только книги из этой категории и её подкатегорий.
+func (r *tagRepo) GetTagCloud(ctx context.Context, statuses []string, categoryID *int, limit int) ([]models.TagCloudEntry, error) {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.GetTagCloud")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		WITH RECURSIVE subcategories AS (
+			SELECT id FROM categories WHERE id = $2
+			UNION ALL
+			SELECT c.id
+			FROM categories c
+			INNER JOIN subcategories sc ON sc.id = c.parent_id
+		)
+		SELECT t.id, t.name, COALESCE(t.color, ''),
+		       COUNT(*) AS usage,
+		       SUM(CASE WHEN bt.weight = 1 THEN 1.0 ELSE 0.5 END) AS score
 		FROM tags t
 		JOIN book_tags bt ON bt.tag_id = t.id
-		WHERE bt.book_id = $1
-	`, bookID)
+		JOIN books b ON b.id = bt.book_id
+		WHERE b.status = ANY($1) AND t.status = '`+models.TagStatusApproved+`'
+		  AND ($2::int IS NULL OR EXISTS (
+		      SELECT 1 FROM book_categories bc
+		      WHERE bc.book_id = b.id AND bc.category_id IN (SELECT id FROM subcategories)
+		  ))
+		GROUP BY t.id
+		ORDER BY score DESC, t.name
+		LIMIT $3
+	`, pq.Array(statuses), categoryID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	var cloud []models.TagCloudEntry
+	for rows.Next() {
+		var e models.TagCloudEntry
+		if err := rows.Scan(&e.ID, &e.Name, &e.Color, &e.UsageCount, &e.Score); err != nil {
+			return nil, err
+		}
+		cloud = append(cloud, e)
+	}
+	return cloud, nil
+}
+
+// GetProposedTags — очередь модерации, старые предложения первыми.
+func (r *tagRepo) GetProposedTags(ctx context.Context, limit, offset int) ([]models.Tag, error) {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.GetProposedTags")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+tagColumns+`
+		FROM tags t
+		WHERE t.status = $1
+		ORDER BY t.created_at ASC, t.id ASC
+		LIMIT $2 OFFSET $3
+	`, models.TagStatusProposed, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
+		}
+	}(rows)
+
+	return scanTags(rows)
+}
+
+func (r *tagRepo) ApproveTag(ctx context.Context, id, reviewerID int) error {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.ApproveTag")
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE tags SET status = $1, reviewed_by = $2, reviewed_at = NOW()
+		WHERE id = $3 AND status = $4
+	`, models.TagStatusApproved, reviewerID, id, models.TagStatusProposed)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTagNotProposed
+	}
+	return nil
+}
 
+func (r *tagRepo) CountTagsCreatedSince(ctx context.Context, userID int, since time.Time) (int, error) {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.CountTagsCreatedSince")
+	var count int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM tags WHERE created_by = $1 AND created_at >= $2`, userID, since).Scan(&count)
+	return count, err
+}
+
+func (r *tagRepo) GetBlocklist(ctx context.Context) ([]models.TagBlock, error) {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.GetBlocklist")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT id, pattern, created_by, created_at FROM tag_blocklist ORDER BY pattern`)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
-	var tags []models.Tag
+	var blocks []models.TagBlock
 	for rows.Next() {
-		var tag models.Tag
-		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Color); err != nil {
+		var b models.TagBlock
+		if err := rows.Scan(&b.ID, &b.Pattern, &b.CreatedBy, &b.CreatedAt); err != nil {
 			return nil, err
 		}
-		tags = append(tags, tag)
+		blocks = append(blocks, b)
 	}
-	return tags, nil
+	return blocks, nil
 }
 
-func (r *tagRepo) AssignTagToBook(bt *models.BookTag) error {
-	_, err := r.db.Exec(
-		`INSERT INTO book_tags (book_id, tag_id, weight) VALUES ($1, $2, $3)
-		 ON CONFLICT (book_id, tag_id) DO UPDATE SET weight = EXCLUDED.weight`,
-		bt.BookID, bt.TagID, bt.Weight,
-	)
-	return err
+func (r *tagRepo) AddToBlocklist(ctx context.Context, block *models.TagBlock) error {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.AddToBlocklist")
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		`INSERT INTO tag_blocklist (pattern, created_by) VALUES ($1, $2) RETURNING id, created_at`,
+		block.Pattern, block.CreatedBy,
+	).Scan(&block.ID, &block.CreatedAt)
+	return dbError(err)
 }
 
-func (r *tagRepo) RemoveTagFromBook(bookID, tagID int) error {
-	_, err := r.db.Exec(`DELETE FROM book_tags WHERE book_id = $1 AND tag_id = $2`, bookID, tagID)
-	return err
+// RemoveFromBlocklist удаляет запрет и возвращает удалённую строку.
+func (r *tagRepo) RemoveFromBlocklist(ctx context.Context, id int) (models.TagBlock, error) {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.RemoveFromBlocklist")
+	var b models.TagBlock
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		`DELETE FROM tag_blocklist WHERE id = $1 RETURNING id, pattern, created_by, created_at`, id,
+	).Scan(&b.ID, &b.Pattern, &b.CreatedBy, &b.CreatedAt)
+	if err != nil {
+		return b, notFound(err, ErrBlockNotFound)
+	}
+	return b, nil
+}
+
+// IsBlocked проверяет, содержит ли название запрещённый фрагмент.
+func (r *tagRepo) IsBlocked(ctx context.Context, name string) (bool, error) {
+	ctx = tracing.WithQueryName(ctx, "tagRepo.IsBlocked")
+	var blocked bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM tag_blocklist WHERE POSITION(LOWER(pattern) IN LOWER($1)) > 0)
+	`, name).Scan(&blocked)
+	return blocked, err
 }
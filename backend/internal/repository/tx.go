@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+)
+
+// TxManager выполняет несколько вызовов репозиториев в одной транзакции: все
+// методы, получившие контекст из fn, работают в ней (unit of work).
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txManager struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func NewTxManager(db *sql.DB, log *slog.Logger) TxManager {
+	return &txManager{db: db, log: log}
+}
+
+type txKey struct{}
+
+// WithinTx коммитит транзакцию, если fn вернула nil, иначе откатывает.
+// Вложенный вызов присоединяется к внешней транзакции.
+func (m *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			m.log.WarnContext(ctx, "failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// dbtx — общее у *sql.DB и *sql.Tx, чтобы одни и те же запросы выполнялись
+// и сами по себе, и внутри чужой транзакции.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn — транзакция из контекста, если метод вызван внутри WithinTx, иначе сама база.
+func conn(ctx context.Context, db *sql.DB) dbtx {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// txn — транзакция одного метода репозитория. Внутри WithinTx это общая
+// транзакция, и её Commit/Rollback ничего не делают: ею распоряжается WithinTx.
+type txn struct {
+	*sql.Tx
+	owned bool
+}
+
+func beginTx(ctx context.Context, db *sql.DB) (*txn, error) {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return &txn{Tx: tx}, nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &txn{Tx: tx, owned: true}, nil
+}
+
+func (t *txn) Commit() error {
+	if !t.owned {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+func (t *txn) Rollback() error {
+	if !t.owned {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
@@ -4,38 +4,47 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/pkg/tracing"
 )
 
+var ErrEmailTaken = apperr.Conflict("email_taken", "email already exists")
+
 type UserRepository interface {
 	GetAllActive(ctx context.Context) ([]models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	SetNewUser(email string, name string, passwordHash string, bio string) (*models.User, error)
-	CheckEmailExists(email string) (bool, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	SetNewUser(ctx context.Context, email string, name string, passwordHash string, bio string) (*models.User, error)
+	CheckEmailExists(ctx context.Context, email string) (bool, error)
 	UpdateUserByID(ctx context.Context, id int, input models.UserInput) (*models.User, error)
+	GetByID(ctx context.Context, id int) (*models.User, error)
 	SoftDeleteUserByID(ctx context.Context, id int) error
 	HardDeleteUserByID(ctx context.Context, id int) error
 	AdminUpdateUser(ctx context.Context, id int, input models.AdminUserUpdateInput) (*models.User, error)
+	GetSyncUserByEmail(ctx context.Context, email string) (*models.User, error)
+	SetSyncKeyHash(ctx context.Context, id int, hash string) error
 }
 
 type UserRepo struct {
-	db *sql.DB
+	db  *sql.DB
+	log *slog.Logger
 }
 
-func NewUserRepository(db *sql.DB) *UserRepo {
-	return &UserRepo{db: db}
+func NewUserRepository(db *sql.DB, log *slog.Logger) *UserRepo {
+	return &UserRepo{db: db, log: log}
 }
 
 func (r *UserRepo) GetAllActive(ctx context.Context) ([]models.User, error) {
-	rows, err := r.db.QueryContext(ctx, "SELECT id, email, name, role, bio, registered_at FROM users WHERE is_active = TRUE")
+	ctx = tracing.WithQueryName(ctx, "UserRepo.GetAllActive")
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT id, email, name, role, bio, registered_at FROM users WHERE is_active = TRUE")
 	if err != nil {
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-			log.Fatalf("failed to close rows: %v", err)
+			r.log.WarnContext(ctx, "failed to close rows", "error", err)
 		}
 	}(rows)
 
@@ -51,52 +60,58 @@ func (r *UserRepo) GetAllActive(ctx context.Context) ([]models.User, error) {
 	return users, nil
 }
 
-func (r *UserRepo) GetByEmail(email string) (*models.User, error) {
+func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx = tracing.WithQueryName(ctx, "UserRepo.GetByEmail")
 	var user models.User
-	err := r.db.QueryRow(`SELECT id, email, password_hash, role, token_version, is_active FROM users WHERE email = $1`, email).
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT id, email, password_hash, role, token_version, is_active FROM users WHERE email = $1`, email).
 		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Role, &user.TokenVersion, &user.Is_active)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, ErrUserNotFound)
 	}
 	return &user, nil
 }
 
 func (r *UserRepo) GetByID(ctx context.Context, id int) (*models.User, error) {
+	ctx = tracing.WithQueryName(ctx, "UserRepo.GetByID")
 	var u models.User
-	err := r.db.QueryRowContext(ctx, `
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT id, email, name, role, bio, registered_at
 		FROM users WHERE id = $1
 	`, id).Scan(&u.ID, &u.Email, &u.Name, &u.Role, &u.Bio, &u.RegisteredAt)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, ErrUserNotFound)
 	}
 	return &u, nil
 }
 
-func (r *UserRepo) SetNewUser(email string, name string, passwordHash string, bio string) (*models.User, error) {
-	exists, err := r.CheckEmailExists(email)
+func (r *UserRepo) SetNewUser(ctx context.Context, email string, name string, passwordHash string, bio string) (*models.User, error) {
+	ctx = tracing.WithQueryName(ctx, "UserRepo.SetNewUser")
+	exists, err := r.CheckEmailExists(ctx, email)
 	if err != nil {
 		return nil, err
 	}
 	if exists {
-		return nil, fmt.Errorf("email already exists")
+		return nil, ErrEmailTaken
 	}
 
-	_, err = r.db.Exec(`
+	var id int
+	err = conn(ctx, r.db).QueryRowContext(ctx, `
 		INSERT INTO users (email, name, password_hash, role, bio)
 		VALUES ($1, $2, $3, $4, $5)
-		`, email, name, passwordHash, models.RoleNewUser, bio)
+		RETURNING id
+		`, email, name, passwordHash, models.RoleNewUser, bio).Scan(&id)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to insert user: %w", err)
+		return nil, fmt.Errorf("failed to insert user: %w", dbError(err))
 	}
 
-	return nil, nil
+	return r.GetByID(ctx, id)
 }
 
-func (r *UserRepo) CheckEmailExists(email string) (bool, error) {
+func (r *UserRepo) CheckEmailExists(ctx context.Context, email string) (bool, error) {
+	ctx = tracing.WithQueryName(ctx, "UserRepo.CheckEmailExists")
 	var count int
-	err := r.db.QueryRow(`SELECT COUNT(*) FROM users WHERE email = $1`, email).Scan(&count)
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE email = $1`, email).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("failed to check email existence: %w", err)
 	}
@@ -104,13 +119,17 @@ func (r *UserRepo) CheckEmailExists(email string) (bool, error) {
 }
 
 func (r *UserRepo) UpdateUserByID(ctx context.Context, id int, input models.UserInput) (*models.User, error) {
-	_, err := r.db.ExecContext(ctx, `
+	ctx = tracing.WithQueryName(ctx, "UserRepo.UpdateUserByID")
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
 		UPDATE users
 		SET email = $1, name = $2, bio = $3
 		WHERE id = $4 AND is_active = TRUE
 	`, input.Email, input.Name, input.Bio, id)
+	if isUniqueViolation(err) {
+		return nil, ErrEmailTaken.WithCause(err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to update user: %w", err)
+		return nil, fmt.Errorf("failed to update user: %w", dbError(err))
 	}
 
 	user, err := r.GetByID(ctx, id)
@@ -122,7 +141,8 @@ func (r *UserRepo) UpdateUserByID(ctx context.Context, id int, input models.User
 }
 
 func (r *UserRepo) SoftDeleteUserByID(ctx context.Context, id int) error {
-	_, err := r.db.ExecContext(ctx, `
+	ctx = tracing.WithQueryName(ctx, "UserRepo.SoftDeleteUserByID")
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
 		UPDATE users
 		SET is_active = FALSE
 		WHERE id = $1
@@ -131,23 +151,50 @@ func (r *UserRepo) SoftDeleteUserByID(ctx context.Context, id int) error {
 }
 
 func (r *UserRepo) HardDeleteUserByID(ctx context.Context, id int) error {
-	_, err := r.db.ExecContext(ctx, `
+	ctx = tracing.WithQueryName(ctx, "UserRepo.HardDeleteUserByID")
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
 		DELETE FROM users
 		WHERE id = $1
 	`, id)
 	return err
 }
 
+// AdminUpdateUser возвращает пользователя, перечитанного после изменения; внутри
+// WithinTx — в той же транзакции, так что это именно сохранённая строка.
 func (r *UserRepo) AdminUpdateUser(ctx context.Context, id int, input models.AdminUserUpdateInput) (*models.User, error) {
-	_, err := r.db.ExecContext(ctx, `
+	ctx = tracing.WithQueryName(ctx, "UserRepo.AdminUpdateUser")
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
 		UPDATE users
 		SET email = $1, name = $2, bio = $3, role = $4, token_version = token_version + 1
 		WHERE id = $5
 	`, input.Email, input.Name, input.Bio, input.Role, id)
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 	return r.GetByID(ctx, id)
 }
 
+func (r *UserRepo) GetSyncUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx = tracing.WithQueryName(ctx, "UserRepo.GetSyncUserByEmail")
+	var user models.User
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, email, role, sync_key_hash, is_active
+		FROM users WHERE email = $1
+	`, email).Scan(&user.ID, &user.Email, &user.Role, &user.SyncKeyHash, &user.Is_active)
+	if err != nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+	return &user, nil
+}
+
+func (r *UserRepo) SetSyncKeyHash(ctx context.Context, id int, hash string) error {
+	ctx = tracing.WithQueryName(ctx, "UserRepo.SetSyncKeyHash")
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE users
+		SET sync_key_hash = $1
+		WHERE id = $2 AND is_active = TRUE
+	`, hash, id)
+	return err
+}
+
 // При смене роли у пользователя UPDATE users SET role = 'user', token_version = token_version + 1 WHERE id = ?;
@@ -3,47 +3,123 @@ package routes
 import (
 	"database/sql"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"log/slog"
+	"online_library/backend/docs"
 	"online_library/backend/internal/handlers"
 	"online_library/backend/internal/middleware"
+	"online_library/backend/internal/pkg/metrics"
+	"online_library/backend/internal/pkg/storage"
+	"online_library/backend/internal/pkg/tracing"
 	"online_library/backend/internal/repository"
 	"online_library/backend/internal/service"
+	"time"
 )
 
-func SetupRoutes(r *gin.Engine, db *sql.DB) {
-	r.Use(gin.Logger())
-	r.Use(gin.Recovery())
+// Дата, с которой API v1 считается устаревшим (заголовок Deprecation).
+var apiV1DeprecatedSince = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
 
-	//bookHandler := handlers.NewBookHandler(db)
+func SetupRoutes(r *gin.Engine, db *sql.DB, log *slog.Logger, requestTimeout time.Duration) {
+	r.Use(otelgin.Middleware(tracing.DefaultServiceName))
+	r.Use(middleware.RequestID())
+	r.Use(middleware.RequestLogger(log))
+	r.Use(middleware.Recovery(log))
+	r.Use(middleware.Metrics())
+	r.Use(middleware.Deadline(requestTimeout))
+	r.Use(middleware.Errors(log))
 
-	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepo)
-	userHandler := handlers.NewUserHandler(userService)
+	if err := metrics.RegisterDB(db, "library"); err != nil {
+		log.Error("failed to register db metrics", "error", err)
+	}
+
+	//bookHandler := handlers.NewBookHandler(db, log)
+
+	txManager := repository.NewTxManager(db, log)
+	auditRepo := repository.NewAuditRepository(db, log)
+
+	userRepo := repository.NewUserRepository(db, log)
+	userService := service.NewUserService(userRepo, auditRepo, txManager)
+	userHandler := handlers.NewUserHandler(userService, log)
 
 	authService := service.NewAuthService(userRepo, userService)
-	authHandler := handlers.NewAuthHandler(authService)
+	authHandler := handlers.NewAuthHandler(authService, log)
+
+	categoryRepo := repository.NewCategoryRepository(db, log)
+	categoryService := service.NewCategoryService(categoryRepo, auditRepo, txManager)
+	categoryHandler := handlers.NewCategoryHandler(categoryService, log)
+
+	auditService := service.NewAuditService(auditRepo)
+	auditHandler := handlers.NewAuditHandler(auditService, log)
+
+	notificationRepo := repository.NewNotificationRepository(db, log)
+	notificationService := service.NewNotificationService(notificationRepo)
+	notificationHandler := handlers.NewNotificationHandler(notificationService, log)
+
+	bookRepo := repository.NewBookRepository(db, log)
+
+	tagRepo := repository.NewTagRepository(db, log)
+	tagService := service.NewTagService(tagRepo, bookRepo, auditRepo, txManager, notificationService)
+	tagHandler := handlers.NewTagHandler(tagService, log)
+
+	bookService := service.NewBookService(bookRepo, tagRepo, auditRepo, txManager, notificationService)
+	bookHandler := handlers.NewBookHandler(bookService, log)
+
+	bookEditRepo := repository.NewBookEditRepository(db, log)
+	bookEditService := service.NewBookEditService(bookEditRepo, bookRepo, tagRepo, auditRepo, txManager, notificationService)
+	bookEditHandler := handlers.NewBookEditHandler(bookEditService, log)
+
+	authorRepo := repository.NewAuthorRepository(db, log)
+	authorService := service.NewAuthorService(authorRepo, bookRepo, auditRepo, txManager)
+	authorHandler := handlers.NewAuthorHandler(authorService, log)
+
+	commentRepo := repository.NewCommentRepository(db, log)
+	commentService := service.NewCommentService(commentRepo, auditRepo, txManager, log)
+	commentHandler := handlers.NewCommentHandler(commentService, log)
+
+	ratingRepo := repository.NewRatingRepository(db, log)
+	ratingService := service.NewRatingService(ratingRepo, bookRepo, auditRepo, txManager)
+	ratingHandler := handlers.NewRatingHandler(ratingService, log)
 
-	categoryRepo := repository.NewCategoryRepository(db)
-	categoryService := service.NewCategoryService(categoryRepo)
-	categoryHandler := handlers.NewCategoryHandler(categoryService)
+	shelfRepo := repository.NewShelfRepository(db, log)
+	shelfService := service.NewShelfService(shelfRepo, bookRepo)
+	shelfHandler := handlers.NewShelfHandler(shelfService, log)
 
-	tagRepo := repository.NewTagRepository(db)
-	tagService := service.NewTagService(tagRepo)
-	tagHandler := handlers.NewTagHandler(tagService)
+	bookFileRepo := repository.NewBookFileRepository(db, log)
+	progressRepo := repository.NewProgressRepository(db, log)
+	progressService := service.NewProgressService(progressRepo, bookFileRepo, bookRepo, userRepo)
+	progressHandler := handlers.NewProgressHandler(progressService, log)
+	kosyncHandler := handlers.NewKosyncHandler(progressService, log)
 
-	authorRepo := repository.NewAuthorRepository(db)
-	authorService := service.NewAuthorService(authorRepo)
-	authorHandler := handlers.NewAuthorHandler(authorService)
+	annotationRepo := repository.NewAnnotationRepository(db, log)
+	annotationService := service.NewAnnotationService(annotationRepo, bookRepo, auditRepo, txManager)
+	annotationHandler := handlers.NewAnnotationHandler(annotationService, log)
 
-	bookRepo := repository.NewBookRepository(db)
-	bookService := service.NewBookService(bookRepo)
-	bookHandler := handlers.NewBookHandler(bookService)
+	loanRepo := repository.NewLoanRepository(db, log)
+	loanService := service.NewLoanService(loanRepo, bookRepo, auditRepo, txManager)
+	loanHandler := handlers.NewLoanHandler(loanService, log)
 
-	commentRepo := repository.NewCommentRepository(db)
-	commentService := service.NewCommentService(commentRepo)
-	commentHandler := handlers.NewCommentHandler(commentService)
+	bookFileService := service.NewBookFileService(bookFileRepo, bookRepo, loanRepo, storage.NewHTTP(10*time.Second))
+	bookFileHandler := handlers.NewBookFileHandler(bookFileService, log)
+
+	healthService := service.NewHealthService(repository.NewHealthRepository(db))
+	healthHandler := handlers.NewHealthHandler(healthService, log)
+
+	// Служебные: зонды оркестратора и метрики
+	r.GET("/healthz", healthHandler.Liveness)
+	r.GET("/readyz", healthHandler.Readiness)
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// Документация: спецификация OpenAPI и Swagger UI
+	r.GET("/api/openapi.json", docs.ServeSpec)
+	r.GET("/api/docs", docs.ServeUI)
+
+	// API v1: прежние маршруты (действия через POST, 200 вместо 204) — устарели,
+	// новые клиенты используют /api/v2
+	v1 := r.Group("/api", middleware.Deprecated(apiV1DeprecatedSince, "/api/docs"))
 
 	// Категории
-	apiCategories := r.Group("/api/categories")
+	apiCategories := v1.Group("/categories")
 	{
 		apiCategories.GET("", middleware.AuthRequired(), categoryHandler.GetAllCategories) // всё дерево категорий
 		apiCategories.GET("/root", middleware.AuthRequired(), categoryHandler.GetRootCategories)
@@ -57,11 +133,11 @@ func SetupRoutes(r *gin.Engine, db *sql.DB) {
 	}
 
 	// Книги
-	apiBooks := r.Group("/api/books")
+	apiBooks := v1.Group("/books")
 	{
 		// Публичные
 		apiBooks.GET("", middleware.AuthRequired(), bookHandler.SearchBooks)
-		apiBooks.GET("/:id", middleware.AuthRequired(), bookHandler.GetBookByID)
+		apiBooks.GET("/:book_id", middleware.AuthRequired(), bookHandler.GetBookByID)
 		apiBooks.GET("/author/:author_id", middleware.AuthRequired(), bookHandler.GetBooksByAuthor)
 		apiBooks.GET("/tag/:tag_id", middleware.AuthRequired(), bookHandler.GetBooksByTag)
 		apiBooks.GET("/duplicates/:title", middleware.AuthRequired(), bookHandler.GetDuplicateBooks)
@@ -74,73 +150,396 @@ func SetupRoutes(r *gin.Engine, db *sql.DB) {
 
 		// CRUD
 		apiBooks.POST("", middleware.AuthRequired(), bookHandler.CreateBook)
-		apiBooks.POST("/:id", middleware.AuthRequired(), middleware.OwnerOrAdmin(), bookHandler.UpdateBook)
-		apiBooks.POST("/:id/delete", middleware.AuthRequired(), middleware.OwnerOrAdmin(), bookHandler.DeleteBook)
+		apiBooks.POST("/:book_id", middleware.AuthRequired(), bookHandler.UpdateBook)
+		apiBooks.POST("/:book_id/delete", middleware.AuthRequired(), bookHandler.DeleteBook)
+		apiBooks.PATCH("/:book_id", middleware.AuthRequired(), bookHandler.PatchBook)
+
+		// Ревизии
+		apiBooks.GET("/:book_id/history", middleware.AuthRequired(), bookHandler.GetBookHistory)
+		apiBooks.POST("/:book_id/history/:revision_id/rollback", middleware.AuthRequired(), middleware.AdminOnly(), bookHandler.RollbackBook)
 
 		// Статус
-		apiBooks.POST("/:book_id/status", middleware.AuthRequired(), middleware.AdminOnly(), bookHandler.UpdateBookStatus)
+		apiBooks.GET("/review-queue", middleware.AuthRequired(), middleware.AdminOnly(), bookHandler.GetReviewQueue)
+		apiBooks.POST("/:book_id/status", middleware.AuthRequired(), bookHandler.UpdateBookStatus)
+		apiBooks.GET("/:book_id/status/history", middleware.AuthRequired(), bookHandler.GetStatusHistory)
+
+		// Предложенные правки
+		apiBooks.POST("/:book_id/edits", middleware.AuthRequired(), bookEditHandler.ProposeEdit)
+		apiBooks.GET("/:book_id/edits", middleware.AuthRequired(), bookEditHandler.GetBookEdits)
 
 		// Авторы
-		apiBooks.POST("/:book_id/authors", middleware.AuthRequired(), middleware.OwnerOrAdmin(), bookHandler.SetBookAuthors)
-		apiBooks.POST("/:book_id/authors/:author_id", middleware.AuthRequired(), middleware.OwnerOrAdmin(), bookHandler.AddBookAuthor)
-		apiBooks.POST("/:book_id/authors/:author_id/remove", middleware.AuthRequired(), middleware.OwnerOrAdmin(), bookHandler.RemoveBookAuthor)
+		apiBooks.POST("/:book_id/authors", middleware.AuthRequired(), bookHandler.SetBookAuthors)
+		apiBooks.POST("/:book_id/authors/:author_id", middleware.AuthRequired(), bookHandler.AddBookAuthor)
+		apiBooks.POST("/:book_id/authors/:author_id/remove", middleware.AuthRequired(), bookHandler.RemoveBookAuthor)
 
 		// Теги
-		apiBooks.POST("/:book_id/tags", middleware.AuthRequired(), middleware.OwnerOrAdmin(), bookHandler.SetBookTags)
-		apiBooks.POST("/:book_id/tags/:tag_id", middleware.AuthRequired(), middleware.OwnerOrAdmin(), bookHandler.AddBookTag)
-		apiBooks.POST("/:book_id/tags/:tag_id/remove", middleware.AuthRequired(), middleware.OwnerOrAdmin(), bookHandler.RemoveBookTag)
+		apiBooks.POST("/:book_id/tags", middleware.AuthRequired(), bookHandler.SetBookTags)
+		apiBooks.POST("/:book_id/tags/:tag_id", middleware.AuthRequired(), bookHandler.AddBookTag)
+		apiBooks.POST("/:book_id/tags/:tag_id/remove", middleware.AuthRequired(), bookHandler.RemoveBookTag)
+
+		// Оценки
+		apiBooks.GET("/:book_id/rating", middleware.AuthRequired(), ratingHandler.GetRatingSummary)
+		apiBooks.POST("/:book_id/rating", middleware.AuthRequired(), ratingHandler.RateBook)
+		apiBooks.POST("/:book_id/rating/remove", middleware.AuthRequired(), ratingHandler.RemoveRating)
+
+		// Файлы
+		apiBooks.GET("/:book_id/files", middleware.AuthRequired(), bookFileHandler.GetBookFiles)
+		apiBooks.GET("/:book_id/files/:file_id/download", middleware.AuthRequired(), bookFileHandler.DownloadFile)
+
+		// Выдача (ограниченное число копий)
+		apiBooks.POST("/:book_id/copies", middleware.AuthRequired(), middleware.AdminOnly(), loanHandler.SetBookCopies)
+		apiBooks.GET("/:book_id/availability", middleware.AuthRequired(), loanHandler.GetAvailability)
+		apiBooks.POST("/:book_id/checkout", middleware.AuthRequired(), loanHandler.Checkout)
+		apiBooks.POST("/:book_id/hold", middleware.AuthRequired(), loanHandler.PlaceHold)
+		apiBooks.POST("/:book_id/hold/cancel", middleware.AuthRequired(), loanHandler.CancelHold)
+	}
+
+	// Рецензии
+	apiReviews := v1.Group("/reviews", middleware.AuthRequired())
+	{
+		apiReviews.GET("/book/:book_id", ratingHandler.GetReviewsByBook)                      // пагинация ?limit=&offset=
+		apiReviews.POST("", ratingHandler.SaveReview)                                         // создание или обновление своей рецензии
+		apiReviews.POST("/:id/delete", ratingHandler.DeleteReview)                            // мягкое удаление (автор или админ)
+		apiReviews.POST("/:id/status", middleware.AdminOnly(), ratingHandler.SetReviewStatus) // модерация
 	}
 	// Авторы
-	apiAuthors := r.Group("/api/authors", middleware.AuthRequired())
+	apiAuthors := v1.Group("/authors", middleware.AuthRequired())
 	{
 		apiAuthors.GET("", authorHandler.ListAuthors)
+		apiAuthors.GET("/duplicates", middleware.AdminOnly(), authorHandler.ListDuplicates)
+		apiAuthors.GET("/merges", middleware.AdminOnly(), authorHandler.ListMerges)
 		apiAuthors.GET("/:id", authorHandler.GetAuthorByID)
 		apiAuthors.POST("", authorHandler.CreateAuthor)
 		apiAuthors.POST("/:id", authorHandler.UpdateAuthor)
 		apiAuthors.POST("/:id/delete", middleware.AdminOnly(), authorHandler.DeleteAuthor)
+		apiAuthors.POST("/:id/merge", middleware.AdminOnly(), authorHandler.MergeAuthors)
+		apiAuthors.POST("/:id/aliases", authorHandler.AddAlias)
+		apiAuthors.POST("/:id/aliases/:alias_id/remove", middleware.AdminOnly(), authorHandler.RemoveAlias)
 	}
 
 	// Комментарии
-	apiComments := r.Group("/api/comments", middleware.AuthRequired())
+	apiComments := v1.Group("/comments", middleware.AuthRequired())
 	{
-		apiComments.POST("", commentHandler.CreateComment)                                       // создание
-		apiComments.POST("/:id", middleware.OwnerOrAdmin(), commentHandler.UpdateComment)        // обновление текста (автор или админ)
-		apiComments.POST("/:id/delete", middleware.OwnerOrAdmin(), commentHandler.DeleteComment) // мягкое удаление
+		apiComments.POST("", commentHandler.CreateComment)            // создание
+		apiComments.POST("/:id", commentHandler.UpdateComment)        // обновление текста (автор или админ)
+		apiComments.POST("/:id/delete", commentHandler.DeleteComment) // мягкое удаление
 
 		apiComments.GET("/book/:book_id", commentHandler.GetCommentsByBook) // пагинация ?limit=&offset=
-		apiComments.GET("/user/:user_id", middleware.OwnerOrAdmin(), commentHandler.GetCommentsByUser)
+		apiComments.GET("/user/:user_id", middleware.OwnerOrAdmin("user_id"), commentHandler.GetCommentsByUser)
 		apiComments.GET("/last", commentHandler.GetLastComments)
 
 		apiComments.POST("/:id/status", middleware.AdminOnly(), commentHandler.SetStatus)
 	}
 
+	// Выдачи
+	apiLoans := v1.Group("/loans", middleware.AuthRequired())
+	{
+		apiLoans.POST("/:id/return", loanHandler.ReturnLoan)
+		apiLoans.POST("/:id/renew", loanHandler.RenewLoan)
+	}
+
+	// Предложенные правки книг
+	apiEdits := v1.Group("/edits", middleware.AuthRequired())
+	{
+		apiEdits.GET("/pending", bookEditHandler.GetPendingEdits)
+		apiEdits.GET("/mine", bookEditHandler.GetMyEdits)
+		apiEdits.POST("/:id/accept", bookEditHandler.AcceptEdit)
+		apiEdits.POST("/:id/reject", bookEditHandler.RejectEdit)
+	}
+
+	// Уведомления
+	apiNotifications := v1.Group("/notifications", middleware.AuthRequired())
+	{
+		apiNotifications.POST("/read-all", notificationHandler.MarkAllRead)
+		apiNotifications.POST("/:id/read", notificationHandler.MarkRead)
+	}
+
+	// Полки
+	apiShelves := v1.Group("/shelves", middleware.AuthRequired())
+	{
+		apiShelves.GET("", shelfHandler.GetMyShelves)
+		apiShelves.POST("", shelfHandler.CreateShelf)
+		apiShelves.POST("/:id", shelfHandler.RenameShelf)
+		apiShelves.POST("/:id/delete", shelfHandler.DeleteShelf)
+
+		apiShelves.GET("/:id/books", shelfHandler.GetShelfBooks) // пагинация ?limit=&offset=
+		apiShelves.POST("/:id/books/:book_id", shelfHandler.AddBookToShelf)
+		apiShelves.POST("/:id/books/:book_id/remove", shelfHandler.RemoveBookFromShelf)
+		apiShelves.POST("/:id/books/:book_id/move", shelfHandler.MoveBook) // {"to_shelf_id": ...}
+	}
+
+	// Позиция чтения
+	apiProgress := v1.Group("/progress", middleware.AuthRequired())
+	{
+		apiProgress.GET("", progressHandler.GetMyProgress)
+		apiProgress.GET("/files/:file_id", progressHandler.GetFileProgress)
+		apiProgress.POST("/files/:file_id", progressHandler.SaveFileProgress)
+		apiProgress.GET("/files/:file_id/history", progressHandler.GetFileProgressHistory)
+		apiProgress.POST("/sync-password", progressHandler.SetSyncPassword)
+	}
+
+	// Пометки: выделения, закладки, заметки
+	apiAnnotations := v1.Group("/annotations", middleware.AuthRequired())
+	{
+		apiAnnotations.GET("/book/:book_id", annotationHandler.GetMyAnnotations)
+		apiAnnotations.GET("/book/:book_id/public", annotationHandler.GetPublicAnnotations) // пагинация ?limit=&offset=
+		apiAnnotations.GET("/book/:book_id/export", annotationHandler.ExportAnnotations)    // ?format=markdown|json
+		apiAnnotations.POST("", annotationHandler.CreateAnnotation)
+		apiAnnotations.POST("/:id", annotationHandler.UpdateAnnotation)
+		apiAnnotations.POST("/:id/delete", annotationHandler.DeleteAnnotation)
+		apiAnnotations.POST("/:id/status", middleware.AdminOnly(), annotationHandler.SetStatus)
+	}
+
+	// Протокол синхронизации KOReader
+	kosync := r.Group("/kosync")
+	{
+		kosync.GET("/healthcheck", kosyncHandler.Healthcheck)
+		kosync.POST("/users/create", kosyncHandler.CreateUser)
+		kosync.GET("/users/auth", kosyncHandler.Auth(), kosyncHandler.AuthUser)
+		kosync.PUT("/syncs/progress", kosyncHandler.Auth(), kosyncHandler.UpdateProgress)
+		kosync.GET("/syncs/progress/:document", kosyncHandler.Auth(), kosyncHandler.GetProgress)
+	}
+
+	// Журнал аудита
+	apiAudit := v1.Group("/audit", middleware.AuthRequired(), middleware.SuperAdminOnly())
+	{
+		apiAudit.GET("", auditHandler.List)
+	}
+
 	// Пользователи
-	apiUsers := r.Group("/api/users")
+	apiUsers := v1.Group("/users")
 	{
 		apiUsers.GET("", middleware.AuthRequired(), middleware.AdminOnly(), userHandler.GetUsers)
-		apiUsers.POST("", middleware.AuthRequired(), middleware.AdminOnly(), userHandler.СreateUser)
-		apiUsers.PUT("/:id", middleware.AuthRequired(), middleware.OwnerOrAdmin(), userHandler.UpdateUser)
+		apiUsers.GET("/me/loans", middleware.AuthRequired(), loanHandler.GetMyLoans)
+		apiUsers.GET("/me/notifications", middleware.AuthRequired(), notificationHandler.GetMyNotifications)
+		apiUsers.POST("", middleware.AuthRequired(), middleware.AdminOnly(), userHandler.CreateUser)
+		apiUsers.PUT("/:id", middleware.AuthRequired(), middleware.OwnerOrAdmin("id"), userHandler.UpdateUser)
+		apiUsers.PUT("/:id/admin", middleware.AuthRequired(), middleware.AdminOnly(), userHandler.AdminUpdateUser)
 		apiUsers.POST("/:id/delete", middleware.AuthRequired(), middleware.AdminOnly(), userHandler.SoftDeleteUser)
 		apiUsers.POST("/:id/harddelete", middleware.AuthRequired(), middleware.SuperAdminOnly(), userHandler.HardDeleteUser)
 	}
 
 	// Аутентификация
-	apiAuth := r.Group("/api/auth")
+	apiAuth := v1.Group("/auth")
 	{
 		apiAuth.POST("/login", authHandler.Login)       // вход (JWT-токен в ответе).
 		apiAuth.POST("/register", authHandler.Register) // регистрация.
 	}
 
 	// Теги
-	apiTags := r.Group("/api/tags")
+	apiTags := v1.Group("/tags")
 	{
-		apiTags.GET("", middleware.AuthRequired(), tagHandler.SearchTags) // ?query=
+		apiTags.GET("", middleware.AuthRequired(), tagHandler.SearchTags)        // ?query=
+		apiTags.GET("/cloud", middleware.AuthRequired(), tagHandler.GetTagCloud) // ?category_id=
+		apiTags.GET("/proposed", middleware.AuthRequired(), middleware.AdminOnly(), tagHandler.GetProposedTags)
+		apiTags.GET("/blocklist", middleware.AuthRequired(), middleware.AdminOnly(), tagHandler.GetBlocklist)
+		apiTags.POST("/blocklist", middleware.AuthRequired(), middleware.AdminOnly(), tagHandler.AddToBlocklist)
+		apiTags.POST("/blocklist/:id/remove", middleware.AuthRequired(), middleware.AdminOnly(), tagHandler.RemoveFromBlocklist)
 		apiTags.GET("/:id", middleware.AuthRequired(), tagHandler.GetTagByID)
 		apiTags.POST("", middleware.AuthRequired(), tagHandler.CreateTag)
 		apiTags.PUT("/:id", middleware.AuthRequired(), middleware.AdminOnly(), tagHandler.UpdateTag)
 		apiTags.POST("/:id/delete", middleware.AuthRequired(), middleware.AdminOnly(), tagHandler.DeleteTag)
+		apiTags.POST("/:id/approve", middleware.AuthRequired(), middleware.AdminOnly(), tagHandler.ApproveTag)
+		apiTags.POST("/:id/reject", middleware.AuthRequired(), middleware.AdminOnly(), tagHandler.RejectTag)
+		apiTags.POST("/:id/merge", middleware.AuthRequired(), middleware.AdminOnly(), tagHandler.MergeTags)
+		apiTags.POST("/:id/synonyms", middleware.AuthRequired(), middleware.AdminOnly(), tagHandler.AddSynonym)
+		apiTags.POST("/:id/synonyms/:synonym_id/remove", middleware.AuthRequired(), middleware.AdminOnly(), tagHandler.RemoveSynonym)
 		apiTags.GET("/book/:bookID", middleware.AuthRequired(), tagHandler.GetTagsByBookID)
-		apiTags.POST("/assign", middleware.AuthRequired(), middleware.OwnerOrAdmin(), tagHandler.AssignTagToBook)
-		apiTags.POST("/remove", middleware.AuthRequired(), middleware.OwnerOrAdmin(), tagHandler.RemoveTagFromBook)
+		apiTags.POST("/assign", middleware.AuthRequired(), tagHandler.AssignTagToBook)
+		apiTags.POST("/remove", middleware.AuthRequired(), tagHandler.RemoveTagFromBook)
+	}
+
+	// API v2: REST-семантика — PATCH для изменений, DELETE для удалений
+	// (204 без тела), POST только для создания (201) и действий. Обработчики
+	// общие с v1, версию они узнают из контекста.
+	v2 := r.Group("/api/v2", middleware.APIVersion(middleware.APIv2))
+
+	v2Categories := v2.Group("/categories", middleware.AuthRequired())
+	{
+		v2Categories.GET("", categoryHandler.GetAllCategories)
+		v2Categories.GET("/root", categoryHandler.GetRootCategories)
+		v2Categories.GET("/:id", categoryHandler.GetCategoryByID)
+		v2Categories.GET("/:id/children", categoryHandler.GetCategoryChildren)
+		v2Categories.GET("/:id/books", categoryHandler.GetBooksInCategory)
+		v2Categories.POST("", middleware.AdminOnly(), categoryHandler.CreateCategory)
+		v2Categories.PUT("/:id", middleware.AdminOnly(), categoryHandler.UpdateCategory)
+		v2Categories.PATCH("/:id", middleware.AdminOnly(), categoryHandler.PatchCategory)
+		v2Categories.DELETE("/:id", middleware.AdminOnly(), categoryHandler.DeleteCategory)
+	}
+
+	v2Books := v2.Group("/books", middleware.AuthRequired())
+	{
+		v2Books.GET("", bookHandler.SearchBooks)
+		v2Books.GET("/:book_id", bookHandler.GetBookByID)
+		v2Books.GET("/author/:author_id", bookHandler.GetBooksByAuthor)
+		v2Books.GET("/tag/:tag_id", bookHandler.GetBooksByTag)
+		v2Books.GET("/duplicates/:title", bookHandler.GetDuplicateBooks)
+		v2Books.GET("/mine", bookHandler.GetUserBooks)
+		v2Books.GET("/favorites", bookHandler.GetUserFavoriteBooks)
+		v2Books.GET("/review-queue", middleware.AdminOnly(), bookHandler.GetReviewQueue)
+
+		v2Books.POST("", bookHandler.CreateBook)
+		v2Books.PUT("/:book_id", bookHandler.UpdateBook)
+		v2Books.PATCH("/:book_id", bookHandler.PatchBook)
+		v2Books.DELETE("/:book_id", bookHandler.DeleteBook)
+
+		v2Books.POST("/:book_id/favorite", bookHandler.AddBookToFavorites)
+		v2Books.DELETE("/:book_id/favorite", bookHandler.RemoveBookFromFavorites)
+
+		v2Books.GET("/:book_id/history", bookHandler.GetBookHistory)
+		v2Books.POST("/:book_id/history/:revision_id/rollback", middleware.AdminOnly(), bookHandler.RollbackBook)
+
+		v2Books.POST("/:book_id/status", bookHandler.UpdateBookStatus)
+		v2Books.GET("/:book_id/status/history", bookHandler.GetStatusHistory)
+
+		v2Books.POST("/:book_id/edits", bookEditHandler.ProposeEdit)
+		v2Books.GET("/:book_id/edits", bookEditHandler.GetBookEdits)
+
+		v2Books.PUT("/:book_id/authors", bookHandler.SetBookAuthors)
+		v2Books.PUT("/:book_id/authors/:author_id", bookHandler.AddBookAuthor)
+		v2Books.DELETE("/:book_id/authors/:author_id", bookHandler.RemoveBookAuthor)
+
+		v2Books.PUT("/:book_id/tags", bookHandler.SetBookTags)
+		v2Books.PUT("/:book_id/tags/:tag_id", bookHandler.AddBookTag)
+		v2Books.DELETE("/:book_id/tags/:tag_id", bookHandler.RemoveBookTag)
+
+		v2Books.GET("/:book_id/rating", ratingHandler.GetRatingSummary)
+		v2Books.PUT("/:book_id/rating", ratingHandler.RateBook)
+		v2Books.DELETE("/:book_id/rating", ratingHandler.RemoveRating)
+
+		v2Books.GET("/:book_id/files", bookFileHandler.GetBookFiles)
+		v2Books.GET("/:book_id/files/:file_id/download", bookFileHandler.DownloadFile)
+
+		v2Books.PUT("/:book_id/copies", middleware.AdminOnly(), loanHandler.SetBookCopies)
+		v2Books.GET("/:book_id/availability", loanHandler.GetAvailability)
+		v2Books.POST("/:book_id/checkout", loanHandler.Checkout)
+		v2Books.POST("/:book_id/hold", loanHandler.PlaceHold)
+		v2Books.DELETE("/:book_id/hold", loanHandler.CancelHold)
+	}
+
+	v2Reviews := v2.Group("/reviews", middleware.AuthRequired())
+	{
+		v2Reviews.GET("/book/:book_id", ratingHandler.GetReviewsByBook)
+		v2Reviews.PUT("", ratingHandler.SaveReview) // создание или обновление своей рецензии
+		v2Reviews.DELETE("/:id", ratingHandler.DeleteReview)
+		v2Reviews.PATCH("/:id/status", middleware.AdminOnly(), ratingHandler.SetReviewStatus)
+	}
+
+	v2Authors := v2.Group("/authors", middleware.AuthRequired())
+	{
+		v2Authors.GET("", authorHandler.ListAuthors)
+		v2Authors.GET("/duplicates", middleware.AdminOnly(), authorHandler.ListDuplicates)
+		v2Authors.GET("/merges", middleware.AdminOnly(), authorHandler.ListMerges)
+		v2Authors.GET("/:id", authorHandler.GetAuthorByID)
+		v2Authors.POST("", authorHandler.CreateAuthor)
+		v2Authors.PATCH("/:id", authorHandler.UpdateAuthor)
+		v2Authors.DELETE("/:id", middleware.AdminOnly(), authorHandler.DeleteAuthor)
+		v2Authors.POST("/:id/merge", middleware.AdminOnly(), authorHandler.MergeAuthors)
+		v2Authors.POST("/:id/aliases", authorHandler.AddAlias)
+		v2Authors.DELETE("/:id/aliases/:alias_id", middleware.AdminOnly(), authorHandler.RemoveAlias)
+	}
+
+	v2Comments := v2.Group("/comments", middleware.AuthRequired())
+	{
+		v2Comments.POST("", commentHandler.CreateComment)
+		v2Comments.PATCH("/:id", commentHandler.UpdateComment)
+		v2Comments.DELETE("/:id", commentHandler.DeleteComment)
+		v2Comments.GET("/book/:book_id", commentHandler.GetCommentsByBook)
+		v2Comments.GET("/user/:user_id", middleware.OwnerOrAdmin("user_id"), commentHandler.GetCommentsByUser)
+		v2Comments.GET("/last", commentHandler.GetLastComments)
+		v2Comments.PATCH("/:id/status", middleware.AdminOnly(), commentHandler.SetStatus)
+	}
+
+	v2Loans := v2.Group("/loans", middleware.AuthRequired())
+	{
+		v2Loans.POST("/:id/return", loanHandler.ReturnLoan)
+		v2Loans.POST("/:id/renew", loanHandler.RenewLoan)
+	}
+
+	v2Edits := v2.Group("/edits", middleware.AuthRequired())
+	{
+		v2Edits.GET("/pending", bookEditHandler.GetPendingEdits)
+		v2Edits.GET("/mine", bookEditHandler.GetMyEdits)
+		v2Edits.POST("/:id/accept", bookEditHandler.AcceptEdit)
+		v2Edits.POST("/:id/reject", bookEditHandler.RejectEdit)
+	}
+
+	v2Notifications := v2.Group("/notifications", middleware.AuthRequired())
+	{
+		v2Notifications.POST("/read-all", notificationHandler.MarkAllRead)
+		v2Notifications.POST("/:id/read", notificationHandler.MarkRead)
+	}
+
+	v2Shelves := v2.Group("/shelves", middleware.AuthRequired())
+	{
+		v2Shelves.GET("", shelfHandler.GetMyShelves)
+		v2Shelves.POST("", shelfHandler.CreateShelf)
+		v2Shelves.PATCH("/:id", shelfHandler.RenameShelf)
+		v2Shelves.DELETE("/:id", shelfHandler.DeleteShelf)
+		v2Shelves.GET("/:id/books", shelfHandler.GetShelfBooks)
+		v2Shelves.PUT("/:id/books/:book_id", shelfHandler.AddBookToShelf)
+		v2Shelves.DELETE("/:id/books/:book_id", shelfHandler.RemoveBookFromShelf)
+		v2Shelves.POST("/:id/books/:book_id/move", shelfHandler.MoveBook)
+	}
+
+	v2Progress := v2.Group("/progress", middleware.AuthRequired())
+	{
+		v2Progress.GET("", progressHandler.GetMyProgress)
+		v2Progress.GET("/files/:file_id", progressHandler.GetFileProgress)
+		v2Progress.PUT("/files/:file_id", progressHandler.SaveFileProgress)
+		v2Progress.GET("/files/:file_id/history", progressHandler.GetFileProgressHistory)
+		v2Progress.PUT("/sync-password", progressHandler.SetSyncPassword)
+	}
+
+	v2Annotations := v2.Group("/annotations", middleware.AuthRequired())
+	{
+		v2Annotations.GET("/book/:book_id", annotationHandler.GetMyAnnotations)
+		v2Annotations.GET("/book/:book_id/public", annotationHandler.GetPublicAnnotations)
+		v2Annotations.GET("/book/:book_id/export", annotationHandler.ExportAnnotations)
+		v2Annotations.POST("", annotationHandler.CreateAnnotation)
+		v2Annotations.PATCH("/:id", annotationHandler.UpdateAnnotation)
+		v2Annotations.DELETE("/:id", annotationHandler.DeleteAnnotation)
+		v2Annotations.PATCH("/:id/status", middleware.AdminOnly(), annotationHandler.SetStatus)
+	}
+
+	v2.GET("/audit", middleware.AuthRequired(), middleware.SuperAdminOnly(), auditHandler.List)
+
+	v2Users := v2.Group("/users", middleware.AuthRequired())
+	{
+		v2Users.GET("", middleware.AdminOnly(), userHandler.GetUsers)
+		v2Users.GET("/me/loans", loanHandler.GetMyLoans)
+		v2Users.GET("/me/notifications", notificationHandler.GetMyNotifications)
+		v2Users.POST("", middleware.AdminOnly(), userHandler.CreateUser)
+		v2Users.PATCH("/:id", middleware.OwnerOrAdmin("id"), userHandler.UpdateUser)
+		v2Users.PATCH("/:id/admin", middleware.AdminOnly(), userHandler.AdminUpdateUser)
+		v2Users.DELETE("/:id", middleware.AdminOnly(), userHandler.SoftDeleteUser)
+		v2Users.DELETE("/:id/permanent", middleware.SuperAdminOnly(), userHandler.HardDeleteUser)
+	}
+
+	v2Auth := v2.Group("/auth")
+	{
+		v2Auth.POST("/login", authHandler.Login)
+		v2Auth.POST("/register", authHandler.Register)
+	}
+
+	v2Tags := v2.Group("/tags", middleware.AuthRequired())
+	{
+		v2Tags.GET("", tagHandler.SearchTags)
+		v2Tags.GET("/cloud", tagHandler.GetTagCloud)
+		v2Tags.GET("/proposed", middleware.AdminOnly(), tagHandler.GetProposedTags)
+		v2Tags.GET("/blocklist", middleware.AdminOnly(), tagHandler.GetBlocklist)
+		v2Tags.POST("/blocklist", middleware.AdminOnly(), tagHandler.AddToBlocklist)
+		v2Tags.DELETE("/blocklist/:id", middleware.AdminOnly(), tagHandler.RemoveFromBlocklist)
+		v2Tags.GET("/:id", tagHandler.GetTagByID)
+		v2Tags.POST("", tagHandler.CreateTag)
+		v2Tags.PATCH("/:id", middleware.AdminOnly(), tagHandler.UpdateTag)
+		v2Tags.DELETE("/:id", middleware.AdminOnly(), tagHandler.DeleteTag)
+		v2Tags.POST("/:id/approve", middleware.AdminOnly(), tagHandler.ApproveTag)
+		v2Tags.POST("/:id/reject", middleware.AdminOnly(), tagHandler.RejectTag)
+		v2Tags.POST("/:id/merge", middleware.AdminOnly(), tagHandler.MergeTags)
+		v2Tags.POST("/:id/synonyms", middleware.AdminOnly(), tagHandler.AddSynonym)
+		v2Tags.DELETE("/:id/synonyms/:synonym_id", middleware.AdminOnly(), tagHandler.RemoveSynonym)
+		v2Tags.GET("/book/:bookID", tagHandler.GetTagsByBookID)
+		v2Tags.POST("/assign", tagHandler.AssignTagToBook) // с предложением нового тега по имени
 	}
 }
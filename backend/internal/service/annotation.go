@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"online_library/backend/internal/middleware"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/repository"
+	"strings"
+	"time"
+)
+
+// AnnotationExport — выгрузка пометок пользователя по книге.
+type AnnotationExport struct {
+	BookID      int                 `json:"book_id"`
+	BookTitle   string              `json:"book_title"`
+	ExportedAt  time.Time           `json:"exported_at"`
+	Annotations []models.Annotation `json:"annotations"`
+}
+
+type AnnotationService interface {
+	Create(ctx context.Context, a *models.Annotation, userRole string) error
+	Update(ctx context.Context, a *models.Annotation, userID int) error
+	Delete(ctx context.Context, id, userID int) error
+
+	GetMyAnnotations(ctx context.Context, bookID, userID int, userRole string) ([]models.Annotation, error)
+	GetPublicAnnotations(ctx context.Context, bookID int, userRole string, limit, offset int) ([]models.Annotation, error)
+	Export(ctx context.Context, bookID, userID int, userRole string) (*AnnotationExport, error)
+	SetStatus(ctx context.Context, id int, status string, actor models.Actor) error
+}
+
+type annotationService struct {
+	repo     repository.AnnotationRepository
+	bookRepo repository.BookRepository
+	audit    repository.AuditRepository
+	tx       repository.TxManager
+}
+
+func NewAnnotationService(repo repository.AnnotationRepository, bookRepo repository.BookRepository, audit repository.AuditRepository, tx repository.TxManager) AnnotationService {
+	return &annotationService{repo: repo, bookRepo: bookRepo, audit: audit, tx: tx}
+}
+
+func validateAnnotation(a *models.Annotation) error {
+	switch a.Kind {
+	case "":
+		a.Kind = models.AnnotationHighlight
+	case models.AnnotationHighlight, models.AnnotationBookmark, models.AnnotationNote:
+	default:
+		return apperr.Validationf("unknown_annotation_kind", "unknown annotation kind: %s", a.Kind)
+	}
+
+	switch a.LocatorType {
+	case "":
+		a.LocatorType = models.LocatorCFI
+	case models.LocatorCFI, models.LocatorXPointer, models.LocatorPage, models.LocatorPercentage:
+	default:
+		return apperr.Validationf("unknown_locator_type", "unknown locator type: %s", a.LocatorType)
+	}
+
+	if strings.TrimSpace(a.Locator) == "" {
+		return errLocatorRequired
+	}
+	if a.Kind == models.AnnotationHighlight && (a.Excerpt == nil || strings.TrimSpace(*a.Excerpt) == "") {
+		return apperr.Validation("excerpt_required", "highlight requires an excerpt")
+	}
+	if a.Kind == models.AnnotationNote && (a.Note == nil || strings.TrimSpace(*a.Note) == "") {
+		return apperr.Validation("note_required", "note text is required")
+	}
+	if a.Color != nil && !models.IsHexColor(*a.Color) {
+		return errColorFormat
+	}
+	return nil
+}
+
+func (s *annotationService) getViewableBook(ctx context.Context, bookID int, userRole string) (*models.Book, error) {
+	statuses := getViewableStatuses(userRole)
+	if len(statuses) == 0 {
+		return nil, repository.ErrBookNotFound
+	}
+	book, err := s.bookRepo.GetBookByID(ctx, bookID, statuses)
+	if err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (s *annotationService) Create(ctx context.Context, a *models.Annotation, userRole string) error {
+	if err := validateAnnotation(a); err != nil {
+		return err
+	}
+	if _, err := s.getViewableBook(ctx, a.BookID, userRole); err != nil {
+		return err
+	}
+
+	a.Status = models.CommentStatusActive // либо "pending", если публичные пометки будут проходить модерацию
+	return s.repo.Create(ctx, a)
+}
+
+// Update меняет только цвет, заметку и видимость — позиция и фрагмент неизменны.
+func (s *annotationService) Update(ctx context.Context, a *models.Annotation, userID int) error {
+	existing, err := s.repo.GetByID(ctx, a.ID)
+	if err != nil {
+		return err
+	}
+	if existing.UserID != userID {
+		return repository.ErrAnnotationNotFound
+	}
+	if a.Color != nil && !models.IsHexColor(*a.Color) {
+		return errColorFormat
+	}
+
+	existing.Color = a.Color
+	existing.Note = a.Note
+	existing.IsPublic = a.IsPublic
+	if err := s.repo.Update(ctx, existing); err != nil {
+		return err
+	}
+	*a = *existing
+	return nil
+}
+
+func (s *annotationService) Delete(ctx context.Context, id, userID int) error {
+	existing, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing.UserID != userID {
+		return repository.ErrAnnotationNotFound
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *annotationService) GetMyAnnotations(ctx context.Context, bookID, userID int, userRole string) ([]models.Annotation, error) {
+	if _, err := s.getViewableBook(ctx, bookID, userRole); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByUserAndBook(ctx, userID, bookID)
+}
+
+func (s *annotationService) GetPublicAnnotations(ctx context.Context, bookID int, userRole string, limit, offset int) ([]models.Annotation, error) {
+	if _, err := s.getViewableBook(ctx, bookID, userRole); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPublicByBook(ctx, bookID, []string{models.CommentStatusActive}, limit, offset)
+}
+
+func (s *annotationService) Export(ctx context.Context, bookID, userID int, userRole string) (*AnnotationExport, error) {
+	book, err := s.getViewableBook(ctx, bookID, userRole)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations, err := s.repo.GetByUserAndBook(ctx, userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if annotations == nil {
+		annotations = []models.Annotation{}
+	}
+
+	return &AnnotationExport{
+		BookID:      book.ID,
+		BookTitle:   book.Title,
+		ExportedAt:  time.Now().UTC(),
+		Annotations: annotations,
+	}, nil
+}
+
+func (s *annotationService) SetStatus(ctx context.Context, id int, status string, actor models.Actor) error {
+	if !middleware.IsAdmin(actor.Role) {
+		return adminOnly("change status")
+	}
+
+	annotation, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.SetStatus(ctx, id, status); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditAnnotationStatus, "annotation", id,
+			map[string]interface{}{"status": annotation.Status},
+			map[string]interface{}{"status": status})
+	})
+}
+
+var annotationKindTitles = map[string]string{
+	models.AnnotationHighlight: "Выделение",
+	models.AnnotationBookmark:  "Закладка",
+	models.AnnotationNote:      "Заметка",
+}
+
+// Markdown форматирует выгрузку пометок для заметочников (Obsidian и т.п.).
+func (e *AnnotationExport) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", e.BookTitle)
+	fmt.Fprintf(&b, "_Экспортировано %s_\n", e.ExportedAt.Format("2006-01-02 15:04"))
+
+	for _, a := range e.Annotations {
+		fmt.Fprintf(&b, "\n## %s · %s `%s`\n\n", annotationKindTitles[a.Kind], a.LocatorType, a.Locator)
+		if a.Excerpt != nil && *a.Excerpt != "" {
+			for _, line := range strings.Split(*a.Excerpt, "\n") {
+				fmt.Fprintf(&b, "> %s\n", line)
+			}
+			b.WriteString("\n")
+		}
+		if a.Note != nil && *a.Note != "" {
+			fmt.Fprintf(&b, "%s\n\n", *a.Note)
+		}
+		fmt.Fprintf(&b, "_%s_\n", a.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/repository"
+	"time"
+)
+
+// DefaultAuditRetention — сколько хранится журнал, если не задано AUDIT_RETENTION_DAYS.
+const DefaultAuditRetention = 365 * 24 * time.Hour
+
+type AuditService interface {
+	List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+	PurgeExpired(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+type auditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+func (s *auditService) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	entries, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []models.AuditEntry{}
+	}
+	return entries, nil
+}
+
+func (s *auditService) PurgeExpired(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repo.DeleteOlderThan(ctx, time.Now().Add(-retention))
+}
+
+// RunAuditRetentionJob периодически удаляет записи журнала старше retention, пока не отменён ctx.
+func RunAuditRetentionJob(ctx context.Context, s AuditService, interval, retention time.Duration, log *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeExpired(ctx, retention)
+			if err != nil {
+				log.Error("audit retention job failed", "error", err)
+				continue
+			}
+			if purged > 0 {
+				log.Info("audit retention job: entries purged", "count", purged)
+			}
+		}
+	}
+}
+
+// newAuditEntry собирает запись журнала; before/after сериализуются в JSON,
+// nil означает, что состояния нет (создание или удаление).
+func newAuditEntry(actor models.Actor, action, targetType string, targetID int, before, after interface{}) (*models.AuditEntry, error) {
+	entry := &models.AuditEntry{
+		ActorRole:  actor.Role,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+	}
+	if actor.ID != 0 {
+		entry.ActorID = &actor.ID
+	}
+	if actor.IP != "" {
+		entry.IP = &actor.IP
+	}
+	if actor.RequestID != "" {
+		entry.RequestID = &actor.RequestID
+	}
+
+	var err error
+	if entry.Before, err = marshalAuditState(before); err != nil {
+		return nil, err
+	}
+	if entry.After, err = marshalAuditState(after); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// recordAudit пишет запись журнала; вызывается внутри WithinTx, чтобы запись
+// фиксировалась вместе с изменением или не фиксировалась вовсе.
+func recordAudit(ctx context.Context, repo repository.AuditRepository, actor models.Actor, action, targetType string, targetID int, before, after interface{}) error {
+	entry, err := newAuditEntry(actor, action, targetType, targetID, before, after)
+	if err != nil {
+		return err
+	}
+	return repo.Write(ctx, entry)
+}
+
+// diffStates раскладывает дифф на состояния до и после — только изменённые поля.
+func diffStates(diff []models.FieldDiff) (before, after map[string]interface{}) {
+	before = make(map[string]interface{}, len(diff))
+	after = make(map[string]interface{}, len(diff))
+	for _, d := range diff {
+		before[d.Field] = d.Current
+		after[d.Field] = d.Proposed
+	}
+	return before, after
+}
+
+func marshalAuditState(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
@@ -1,9 +1,11 @@
 package service
 
 import (
-	"errors"
+	"context"
 	"golang.org/x/crypto/bcrypt"
+	"online_library/backend/internal/pkg/apperr"
 	"online_library/backend/internal/pkg/auth"
+	"online_library/backend/internal/pkg/metrics"
 	"online_library/backend/internal/repository"
 )
 
@@ -19,31 +21,36 @@ func NewAuthService(repo *repository.UserRepo, userService UserService) *AuthSer
 	}
 }
 
-func (s *AuthService) Register(email, name, password string, bio string) error {
+func (s *AuthService) Register(ctx context.Context, email, name, password string, bio string) error {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 
-	_, err = s.Repo.SetNewUser(email, name, string(hash), bio)
+	_, err = s.Repo.SetNewUser(ctx, email, name, string(hash), bio)
 	if err != nil {
 		return err
 	}
 	return err
 }
 
-func (s *AuthService) Login(email, password string) (string, error) {
-	user, err := s.Repo.GetByEmail(email)
+var ErrInvalidCredentials = apperr.Unauthorized("invalid_credentials", "invalid credentials")
+
+func (s *AuthService) Login(ctx context.Context, email, password string) (string, error) {
+	user, err := s.Repo.GetByEmail(ctx, email)
 	if err != nil {
-		return "", errors.New("invalid credentials")
+		metrics.LoginsFailed.Inc()
+		return "", ErrInvalidCredentials
 	}
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
 	if err != nil {
-		return "", errors.New("invalid credentials")
+		metrics.LoginsFailed.Inc()
+		return "", ErrInvalidCredentials
 	}
 
 	if user.Is_active == false {
-		return "", errors.New("invalid credentials")
+		metrics.LoginsFailed.Inc()
+		return "", ErrInvalidCredentials
 	}
 
 	token, err := auth.GenerateToken(user.ID, user.Role, user.TokenVersion)
@@ -1,85 +1,140 @@
 package service
 
 import (
-	"errors"
+	"context"
+	"online_library/backend/internal/middleware"
 	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
 	"online_library/backend/internal/pkg/translit"
 	"online_library/backend/internal/repository"
+	"strings"
+)
+
+var (
+	errAuthorNameRequired = apperr.Validation("author_name_required", "at least one of NameRU or NameEN must be provided")
+	errAuthorExists       = apperr.Conflict("author_exists", "author with this name already exists")
+	errUnknownScheme      = apperr.Validation("unknown_translit_scheme", "unknown transliteration scheme")
 )
 
 type AuthorServiceInterface interface {
-	CreateAuthor(author *models.Author) error
-	UpdateAuthor(author *models.Author) error
-	DeleteAuthor(id int) error
-	GetAuthorByID(id int) (*models.Author, error)
-	SearchAuthors(query string, limit, offset int) ([]*models.Author, int, error)
-	GetAllAuthors(limit, offset int) ([]models.Author, error)
+	CreateAuthor(ctx context.Context, author *models.Author, scheme translit.Scheme, actor models.Actor) error
+	UpdateAuthor(ctx context.Context, author *models.Author, scheme translit.Scheme, actor models.Actor) error
+	DeleteAuthor(ctx context.Context, id int, actor models.Actor) error
+	GetAuthorByID(ctx context.Context, id int) (*models.Author, error)
+	SearchAuthors(ctx context.Context, query string, limit, offset int) ([]*models.Author, int, error)
+	GetAllAuthors(ctx context.Context, limit, offset int) ([]models.Author, error)
+
+	GetAuthorDetails(ctx context.Context, id int, userRole string, limit, offset int) (*models.AuthorDetails, error)
+	AddAlias(ctx context.Context, alias *models.AuthorAlias) error
+	RemoveAlias(ctx context.Context, authorID, aliasID int, actor models.Actor) error
+
+	MergeAuthors(ctx context.Context, targetID int, sourceIDs []int, userID int, userRole string) ([]models.AuthorMerge, error)
+	GetMerges(ctx context.Context, userRole string, limit, offset int) ([]models.AuthorMerge, error)
+	FindDuplicates(ctx context.Context, userRole string, threshold float64, limit int) ([]models.DuplicateAuthors, error)
 }
 
 type AuthorService struct {
-	repo repository.AuthorRepository
+	repo     repository.AuthorRepository
+	bookRepo repository.BookRepository
+	audit    repository.AuditRepository
+	tx       repository.TxManager
 }
 
-func NewAuthorService(repo repository.AuthorRepository) *AuthorService {
-	return &AuthorService{repo: repo}
+func NewAuthorService(repo repository.AuthorRepository, bookRepo repository.BookRepository, audit repository.AuditRepository, tx repository.TxManager) *AuthorService {
+	return &AuthorService{repo: repo, bookRepo: bookRepo, audit: audit, tx: tx}
 }
 
-func (s *AuthorService) CreateAuthor(author *models.Author) error {
+// CreateAuthor — пустой name_en заполняется транслитерацией name_ru по scheme
+// (пустая — схема по умолчанию).
+func (s *AuthorService) CreateAuthor(ctx context.Context, author *models.Author, scheme translit.Scheme, actor models.Actor) error {
 
 	if author.NameRU == "" && author.NameEN == "" {
-		return errors.New("at least one of NameRU or NameEN must be provided")
+		return errAuthorNameRequired
 	}
 
+	if scheme != "" && !translit.IsScheme(scheme) {
+		return errUnknownScheme
+	}
 	if author.NameEN == "" && author.NameRU != "" {
-		author.NameEN = translit.ToLatin(author.NameRU)
+		author.NameEN = translit.Transliterate(author.NameRU, scheme)
 	}
 
-	exists, err := s.repo.AuthorExists(author.NameRU, author.NameEN, 0)
+	exists, err := s.repo.AuthorExists(ctx, author.NameRU, author.NameEN, 0)
 	if err != nil {
 		return err
 	}
 	if exists {
-		return errors.New("author with this name already exists")
+		return errAuthorExists
 	}
 
-	return s.repo.CreateAuthor(author)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateAuthor(ctx, author); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditAuthorCreate, "author", author.ID, nil, author)
+	})
 }
 
-func (s *AuthorService) UpdateAuthor(author *models.Author) error {
+func (s *AuthorService) UpdateAuthor(ctx context.Context, author *models.Author, scheme translit.Scheme, actor models.Actor) error {
 	if author.NameRU == "" && author.NameEN == "" {
-		return errors.New("at least one of NameRU or NameEN must be provided")
+		return errAuthorNameRequired
 	}
 
+	if scheme != "" && !translit.IsScheme(scheme) {
+		return errUnknownScheme
+	}
 	if author.NameEN == "" && author.NameRU != "" {
-		author.NameEN = translit.ToLatin(author.NameRU)
+		author.NameEN = translit.Transliterate(author.NameRU, scheme)
 	}
 
-	exists, err := s.repo.AuthorExists(author.NameRU, author.NameEN, author.ID) // исключаем самого себя по ID
+	exists, err := s.repo.AuthorExists(ctx, author.NameRU, author.NameEN, author.ID) // исключаем самого себя по ID
 	if err != nil {
 		return err
 	}
 	if exists {
-		return errors.New("author with this name already exists")
+		return errAuthorExists
 	}
 
-	return s.repo.UpdateAuthor(author)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetAuthorByID(ctx, author.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.UpdateAuthor(ctx, author); err != nil {
+			return err
+		}
+		after, err := s.repo.GetAuthorByID(ctx, author.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditAuthorUpdate, "author", author.ID, before, after)
+	})
 }
 
-func (s *AuthorService) DeleteAuthor(id int) error {
-	return s.repo.DeleteAuthor(id)
+func (s *AuthorService) DeleteAuthor(ctx context.Context, id int, actor models.Actor) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetAuthorByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.DeleteAuthor(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditAuthorDelete, "author", id, before, nil)
+	})
 }
 
-func (s *AuthorService) GetAuthorByID(id int) (*models.Author, error) {
-	return s.repo.GetAuthorByID(id)
+func (s *AuthorService) GetAuthorByID(ctx context.Context, id int) (*models.Author, error) {
+	return s.repo.GetAuthorByID(ctx, id)
 }
 
-func (s *AuthorService) SearchAuthors(query string, limit, offset int) ([]*models.Author, int, error) {
-	authors, err := s.repo.SearchAuthorByName(query, limit, offset)
+func (s *AuthorService) SearchAuthors(ctx context.Context, query string, limit, offset int) ([]*models.Author, int, error) {
+	authors, err := s.repo.SearchAuthorByName(ctx, query, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	count, err := s.repo.CountAuthors(query)
+	count, err := s.repo.CountAuthors(ctx, query)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -87,6 +142,158 @@ func (s *AuthorService) SearchAuthors(query string, limit, offset int) ([]*model
 	return authors, count, nil
 }
 
-func (s *AuthorService) GetAllAuthors(limit, offset int) ([]models.Author, error) {
-	return s.repo.GetAllAuthors(limit, offset)
+func (s *AuthorService) GetAllAuthors(ctx context.Context, limit, offset int) ([]models.Author, error) {
+	return s.repo.GetAllAuthors(ctx, limit, offset)
+}
+
+// GetAuthorDetails собирает страницу автора: профиль, псевдонимы, библиографию по ролям и соавторов.
+// Книги и соавторы фильтруются по статусам, доступным роли пользователя.
+func (s *AuthorService) GetAuthorDetails(ctx context.Context, id int, userRole string, limit, offset int) (*models.AuthorDetails, error) {
+	author, err := s.repo.GetAuthorByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases, err := s.repo.GetAliases(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	author.Aliases = aliases
+
+	details := &models.AuthorDetails{
+		Author:    *author,
+		Works:     []models.AuthorWorks{},
+		CoAuthors: []models.CoAuthor{},
+	}
+
+	statuses := getViewableStatuses(userRole)
+	if len(statuses) == 0 {
+		return details, nil
+	}
+
+	details.BooksCount, err = s.repo.CountAuthorBooks(ctx, id, statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	// Книги группируются по ролям, limit/offset применяются к каждой группе
+	counts, err := s.repo.CountAuthorBooksByRole(ctx, id, statuses)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range models.ContributorRoles {
+		if counts[role] == 0 {
+			continue
+		}
+		books, err := s.bookRepo.GetBooksByAuthor(ctx, id, role, statuses, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		if books == nil {
+			books = []models.Book{}
+		}
+		details.Works = append(details.Works, models.AuthorWorks{Role: role, Count: counts[role], Books: books})
+	}
+
+	coAuthors, err := s.repo.GetCoAuthors(ctx, id, statuses)
+	if err != nil {
+		return nil, err
+	}
+	if coAuthors != nil {
+		details.CoAuthors = coAuthors
+	}
+
+	return details, nil
+}
+
+func (s *AuthorService) AddAlias(ctx context.Context, alias *models.AuthorAlias) error {
+	alias.Name = strings.TrimSpace(alias.Name)
+	if alias.Name == "" {
+		return apperr.Validation("alias_name_required", "alias name is required")
+	}
+
+	switch alias.Kind {
+	case "":
+		alias.Kind = models.AliasSpelling
+	case models.AliasPseudonym, models.AliasSpelling, models.AliasTranslit:
+	default:
+		return apperr.Validationf("unknown_alias_kind", "unknown alias kind: %s", alias.Kind)
+	}
+
+	if _, err := s.repo.GetAuthorByID(ctx, alias.AuthorID); err != nil {
+		return err
+	}
+
+	// Псевдоним не должен совпадать с именем или псевдонимом другого автора
+	exists, err := s.repo.AuthorExists(ctx, alias.Name, alias.Name, alias.AuthorID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return apperr.Conflict("alias_taken", "another author already uses this name")
+	}
+
+	return s.repo.AddAlias(ctx, alias)
+}
+
+func (s *AuthorService) RemoveAlias(ctx context.Context, authorID, aliasID int, actor models.Actor) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		removed, err := s.repo.RemoveAlias(ctx, authorID, aliasID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditAuthorAliasRemove, "author", authorID, removed, nil)
+	})
+}
+
+func (s *AuthorService) MergeAuthors(ctx context.Context, targetID int, sourceIDs []int, userID int, userRole string) ([]models.AuthorMerge, error) {
+	if !middleware.IsAdmin(userRole) {
+		return nil, adminOnly("merge authors")
+	}
+	if len(sourceIDs) == 0 {
+		return nil, errSourceIDsRequired
+	}
+
+	// Повторяющиеся id в запросе сливаем один раз
+	seen := make(map[int]bool, len(sourceIDs))
+	unique := make([]int, 0, len(sourceIDs))
+	for _, id := range sourceIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return s.repo.MergeAuthors(ctx, targetID, unique, userID)
+}
+
+func (s *AuthorService) GetMerges(ctx context.Context, userRole string, limit, offset int) ([]models.AuthorMerge, error) {
+	if !middleware.IsAdmin(userRole) {
+		return nil, adminOnly("view merges")
+	}
+	merges, err := s.repo.GetMerges(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if merges == nil {
+		merges = []models.AuthorMerge{}
+	}
+	return merges, nil
+}
+
+func (s *AuthorService) FindDuplicates(ctx context.Context, userRole string, threshold float64, limit int) ([]models.DuplicateAuthors, error) {
+	if !middleware.IsAdmin(userRole) {
+		return nil, adminOnly("view duplicates")
+	}
+	if threshold <= 0 || threshold > 1 {
+		return nil, apperr.Validation("invalid_threshold", "threshold must be in (0, 1]")
+	}
+	duplicates, err := s.repo.FindDuplicates(ctx, threshold, limit)
+	if err != nil {
+		return nil, err
+	}
+	if duplicates == nil {
+		duplicates = []models.DuplicateAuthors{}
+	}
+	return duplicates, nil
 }
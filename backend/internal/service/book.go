@@ -1,41 +1,62 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"online_library/backend/internal/middleware"
 	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/pkg/metrics"
 	"online_library/backend/internal/repository"
+	"strings"
+)
+
+var (
+	ErrInvalidStatusTransition = apperr.Conflict("invalid_status_transition", "status transition is not allowed")
+	ErrStatusReasonRequired    = apperr.Validation("status_reason_required", "reason is required for this status change")
+	ErrVersionRequired         = apperr.New(apperr.KindPreconditionRequired, "version_required", "book version is required for a partial update")
+	errTitleEmpty              = apperr.Validation("title_empty", "title must not be empty")
 )
 
 type BookService interface {
-	CreateBook(book *models.Book, userRole string, userID int) (int, error)
-	UpdateBook(book *models.Book, userID int, userRole string) error
-	DeleteBook(bookID int, userID int, userRole string) error
-	GetBookByID(bookID int, userRole string) (*models.Book, error)
-	GetBooksByStatuses(userRole string, offset, limit int) ([]models.Book, error)
-	GetBooksByAuthor(authorID int, userRole string, offset, limit int) ([]models.Book, error)
-	GetBooksByTag(tagID int, userRole string, offset, limit int) ([]models.Book, error)
-	SetBookAuthors(bookID int, authorIDs []int, userID int, userRole string) error
-	AddBookAuthor(bookID, authorID int, userID int, userRole string) error
-	RemoveBookAuthor(bookID, authorID int, userID int, userRole string) error
-	SetBookTags(bookID int, tagIDs []int, userID int, userRole string) error
-	AddBookTag(bookID, tagID int, userID int, userRole string) error
-	RemoveBookTag(bookID, tagID int, userID int, userRole string) error
-	UpdateBookStatus(bookID int, status string, userRole string) error
-	SearchBooks(query string, userRole string, limit, offset int) ([]*models.Book, error)
-	GetDuplicateBooks(title string) ([]*models.Book, error)
-	GetUserBooks(userID int) ([]*models.Book, error)
-	GetUserFavoriteBooks(userID int, userRole string) ([]*models.Book, error)
-	AddBookToFavorites(userID, bookID int) error
-	RemoveBookFromFavorites(userID, bookID int) error
+	CreateBook(ctx context.Context, in models.CreateBookInput, rel models.BookRelations, actor models.Actor) (int, error)
+	UpdateBook(ctx context.Context, bookID int, in models.BookInput, actor models.Actor) error
+	PatchBook(ctx context.Context, bookID int, patch models.BookPatch, expectedVersion int, actor models.Actor) (*models.Book, error)
+	GetBookHistory(ctx context.Context, bookID, userID int, userRole string, limit, offset int) ([]models.BookRevision, error)
+	RollbackBook(ctx context.Context, bookID, revisionID int, actor models.Actor) (*models.Book, error)
+	DeleteBook(ctx context.Context, bookID int, actor models.Actor) error
+	GetBookByID(ctx context.Context, bookID, userID int, userRole string) (*models.Book, error)
+	GetBooksByStatuses(ctx context.Context, userRole string, offset, limit int) ([]models.Book, error)
+	GetBooksByAuthor(ctx context.Context, authorID int, role string, userRole string, offset, limit int) ([]models.Book, error)
+	GetBooksByTag(ctx context.Context, tagID int, userRole string, offset, limit int) ([]models.Book, error)
+	SetBookAuthors(ctx context.Context, bookID int, contributors []models.BookContributor, actor models.Actor) error
+	AddBookAuthor(ctx context.Context, bookID int, contributor models.BookContributor, actor models.Actor) error
+	RemoveBookAuthor(ctx context.Context, bookID, authorID int, role string, actor models.Actor) error
+	SetBookTags(ctx context.Context, bookID int, tags []models.BookTag, actor models.Actor) error
+	AddBookTag(ctx context.Context, bookID, tagID, weight int, actor models.Actor) error
+	RemoveBookTag(ctx context.Context, bookID, tagID int, actor models.Actor) error
+	ChangeBookStatus(ctx context.Context, bookID int, status, reason string, actor models.Actor) error
+	GetStatusHistory(ctx context.Context, bookID int, userID int, userRole string) ([]models.BookStatusChange, error)
+	GetReviewQueue(ctx context.Context, userRole string, limit, offset int) ([]models.ReviewQueueItem, error)
+	SearchBooks(ctx context.Context, query string, userRole string, sortBy string, limit, offset int) ([]*models.Book, error)
+	GetDuplicateBooks(ctx context.Context, title string) ([]*models.Book, error)
+	GetUserBooks(ctx context.Context, userID int) ([]*models.Book, error)
+	GetUserFavoriteBooks(ctx context.Context, userID int, userRole string) ([]*models.Book, error)
+	AddBookToFavorites(ctx context.Context, userID, bookID int) error
+	RemoveBookFromFavorites(ctx context.Context, userID, bookID int) error
 }
 
 type bookService struct {
-	repo repository.BookRepository
+	repo          repository.BookRepository
+	tagRepo       repository.TagRepository
+	audit         repository.AuditRepository
+	tx            repository.TxManager
+	notifications NotificationService
 }
 
-func NewBookService(repo repository.BookRepository) BookService {
-	return &bookService{repo: repo}
+func NewBookService(repo repository.BookRepository, tagRepo repository.TagRepository, audit repository.AuditRepository, tx repository.TxManager, notifications NotificationService) BookService {
+	return &bookService{repo: repo, tagRepo: tagRepo, audit: audit, tx: tx, notifications: notifications}
 }
 
 func getViewableStatuses(userRole string) []string {
@@ -45,6 +66,8 @@ func getViewableStatuses(userRole string) []string {
 			models.StatusBookArchived,
 			models.StatusBookQuarantine,
 			models.StatusBookPrivate,
+			models.StatusBookDraft,
+			models.StatusBookRejected,
 		}
 	}
 
@@ -63,138 +86,522 @@ func getViewableStatuses(userRole string) []string {
 	return []string{models.StatusBookVisible}
 }
 
-func (s *bookService) checkBookOwnership(bookID, userID int, userRole string) error {
+func (s *bookService) checkBookOwnership(ctx context.Context, bookID, userID int, userRole string) error {
+	return checkBookOwner(ctx, s.repo, bookID, userID, userRole)
+}
+
+// checkBookOwner пропускает админа или создателя книги.
+func checkBookOwner(ctx context.Context, repo repository.BookRepository, bookID, userID int, userRole string) error {
 	if middleware.IsAdmin(userRole) {
 		return nil
 	}
 
-	book, err := s.repo.GetBookMeta(bookID)
+	book, err := repo.GetBookMeta(ctx, bookID)
 	if err != nil {
-		return fmt.Errorf("failed to check ownership: %w", err)
+		return err
 	}
 
 	if book.CreatedBy != userID {
-		return fmt.Errorf("permission denied: user is not the creator of the book")
+		return errNotBookCreator
 	}
 
 	return nil
 }
 
-func (s *bookService) CreateBook(book *models.Book, userRole string, userID int) (int, error) {
-	if middleware.IsAdmin(userRole) {
+// CreateBook создаёт книгу вместе с авторами, тегами и категориями в одной
+// транзакции: при ошибке в любой связи книга не остаётся наполовину созданной.
+func (s *bookService) CreateBook(ctx context.Context, in models.CreateBookInput, rel models.BookRelations, actor models.Actor) (int, error) {
+	for i := range rel.Authors {
+		if err := normalizeContributor(&rel.Authors[i]); err != nil {
+			return 0, err
+		}
+	}
+	// Ссылка на недоступный тег при создании — ошибка запроса, как и на несуществующий
+	if err := s.checkBookTags(ctx, rel.Tags, actor.ID, actor.Role); errors.Is(err, repository.ErrTagNotFound) {
+		return 0, repository.ErrTagNotFound.WithKind(apperr.KindValidation)
+	} else if err != nil {
+		return 0, err
+	}
+
+	book := bookFromInput(in.BookInput)
+	if book.Title == "" {
+		return 0, errTitleEmpty
+	}
+
+	// Черновик можно создать явно, иначе книга сразу уходит на модерацию
+	switch {
+	case in.Status == models.StatusBookDraft:
+		book.Status = models.StatusBookDraft
+	case middleware.IsAdmin(actor.Role):
 		book.Status = models.StatusBookVisible
-	} else {
+	default:
 		book.Status = models.StatusBookQuarantine
 	}
-	book.CreatedBy = userID
-	return s.repo.CreateBook(book)
+	book.CreatedBy = actor.ID
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		id, err := s.repo.CreateBook(ctx, book)
+		if err != nil {
+			return err
+		}
+		if rel.Authors != nil {
+			if err := s.repo.SetBookAuthors(ctx, id, rel.Authors); err != nil {
+				return err
+			}
+		}
+		if rel.Tags != nil {
+			for i := range rel.Tags {
+				rel.Tags[i].BookID = id
+			}
+			if err := s.repo.SetBookTags(ctx, id, rel.Tags); err != nil {
+				return err
+			}
+		}
+		if rel.CategoryIDs != nil {
+			if err := s.repo.SetBookCategories(ctx, id, rel.CategoryIDs); err != nil {
+				return err
+			}
+		}
+		book.ID = id
+		return recordAudit(ctx, s.audit, actor, models.AuditBookCreate, "book", id, nil, book)
+	})
+	if err != nil {
+		return 0, err
+	}
+	metrics.BooksCreated.Inc()
+	return book.ID, nil
 }
 
-func (s *bookService) UpdateBook(book *models.Book, userID int, userRole string) error {
-	if err := s.checkBookOwnership(book.ID, userID, userRole); err != nil {
+// UpdateBook — полное обновление: все редактируемые поля берутся из in.
+func (s *bookService) UpdateBook(ctx context.Context, bookID int, in models.BookInput, actor models.Actor) error {
+	if err := s.checkBookOwnership(ctx, bookID, actor.ID, actor.Role); err != nil {
 		return err
 	}
-	return s.repo.UpdateBook(book)
+	book := bookFromInput(in)
+	if book.Title == "" {
+		return errTitleEmpty
+	}
+	current, err := s.repo.GetBookByID(ctx, bookID, getViewableStatuses(models.RoleAdmin))
+	if err != nil {
+		return err
+	}
+
+	// Все поля, включая пустые: отсутствующее в запросе значение очищается
+	_, err = s.applySnapshot(ctx, current, models.SnapshotOf(book), 0, actor, models.AuditBookUpdate, nil)
+	return err
 }
 
-func (s *bookService) DeleteBook(bookID int, userID int, userRole string) error {
-	if err := s.checkBookOwnership(bookID, userID, userRole); err != nil {
+// bookFromInput переносит в книгу только редактируемые поля запроса.
+func bookFromInput(in models.BookInput) *models.Book {
+	return &models.Book{
+		Title:       strings.TrimSpace(in.Title),
+		Description: in.Description,
+		PublishYear: in.PublishYear,
+		Pages:       in.Pages,
+		Language:    in.Language,
+		Publisher:   in.Publisher,
+		Type:        in.Type,
+		CoverURL:    in.CoverURL,
+	}
+}
+
+// PatchBook меняет только переданные поля. expectedVersion — версия из If-Match,
+// при расхождении возвращается repository.ErrBookVersionConflict.
+func (s *bookService) PatchBook(ctx context.Context, bookID int, patch models.BookPatch, expectedVersion int, actor models.Actor) (*models.Book, error) {
+	if expectedVersion <= 0 {
+		return nil, ErrVersionRequired
+	}
+	if err := s.checkBookOwnership(ctx, bookID, actor.ID, actor.Role); err != nil {
+		return nil, err
+	}
+	if patch.Title != nil && strings.TrimSpace(*patch.Title) == "" {
+		return nil, errTitleEmpty
+	}
+
+	current, err := s.repo.GetBookByID(ctx, bookID, getViewableStatuses(models.RoleAdmin))
+	if err != nil {
+		return nil, err
+	}
+	if current.Version != expectedVersion {
+		return nil, repository.ErrBookVersionConflict
+	}
+	return s.applySnapshot(ctx, current, models.SnapshotOf(current).With(patch), expectedVersion, actor, models.AuditBookPatch, nil)
+}
+
+// applySnapshot записывает в book все поля target, сохраняет ревизию и запись
+// журнала action. Если ничего не поменялось, книга возвращается как есть,
+// без новой версии.
+func (s *bookService) applySnapshot(ctx context.Context, book *models.Book, target models.BookSnapshot, expectedVersion int, actor models.Actor, action string, note *string) (*models.Book, error) {
+	before := models.SnapshotOf(book)
+	changes := before.Diff(target)
+	if len(changes) == 0 {
+		return book, nil
+	}
+	target.ApplyTo(book)
+
+	rev := &models.BookRevision{ChangedBy: &actor.ID, Changes: changes, Note: note}
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdateBook(ctx, book, rev, expectedVersion); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, action, "book", book.ID, before, target)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+// GetBookHistory — ревизии книги для всех, кому видна сама книга.
+func (s *bookService) GetBookHistory(ctx context.Context, bookID, userID int, userRole string, limit, offset int) ([]models.BookRevision, error) {
+	if _, err := s.getBookForViewer(ctx, bookID, userID, userRole); err != nil {
+		return nil, err
+	}
+	revisions, err := s.repo.GetRevisions(ctx, bookID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if revisions == nil {
+		revisions = []models.BookRevision{}
+	}
+	return revisions, nil
+}
+
+// RollbackBook возвращает поля книги к снимку ревизии. Откат — тоже новая
+// ревизия, так что его самого можно откатить. Авторы и теги не затрагиваются.
+func (s *bookService) RollbackBook(ctx context.Context, bookID, revisionID int, actor models.Actor) (*models.Book, error) {
+	if !middleware.IsAdmin(actor.Role) {
+		return nil, adminOnly("roll back a book")
+	}
+
+	revision, err := s.repo.GetRevision(ctx, bookID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.repo.GetBookByID(ctx, bookID, getViewableStatuses(actor.Role))
+	if err != nil {
+		return nil, err
+	}
+
+	note := fmt.Sprintf("откат к версии %d", revision.Version)
+	return s.applySnapshot(ctx, current, revision.Snapshot, current.Version, actor, models.AuditBookRollback, &note)
+}
+
+// applyBookPatch меняет в книге только заданные в patch поля.
+func applyBookPatch(book *models.Book, p models.BookPatch) {
+	models.SnapshotOf(book).With(p).ApplyTo(book)
+}
+
+// diffBookPatch — поля, которые patch действительно меняет.
+func diffBookPatch(book *models.Book, p models.BookPatch) []models.FieldDiff {
+	before := models.SnapshotOf(book)
+	return before.Diff(before.With(p))
+}
+
+func (s *bookService) DeleteBook(ctx context.Context, bookID int, actor models.Actor) error {
+	if err := s.checkBookOwnership(ctx, bookID, actor.ID, actor.Role); err != nil {
+		return err
+	}
+	book, err := s.repo.GetBookByID(ctx, bookID, getViewableStatuses(models.RoleAdmin))
+	if err != nil {
 		return err
 	}
-	return s.repo.DeleteBook(bookID)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeleteBook(ctx, bookID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditBookDelete, "book", bookID, book, nil)
+	})
 }
 
-func (s *bookService) GetBookByID(bookID int, userRole string) (*models.Book, error) {
-	statuses := getViewableStatuses(userRole)
-	return s.repo.GetBookByID(bookID, statuses)
+func (s *bookService) GetBookByID(ctx context.Context, bookID, userID int, userRole string) (*models.Book, error) {
+	book, err := s.getBookForViewer(ctx, bookID, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+
+	book.Contributors, err = s.repo.GetBookContributors(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	return book, nil
 }
 
-func (s *bookService) GetBooksByStatuses(userRole string, offset, limit int) ([]models.Book, error) {
+// getBookForViewer — книга, если она видна пользователю: по статусу для его роли
+// или в любом статусе, если он создал запись (свои черновики и отклонённые).
+func (s *bookService) getBookForViewer(ctx context.Context, bookID, userID int, userRole string) (*models.Book, error) {
+	book, err := s.repo.GetBookByID(ctx, bookID, getViewableStatuses(userRole))
+	if !errors.Is(err, repository.ErrBookNotFound) {
+		return book, err
+	}
+	meta, metaErr := s.repo.GetBookMeta(ctx, bookID)
+	if metaErr != nil {
+		return nil, metaErr
+	}
+	if meta.CreatedBy != userID {
+		return nil, err
+	}
+	return s.repo.GetBookByID(ctx, bookID, []string{meta.Status})
+}
+
+func (s *bookService) GetBooksByStatuses(ctx context.Context, userRole string, offset, limit int) ([]models.Book, error) {
 	statuses := getViewableStatuses(userRole)
-	return s.repo.GetBooksByStatuses(statuses, offset, limit)
+	return s.repo.GetBooksByStatuses(ctx, statuses, offset, limit)
 }
 
-func (s *bookService) GetBooksByAuthor(authorID int, userRole string, offset, limit int) ([]models.Book, error) {
+func (s *bookService) GetBooksByAuthor(ctx context.Context, authorID int, role string, userRole string, offset, limit int) ([]models.Book, error) {
+	if role != "" && !models.IsContributorRole(role) {
+		return nil, apperr.Validationf("unknown_contributor_role", "unknown contributor role: %s", role)
+	}
 	statuses := getViewableStatuses(userRole)
-	return s.repo.GetBooksByAuthor(authorID, statuses, limit, offset)
+	return s.repo.GetBooksByAuthor(ctx, authorID, role, statuses, limit, offset)
 }
 
-func (s *bookService) GetBooksByTag(tagID int, userRole string, offset, limit int) ([]models.Book, error) {
+func (s *bookService) GetBooksByTag(ctx context.Context, tagID int, userRole string, offset, limit int) ([]models.Book, error) {
 	statuses := getViewableStatuses(userRole)
-	return s.repo.GetBooksByTag(tagID, statuses, limit, offset)
+	return s.repo.GetBooksByTag(ctx, tagID, statuses, limit, offset)
+}
+
+// normalizeContributor подставляет роль по умолчанию и проверяет её.
+func normalizeContributor(c *models.BookContributor) error {
+	if c.Role == "" {
+		c.Role = models.ContributorAuthor
+	}
+	if !models.IsContributorRole(c.Role) {
+		return apperr.Validationf("unknown_contributor_role", "unknown contributor role: %s", c.Role)
+	}
+	if c.Position < 0 {
+		return apperr.Validation("negative_position", "position must not be negative")
+	}
+	return nil
 }
 
-func (s *bookService) SetBookAuthors(bookID int, authorIDs []int, userID int, userRole string) error {
-	if err := s.checkBookOwnership(bookID, userID, userRole); err != nil {
+func (s *bookService) SetBookAuthors(ctx context.Context, bookID int, contributors []models.BookContributor, actor models.Actor) error {
+	for i := range contributors {
+		if err := normalizeContributor(&contributors[i]); err != nil {
+			return err
+		}
+	}
+	return s.changeBookAuthors(ctx, bookID, actor, func(ctx context.Context) error {
+		return s.repo.SetBookAuthors(ctx, bookID, contributors)
+	})
+}
+
+func (s *bookService) AddBookAuthor(ctx context.Context, bookID int, contributor models.BookContributor, actor models.Actor) error {
+	if err := normalizeContributor(&contributor); err != nil {
 		return err
 	}
-	return s.repo.SetBookAuthors(bookID, authorIDs)
+	return s.changeBookAuthors(ctx, bookID, actor, func(ctx context.Context) error {
+		return s.repo.AddBookAuthor(ctx, bookID, contributor)
+	})
 }
 
-func (s *bookService) AddBookAuthor(bookID, authorID int, userID int, userRole string) error {
-	if err := s.checkBookOwnership(bookID, userID, userRole); err != nil {
+func (s *bookService) RemoveBookAuthor(ctx context.Context, bookID, authorID int, role string, actor models.Actor) error {
+	return s.changeBookAuthors(ctx, bookID, actor, func(ctx context.Context) error {
+		return s.repo.RemoveBookAuthor(ctx, bookID, authorID, role)
+	})
+}
+
+// changeBookAuthors проверяет права на книгу и выполняет change в транзакции
+// с записью журнала: состав авторов до и после.
+func (s *bookService) changeBookAuthors(ctx context.Context, bookID int, actor models.Actor, change func(ctx context.Context) error) error {
+	if err := s.checkBookOwnership(ctx, bookID, actor.ID, actor.Role); err != nil {
 		return err
 	}
-	return s.repo.AddBookAuthor(bookID, authorID)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetBookContributors(ctx, bookID)
+		if err != nil {
+			return err
+		}
+		if err := change(ctx); err != nil {
+			return err
+		}
+		after, err := s.repo.GetBookContributors(ctx, bookID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditBookAuthors, "book", bookID,
+			map[string]interface{}{"authors": before}, map[string]interface{}{"authors": after})
+	})
 }
 
-func (s *bookService) RemoveBookAuthor(bookID, authorID int, userID int, userRole string) error {
-	if err := s.checkBookOwnership(bookID, userID, userRole); err != nil {
+func (s *bookService) SetBookTags(ctx context.Context, bookID int, tags []models.BookTag, actor models.Actor) error {
+	if err := s.checkBookTags(ctx, tags, actor.ID, actor.Role); err != nil {
 		return err
 	}
-	return s.repo.RemoveBookAuthor(bookID, authorID)
+	return s.changeBookTags(ctx, bookID, actor, func(ctx context.Context) error {
+		return s.repo.SetBookTags(ctx, bookID, tags)
+	})
 }
 
-func (s *bookService) SetBookTags(bookID int, tagIDs []int, userID int, userRole string) error {
-	if err := s.checkBookOwnership(bookID, userID, userRole); err != nil {
+// checkBookTags проверяет вес и видимость тегов, как при привязке через /api/tags/assign.
+func (s *bookService) checkBookTags(ctx context.Context, tags []models.BookTag, userID int, userRole string) error {
+	for _, t := range tags {
+		if err := validateTagWeight(t.Weight); err != nil {
+			return err
+		}
+		if err := checkTagVisible(ctx, s.tagRepo, t.TagID, userID, userRole); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *bookService) AddBookTag(ctx context.Context, bookID, tagID, weight int, actor models.Actor) error {
+	if err := validateTagWeight(weight); err != nil {
+		return err
+	}
+	if err := checkTagVisible(ctx, s.tagRepo, tagID, actor.ID, actor.Role); err != nil {
 		return err
 	}
-	return s.repo.SetBookTags(bookID, tagIDs)
+	return s.changeBookTags(ctx, bookID, actor, func(ctx context.Context) error {
+		return s.repo.AddBookTag(ctx, bookID, tagID, weight)
+	})
 }
 
-func (s *bookService) AddBookTag(bookID, tagID int, userID int, userRole string) error {
-	if err := s.checkBookOwnership(bookID, userID, userRole); err != nil {
+func (s *bookService) RemoveBookTag(ctx context.Context, bookID, tagID int, actor models.Actor) error {
+	return s.changeBookTags(ctx, bookID, actor, func(ctx context.Context) error {
+		return s.repo.RemoveBookTag(ctx, bookID, tagID)
+	})
+}
+
+// changeBookTags — как changeBookAuthors, но для тегов книги.
+func (s *bookService) changeBookTags(ctx context.Context, bookID int, actor models.Actor, change func(ctx context.Context) error) error {
+	if err := s.checkBookOwnership(ctx, bookID, actor.ID, actor.Role); err != nil {
 		return err
 	}
-	return s.repo.AddBookTag(bookID, tagID)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetBookTags(ctx, bookID)
+		if err != nil {
+			return err
+		}
+		if err := change(ctx); err != nil {
+			return err
+		}
+		after, err := s.repo.GetBookTags(ctx, bookID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditBookTags, "book", bookID,
+			map[string]interface{}{"tags": before}, map[string]interface{}{"tags": after})
+	})
 }
 
-func (s *bookService) RemoveBookTag(bookID, tagID int, userID int, userRole string) error {
-	if err := s.checkBookOwnership(bookID, userID, userRole); err != nil {
+// ChangeBookStatus проводит книгу по автомату публикации. Владелец может
+// отправить черновик на модерацию или отозвать его, остальное — только админ.
+func (s *bookService) ChangeBookStatus(ctx context.Context, bookID int, status, reason string, actor models.Actor) error {
+	book, err := s.repo.GetBookMeta(ctx, bookID)
+	if err != nil {
 		return err
 	}
-	return s.repo.RemoveBookTag(bookID, tagID)
+
+	isAdmin := middleware.IsAdmin(actor.Role)
+	if !isAdmin && book.CreatedBy != actor.ID {
+		return errNotBookCreator
+	}
+
+	transition, ok := models.FindBookStatusTransition(book.Status, status)
+	if !ok {
+		return apperr.Conflictf("invalid_status_transition", "status transition is not allowed: %s → %s", book.Status, status)
+	}
+	if transition.AdminOnly && !isAdmin {
+		return apperr.Forbiddenf("admin_only_status", "permission denied: only admin can move a book to %s", status)
+	}
+
+	reason = strings.TrimSpace(reason)
+	if transition.ReasonRequired && reason == "" {
+		return ErrStatusReasonRequired
+	}
+	var reasonPtr *string
+	if reason != "" {
+		reasonPtr = &reason
+	}
+
+	// Журнал и уведомление пишутся в той же транзакции: смена статуса без них не фиксируется
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.ChangeBookStatus(ctx, bookID, book.Status, status, actor.ID, reasonPtr); err != nil {
+			return err
+		}
+		err := recordAudit(ctx, s.audit, actor, models.AuditBookStatus, "book", bookID,
+			map[string]interface{}{"status": book.Status},
+			map[string]interface{}{"status": status, "reason": reasonPtr})
+		if err != nil {
+			return err
+		}
+		return s.notifySubmitter(ctx, book, status, reason, actor.ID)
+	})
 }
 
-func (s *bookService) UpdateBookStatus(bookID int, status string, userRole string) error {
+// notifySubmitter сообщает автору записи о решении модератора.
+func (s *bookService) notifySubmitter(ctx context.Context, book *models.Book, status, reason string, actorID int) error {
+	if book.CreatedBy == 0 || book.CreatedBy == actorID || book.Status != models.StatusBookQuarantine {
+		return nil
+	}
+
+	var kind, msg string
+	switch status {
+	case models.StatusBookVisible:
+		kind = models.NotificationBookPublished
+		msg = fmt.Sprintf("Ваша книга «%s» опубликована", book.Title)
+	case models.StatusBookRejected:
+		kind = models.NotificationBookRejected
+		msg = fmt.Sprintf("Ваша книга «%s» отклонена: %s", book.Title, reason)
+	default:
+		return nil
+	}
+	return s.notifications.Notify(ctx, book.CreatedBy, kind, msg, "book", book.ID)
+}
+
+func (s *bookService) GetStatusHistory(ctx context.Context, bookID int, userID int, userRole string) ([]models.BookStatusChange, error) {
+	if err := s.checkBookOwnership(ctx, bookID, userID, userRole); err != nil {
+		return nil, err
+	}
+	history, err := s.repo.GetStatusHistory(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		history = []models.BookStatusChange{}
+	}
+	return history, nil
+}
+
+func (s *bookService) GetReviewQueue(ctx context.Context, userRole string, limit, offset int) ([]models.ReviewQueueItem, error) {
 	if !middleware.IsAdmin(userRole) {
-		return fmt.Errorf("permission denied: only admin can update status")
+		return nil, adminOnly("review books")
+	}
+	queue, err := s.repo.GetReviewQueue(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if queue == nil {
+		queue = []models.ReviewQueueItem{}
 	}
-	return s.repo.UpdateBookStatus(bookID, status)
+	return queue, nil
 }
 
-func (s *bookService) SearchBooks(query string, userRole string, limit, offset int) ([]*models.Book, error) {
+func (s *bookService) SearchBooks(ctx context.Context, query string, userRole string, sortBy string, limit, offset int) ([]*models.Book, error) {
 	statuses := getViewableStatuses(userRole)
-	return s.repo.SearchBooks(query, statuses, limit, offset)
+	return s.repo.SearchBooks(ctx, query, statuses, sortBy, limit, offset)
 }
 
-func (s *bookService) GetDuplicateBooks(title string) ([]*models.Book, error) {
-	return s.repo.GetDuplicateBooks(title)
+func (s *bookService) GetDuplicateBooks(ctx context.Context, title string) ([]*models.Book, error) {
+	return s.repo.GetDuplicateBooks(ctx, title)
 }
 
-func (s *bookService) GetUserBooks(userID int) ([]*models.Book, error) {
-	return s.repo.GetUserBooks(userID)
+func (s *bookService) GetUserBooks(ctx context.Context, userID int) ([]*models.Book, error) {
+	return s.repo.GetUserBooks(ctx, userID)
 }
 
-func (s *bookService) GetUserFavoriteBooks(userID int, userRole string) ([]*models.Book, error) {
+func (s *bookService) GetUserFavoriteBooks(ctx context.Context, userID int, userRole string) ([]*models.Book, error) {
 	statuses := getViewableStatuses(userRole)
-	return s.repo.GetUserFavoriteBooks(userID, statuses)
+	return s.repo.GetUserFavoriteBooks(ctx, userID, statuses)
 }
 
-func (s *bookService) AddBookToFavorites(userID, bookID int) error {
-	return s.repo.AddBookToFavorites(userID, bookID)
+func (s *bookService) AddBookToFavorites(ctx context.Context, userID, bookID int) error {
+	return s.repo.AddBookToFavorites(ctx, userID, bookID)
 }
 
-func (s *bookService) RemoveBookFromFavorites(userID, bookID int) error {
-	return s.repo.RemoveBookFromFavorites(userID, bookID)
+func (s *bookService) RemoveBookFromFavorites(ctx context.Context, userID, bookID int) error {
+	return s.repo.RemoveBookFromFavorites(ctx, userID, bookID)
 }
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"online_library/backend/internal/middleware"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/repository"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrEditNoChanges  = apperr.Validation("edit_no_changes", "proposal does not change anything")
+	errTagNotApproved = apperr.Validation("tag_not_approved", "only approved tags can be proposed")
+)
+
+type BookEditService interface {
+	ProposeEdit(ctx context.Context, bookID int, changes models.BookEditChanges, comment string, userID int, userRole string) (*models.BookEditProposal, error)
+	GetBookEdits(ctx context.Context, bookID int, status string, userID int, userRole string, limit, offset int) ([]models.BookEditProposal, error)
+	GetPendingEdits(ctx context.Context, userID int, userRole string, limit, offset int) ([]models.BookEditProposal, error)
+	GetUserEdits(ctx context.Context, userID int, limit, offset int) ([]models.BookEditProposal, error)
+	AcceptEdit(ctx context.Context, id int, comment string, actor models.Actor) error
+	RejectEdit(ctx context.Context, id int, comment string, actor models.Actor) error
+}
+
+type bookEditService struct {
+	repo          repository.BookEditRepository
+	bookRepo      repository.BookRepository
+	tagRepo       repository.TagRepository
+	audit         repository.AuditRepository
+	tx            repository.TxManager
+	notifications NotificationService
+}
+
+func NewBookEditService(repo repository.BookEditRepository, bookRepo repository.BookRepository, tagRepo repository.TagRepository,
+	audit repository.AuditRepository, tx repository.TxManager, notifications NotificationService) BookEditService {
+	return &bookEditService{repo: repo, bookRepo: bookRepo, tagRepo: tagRepo, audit: audit, tx: tx, notifications: notifications}
+}
+
+// ProposeEdit сохраняет правку, если она действительно что-то меняет в книге,
+// которую пользователь может видеть, и сообщает владельцу книги.
+func (s *bookEditService) ProposeEdit(ctx context.Context, bookID int, changes models.BookEditChanges, comment string, userID int, userRole string) (*models.BookEditProposal, error) {
+	book, err := s.bookRepo.GetBookByID(ctx, bookID, getViewableStatuses(userRole))
+	if err != nil {
+		return nil, err
+	}
+	if err := validateEditChanges(&changes); err != nil {
+		return nil, err
+	}
+	if err := s.checkApprovedTags(ctx, changes.Tags); err != nil {
+		return nil, err
+	}
+
+	diff, err := s.diffBook(ctx, book, changes)
+	if err != nil {
+		return nil, err
+	}
+	if len(diff) == 0 {
+		return nil, ErrEditNoChanges
+	}
+
+	proposal := &models.BookEditProposal{
+		BookID:     bookID,
+		BookTitle:  book.Title,
+		ProposedBy: &userID,
+		Changes:    changes,
+		Diff:       diff,
+	}
+	if comment = strings.TrimSpace(comment); comment != "" {
+		proposal.Comment = &comment
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, proposal); err != nil {
+			return err
+		}
+		meta, err := s.bookRepo.GetBookMeta(ctx, bookID)
+		if err != nil {
+			return err
+		}
+		if meta.CreatedBy == 0 || meta.CreatedBy == userID {
+			return nil
+		}
+		msg := fmt.Sprintf("Предложена правка книги «%s»", book.Title)
+		return s.notifications.Notify(ctx, meta.CreatedBy, models.NotificationEditProposed, msg, "book", bookID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+func validateEditChanges(changes *models.BookEditChanges) error {
+	if changes.Title != nil {
+		title := strings.TrimSpace(*changes.Title)
+		if title == "" {
+			return errTitleEmpty
+		}
+		changes.Title = &title
+	}
+	if changes.Authors != nil {
+		authors := *changes.Authors
+		for i := range authors {
+			if err := normalizeContributor(&authors[i]); err != nil {
+				return err
+			}
+		}
+	}
+	if changes.Tags != nil {
+		tags := *changes.Tags
+		for i, t := range tags {
+			if t.TagID <= 0 {
+				return errTagIDRequired
+			}
+			if err := validateTagWeight(t.Weight); err != nil {
+				return err
+			}
+			tags[i] = models.BookTag{TagID: t.TagID, Weight: t.Weight}
+		}
+	}
+	return nil
+}
+
+// checkApprovedTags — в правке допустимы только одобренные теги: предложенный
+// тег виден одному автору и ещё может быть отклонён модератором.
+func (s *bookEditService) checkApprovedTags(ctx context.Context, tags *[]models.BookTag) error {
+	if tags == nil {
+		return nil
+	}
+	for _, t := range *tags {
+		tag, err := s.tagRepo.GetTagByID(ctx, t.TagID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrTagNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if tag.Status != models.TagStatusApproved {
+			return errTagNotApproved
+		}
+	}
+	return nil
+}
+
+func (s *bookEditService) canReview(ctx context.Context, bookID, userID int, userRole string) error {
+	if middleware.IsAdmin(userRole) {
+		return nil
+	}
+	book, err := s.bookRepo.GetBookMeta(ctx, bookID)
+	if err != nil {
+		return err
+	}
+	if book.CreatedBy != userID {
+		return errNotBookCreator
+	}
+	return nil
+}
+
+// GetBookEdits — правки книги для владельца или админа, ожидающие — с диффом.
+func (s *bookEditService) GetBookEdits(ctx context.Context, bookID int, status string, userID int, userRole string, limit, offset int) ([]models.BookEditProposal, error) {
+	if err := s.canReview(ctx, bookID, userID, userRole); err != nil {
+		return nil, err
+	}
+	edits, err := s.repo.GetByBook(ctx, bookID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return s.withDiffs(ctx, edits)
+}
+
+// GetPendingEdits — очередь правок: у админа по всем книгам, у остальных — по своим.
+func (s *bookEditService) GetPendingEdits(ctx context.Context, userID int, userRole string, limit, offset int) ([]models.BookEditProposal, error) {
+	ownerID := userID
+	if middleware.IsAdmin(userRole) {
+		ownerID = 0
+	}
+	edits, err := s.repo.GetPending(ctx, ownerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return s.withDiffs(ctx, edits)
+}
+
+func (s *bookEditService) GetUserEdits(ctx context.Context, userID int, limit, offset int) ([]models.BookEditProposal, error) {
+	edits, err := s.repo.GetByUser(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if edits == nil {
+		edits = []models.BookEditProposal{}
+	}
+	return edits, nil
+}
+
+func (s *bookEditService) withDiffs(ctx context.Context, edits []models.BookEditProposal) ([]models.BookEditProposal, error) {
+	if edits == nil {
+		return []models.BookEditProposal{}, nil
+	}
+	books := make(map[int]*models.Book)
+	for i := range edits {
+		if edits[i].Status != models.EditStatusPending {
+			continue
+		}
+		book, ok := books[edits[i].BookID]
+		if !ok {
+			var err error
+			book, err = s.bookRepo.GetBookByID(ctx, edits[i].BookID, getViewableStatuses(models.RoleAdmin))
+			if err != nil {
+				return nil, err
+			}
+			books[edits[i].BookID] = book
+		}
+		diff, err := s.diffBook(ctx, book, edits[i].Changes)
+		if err != nil {
+			return nil, err
+		}
+		edits[i].Diff = diff
+	}
+	return edits, nil
+}
+
+// AcceptEdit применяет правку к текущей версии книги целиком в одной транзакции
+// и засчитывает её автору правки.
+func (s *bookEditService) AcceptEdit(ctx context.Context, id int, comment string, actor models.Actor) error {
+	proposal, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.canReview(ctx, proposal.BookID, actor.ID, actor.Role); err != nil {
+		return err
+	}
+	if proposal.Status != models.EditStatusPending {
+		return repository.ErrEditNotPending
+	}
+
+	// Теги проверяются повторно: с момента предложения их могли удалить или слить
+	if err := s.checkApprovedTags(ctx, proposal.Changes.Tags); err != nil {
+		return err
+	}
+	book, err := s.bookRepo.GetBookByID(ctx, proposal.BookID, getViewableStatuses(models.RoleAdmin))
+	if err != nil {
+		return err
+	}
+	changes, err := s.diffBook(ctx, book, proposal.Changes)
+	if err != nil {
+		return err
+	}
+	applyBookPatch(book, proposal.Changes.BookPatch)
+
+	// Ревизия записывается от имени автора правки, принявший указан в заметке
+	note := fmt.Sprintf("правка #%d", proposal.ID)
+	rev := &models.BookRevision{ChangedBy: proposal.ProposedBy, Changes: changes, Note: &note}
+	before, after := diffStates(changes)
+	after["edit_id"] = proposal.ID
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Apply(ctx, proposal, book, rev, actor.ID, optionalString(comment)); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, s.audit, actor, models.AuditBookEditAccept, "book", proposal.BookID, before, after); err != nil {
+			return err
+		}
+		return s.notifyProposer(ctx, proposal, models.NotificationEditAccepted,
+			fmt.Sprintf("Ваша правка книги «%s» принята", proposal.BookTitle), actor.ID)
+	})
+}
+
+func (s *bookEditService) RejectEdit(ctx context.Context, id int, comment string, actor models.Actor) error {
+	proposal, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.canReview(ctx, proposal.BookID, actor.ID, actor.Role); err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("Ваша правка книги «%s» отклонена", proposal.BookTitle)
+	if trimmed := strings.TrimSpace(comment); trimmed != "" {
+		msg += ": " + trimmed
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Reject(ctx, id, actor.ID, optionalString(comment)); err != nil {
+			return err
+		}
+		return s.notifyProposer(ctx, proposal, models.NotificationEditRejected, msg, actor.ID)
+	})
+}
+
+func (s *bookEditService) notifyProposer(ctx context.Context, p *models.BookEditProposal, kind, msg string, actorID int) error {
+	if p.ProposedBy == nil || *p.ProposedBy == actorID {
+		return nil
+	}
+	return s.notifications.Notify(ctx, *p.ProposedBy, kind, msg, "book", p.BookID)
+}
+
+func optionalString(s string) *string {
+	if s = strings.TrimSpace(s); s == "" {
+		return nil
+	}
+	return &s
+}
+
+// diffBook сравнивает предложенные значения с текущими и оставляет только отличия.
+func (s *bookEditService) diffBook(ctx context.Context, book *models.Book, c models.BookEditChanges) ([]models.FieldDiff, error) {
+	diff := diffBookPatch(book, c.BookPatch)
+
+	if c.Authors != nil {
+		contributors, err := s.bookRepo.GetBookContributors(ctx, book.ID)
+		if err != nil {
+			return nil, err
+		}
+		current := make([]models.BookContributor, len(contributors))
+		for i, ct := range contributors {
+			current[i] = models.BookContributor{AuthorID: ct.ID, Role: ct.Role, Position: ct.Position}
+		}
+		if !sameContributors(current, *c.Authors) {
+			diff = append(diff, models.FieldDiff{Field: "authors", Current: current, Proposed: *c.Authors})
+		}
+	}
+
+	if c.Tags != nil {
+		current, err := s.bookRepo.GetBookTags(ctx, book.ID)
+		if err != nil {
+			return nil, err
+		}
+		if current == nil {
+			current = []models.BookTag{}
+		}
+		if !sameTags(current, *c.Tags) {
+			diff = append(diff, models.FieldDiff{Field: "tags", Current: current, Proposed: *c.Tags})
+		}
+	}
+	return diff, nil
+}
+
+func sameContributors(a, b []models.BookContributor) bool {
+	sortContributors := func(list []models.BookContributor) []models.BookContributor {
+		sorted := append([]models.BookContributor(nil), list...)
+		sort.Slice(sorted, func(i, j int) bool {
+			if sorted[i].Role != sorted[j].Role {
+				return sorted[i].Role < sorted[j].Role
+			}
+			if sorted[i].Position != sorted[j].Position {
+				return sorted[i].Position < sorted[j].Position
+			}
+			return sorted[i].AuthorID < sorted[j].AuthorID
+		})
+		return sorted
+	}
+	return len(a) == len(b) && reflect.DeepEqual(sortContributors(a), sortContributors(b))
+}
+
+func sameTags(a, b []models.BookTag) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	weights := make(map[int]int, len(a))
+	for _, t := range a {
+		weights[t.TagID] = t.Weight
+	}
+	for _, t := range b {
+		if w, ok := weights[t.TagID]; !ok || w != t.Weight {
+			return false
+		}
+	}
+	return true
+}
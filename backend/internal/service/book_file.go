@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"online_library/backend/internal/middleware"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/pkg/storage"
+	"online_library/backend/internal/repository"
+)
+
+var ErrLoanRequired = apperr.Forbidden("loan_required", "an active loan is required to download this book")
+
+type BookFileService interface {
+	GetBookFiles(ctx context.Context, bookID int, userRole string) ([]models.BookFile, error)
+	OpenDownload(ctx context.Context, bookID, fileID, userID int, userRole string) (*models.BookFile, *storage.Object, error)
+}
+
+type bookFileService struct {
+	repo     repository.BookFileRepository
+	bookRepo repository.BookRepository
+	loanRepo repository.LoanRepository
+	storage  storage.Storage
+}
+
+func NewBookFileService(repo repository.BookFileRepository, bookRepo repository.BookRepository,
+	loanRepo repository.LoanRepository, store storage.Storage) BookFileService {
+	return &bookFileService{repo: repo, bookRepo: bookRepo, loanRepo: loanRepo, storage: store}
+}
+
+func (s *bookFileService) ensureBookViewable(ctx context.Context, bookID int, userRole string) error {
+	statuses := getViewableStatuses(userRole)
+	if len(statuses) == 0 {
+		return repository.ErrBookNotFound
+	}
+	_, err := s.bookRepo.GetBookByID(ctx, bookID, statuses)
+	return err
+}
+
+// GetBookFiles отдаёт список форматов без ссылок — ссылка выдаётся только при скачивании.
+func (s *bookFileService) GetBookFiles(ctx context.Context, bookID int, userRole string) ([]models.BookFile, error) {
+	if err := s.ensureBookViewable(ctx, bookID, userRole); err != nil {
+		return nil, err
+	}
+
+	files, err := s.repo.GetFilesByBook(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range files {
+		files[i].URL = ""
+	}
+	return files, nil
+}
+
+// OpenDownload проверяет доступ и открывает файл в хранилище; закрыть
+// Object.Body должен вызывающий. Адрес в хранилище наружу не уходит.
+func (s *bookFileService) OpenDownload(ctx context.Context, bookID, fileID, userID int, userRole string) (*models.BookFile, *storage.Object, error) {
+	file, err := s.checkDownload(ctx, bookID, fileID, userID, userRole)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Срок запроса (middleware.Deadline) рассчитан на обработчик, а не на передачу
+	// файла: тело читается до конца или пока обработчик его не закроет
+	obj, err := s.storage.Open(context.WithoutCancel(ctx), file.URL)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, repository.ErrFileNotFound.WithCause(err)
+		}
+		return nil, nil, err
+	}
+	return file, obj, nil
+}
+
+// checkDownload проверяет доступ к файлу: книги с ограниченным числом копий
+// скачиваются только при активной выдаче (админам — без ограничений).
+func (s *bookFileService) checkDownload(ctx context.Context, bookID, fileID, userID int, userRole string) (*models.BookFile, error) {
+	if err := s.ensureBookViewable(ctx, bookID, userRole); err != nil {
+		return nil, err
+	}
+
+	file, err := s.repo.GetBookFileByID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if file.BookID != bookID {
+		return nil, repository.ErrFileNotFound
+	}
+
+	if middleware.IsAdmin(userRole) {
+		return file, nil
+	}
+
+	availability, err := s.loanRepo.GetAvailability(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if availability.Copies == nil {
+		return file, nil
+	}
+
+	if _, err := s.loanRepo.GetActiveLoan(ctx, bookID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrLoanRequired
+		}
+		return nil, err
+	}
+	return file, nil
+}
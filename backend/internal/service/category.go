@@ -1,31 +1,35 @@
 package service
 
 import (
+	"context"
 	"online_library/backend/internal/models"
 	"online_library/backend/internal/repository"
 )
 
 type CategoryService interface {
-	GetCategoryTree() ([]map[string]interface{}, error)
-	GetCategoryRoot() ([]*models.Category, error)
-	GetCategoryByID(id int) (*models.Category, error)
-	GetCategoryChildren(id int) ([]*models.Category, error)
-	GetBooksByCategoryIDRecursive(id int) ([]*models.Book, error)
-	CreateCategory(category *models.Category) (int, error)
-	UpdateCategory(category *models.Category) error
-	DeleteCategory(id int) error
+	GetCategoryTree(ctx context.Context) ([]map[string]interface{}, error)
+	GetCategoryRoot(ctx context.Context) ([]*models.Category, error)
+	GetCategoryByID(ctx context.Context, id int) (*models.Category, error)
+	GetCategoryChildren(ctx context.Context, id int) ([]*models.Category, error)
+	GetBooksByCategoryIDRecursive(ctx context.Context, id int) ([]*models.Book, error)
+	CreateCategory(ctx context.Context, in models.CategoryInput, actor models.Actor) (int, error)
+	UpdateCategory(ctx context.Context, id int, in models.CategoryInput, actor models.Actor) error
+	PatchCategory(ctx context.Context, id int, patch models.CategoryPatch, actor models.Actor) (*models.Category, error)
+	DeleteCategory(ctx context.Context, id int, actor models.Actor) error
 }
 
 type categoryService struct {
-	repo repository.CategoryRepository
+	repo  repository.CategoryRepository
+	audit repository.AuditRepository
+	tx    repository.TxManager
 }
 
-func NewCategoryService(repo repository.CategoryRepository) CategoryService {
-	return &categoryService{repo}
+func NewCategoryService(repo repository.CategoryRepository, audit repository.AuditRepository, tx repository.TxManager) CategoryService {
+	return &categoryService{repo: repo, audit: audit, tx: tx}
 }
 
-func (s *categoryService) GetCategoryTree() ([]map[string]interface{}, error) {
-	categories, err := s.repo.GetAllCategories()
+func (s *categoryService) GetCategoryTree(ctx context.Context) ([]map[string]interface{}, error) {
+	categories, err := s.repo.GetAllCategories(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -50,30 +54,105 @@ func buildCategoryTree(categories []models.Category, parentID *int) []map[string
 	return tree
 }
 
-func (s *categoryService) GetCategoryRoot() ([]*models.Category, error) {
-	return s.repo.GetRootCategories()
+func (s *categoryService) GetCategoryRoot(ctx context.Context) ([]*models.Category, error) {
+	return s.repo.GetRootCategories(ctx)
 }
 
-func (s *categoryService) GetCategoryByID(id int) (*models.Category, error) {
-	return s.repo.GetCategoryByID(id)
+func (s *categoryService) GetCategoryByID(ctx context.Context, id int) (*models.Category, error) {
+	return s.repo.GetCategoryByID(ctx, id)
 }
 
-func (s *categoryService) GetCategoryChildren(id int) ([]*models.Category, error) {
-	return s.repo.GetCategoryChildren(id)
+func (s *categoryService) GetCategoryChildren(ctx context.Context, id int) ([]*models.Category, error) {
+	return s.repo.GetCategoryChildren(ctx, id)
 }
 
-func (s *categoryService) GetBooksByCategoryIDRecursive(categoryID int) ([]*models.Book, error) {
-	return s.repo.GetBooksByCategoryIDRecursive(categoryID)
+func (s *categoryService) GetBooksByCategoryIDRecursive(ctx context.Context, categoryID int) ([]*models.Book, error) {
+	return s.repo.GetBooksByCategoryIDRecursive(ctx, categoryID)
 }
 
-func (s *categoryService) CreateCategory(category *models.Category) (int, error) {
-	return s.repo.CreateCategory(category)
+func (s *categoryService) CreateCategory(ctx context.Context, in models.CategoryInput, actor models.Actor) (int, error) {
+	category := categoryFromInput(in)
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		id, err := s.repo.CreateCategory(ctx, category)
+		if err != nil {
+			return err
+		}
+		category.ID = id
+		return recordAudit(ctx, s.audit, actor, models.AuditCategoryCreate, "category", id, nil, category)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return category.ID, nil
+}
+
+func (s *categoryService) UpdateCategory(ctx context.Context, id int, in models.CategoryInput, actor models.Actor) error {
+	category := categoryFromInput(in)
+	category.ID = id
+	return s.updateCategory(ctx, category, actor)
+}
+
+// updateCategory сохраняет категорию и пишет в журнал её вид до и после.
+func (s *categoryService) updateCategory(ctx context.Context, category *models.Category, actor models.Actor) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetCategoryByID(ctx, category.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.UpdateCategory(ctx, category); err != nil {
+			return err
+		}
+		after, err := s.repo.GetCategoryByID(ctx, category.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditCategoryUpdate, "category", category.ID, before, after)
+	})
+}
+
+// PatchCategory меняет только переданные поля и возвращает категорию
+// в новом виде.
+func (s *categoryService) PatchCategory(ctx context.Context, id int, patch models.CategoryPatch, actor models.Actor) (*models.Category, error) {
+	category, err := s.repo.GetCategoryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Name != nil {
+		category.Name = *patch.Name
+	}
+	if patch.ParentID != nil {
+		category.ParentID = patch.ParentID
+	}
+	if patch.Slug != nil {
+		category.Slug = patch.Slug
+	}
+	if patch.Description != nil {
+		category.Description = patch.Description
+	}
+	if err := s.updateCategory(ctx, category, actor); err != nil {
+		return nil, err
+	}
+	return category, nil
 }
 
-func (s *categoryService) UpdateCategory(category *models.Category) error {
-	return s.repo.UpdateCategory(category)
+func categoryFromInput(in models.CategoryInput) *models.Category {
+	return &models.Category{
+		Name:        in.Name,
+		ParentID:    in.ParentID,
+		Slug:        in.Slug,
+		Description: in.Description,
+	}
 }
 
-func (s *categoryService) DeleteCategory(id int) error {
-	return s.repo.DeleteCategory(id)
+func (s *categoryService) DeleteCategory(ctx context.Context, id int, actor models.Actor) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetCategoryByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.DeleteCategory(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditCategoryDelete, "category", id, before, nil)
+	})
 }
@@ -1,8 +1,10 @@
 package service
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
 	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/metrics"
 	"online_library/backend/internal/repository"
 	"time"
 )
@@ -12,35 +14,41 @@ const (
 )
 
 type CommentService interface {
-	Create(comment *models.Comment) error
-	Update(comment *models.Comment, userID int, userRole string) error
-	Delete(id, userID int, userRole string) error
+	Create(ctx context.Context, comment *models.Comment) error
+	Update(ctx context.Context, comment *models.Comment, userID int, userRole string) error
+	Delete(ctx context.Context, id int, actor models.Actor) error
 
-	GetByID(id int) (*models.Comment, error)
-	GetByBookID(bookID int, limit, offset int, statuses []string) ([]models.Comment, error)
-	GetByUserID(userID int, limit, offset int) ([]models.Comment, error)
-	GetLast(limit int) ([]models.Comment, error)
+	GetByID(ctx context.Context, id int) (*models.Comment, error)
+	GetByBookID(ctx context.Context, bookID int, limit, offset int, statuses []string) ([]models.Comment, error)
+	GetByUserID(ctx context.Context, userID int, limit, offset int) ([]models.Comment, error)
+	GetLast(ctx context.Context, limit int) ([]models.Comment, error)
 
-	SetStatus(id int, status, userRole string) error
-	CountByBook(bookID int) (int, error)
+	SetStatus(ctx context.Context, id int, status string, actor models.Actor) error
+	CountByBook(ctx context.Context, bookID int) (int, error)
 }
 
 type commentService struct {
-	repo repository.CommentRepository
-	//logger *zap.SugaredLogger
+	repo  repository.CommentRepository
+	audit repository.AuditRepository
+	tx    repository.TxManager
+	log   *slog.Logger
 }
 
-func NewCommentService(repo repository.CommentRepository) CommentService { // logger *zap.SugaredLogger
-	return &commentService{repo: repo} // , logger: logger
+func NewCommentService(repo repository.CommentRepository, audit repository.AuditRepository, tx repository.TxManager, log *slog.Logger) CommentService {
+	return &commentService{repo: repo, audit: audit, tx: tx, log: log}
 }
 
-func (s *commentService) Create(comment *models.Comment) error {
+func (s *commentService) Create(ctx context.Context, comment *models.Comment) error {
 	comment.Status = models.CommentStatusActive // либо "pending", если будет модерация
-	return s.repo.Create(comment)
+	if err := s.repo.Create(ctx, comment); err != nil {
+		return err
+	}
+	metrics.CommentsPosted.Inc()
+	return nil
 }
 
-func (s *commentService) Update(comment *models.Comment, userID int, userRole string) error {
-	existing, err := s.repo.GetByID(comment.ID)
+func (s *commentService) Update(ctx context.Context, comment *models.Comment, userID int, userRole string) error {
+	existing, err := s.repo.GetByID(ctx, comment.ID)
 	if err != nil {
 		return err
 	}
@@ -49,59 +57,87 @@ func (s *commentService) Update(comment *models.Comment, userID int, userRole st
 	isAdmin := userRole == models.RoleAdmin || userRole == models.RoleSuperAdmin
 
 	if !isOwner && !isAdmin {
-		return fmt.Errorf("access denied: not owner or admin")
+		return errNotOwner
 	}
 
 	existing.Text = comment.Text
 	existing.UpdatedAt = time.Now()
 
-	return s.repo.Update(existing)
+	return s.repo.Update(ctx, existing)
 }
 
-func (s *commentService) Delete(id, userID int, userRole string) error {
-	comment, err := s.repo.GetByID(id)
+func (s *commentService) Delete(ctx context.Context, id int, actor models.Actor) error {
+	comment, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	isOwner := comment.UserID == userID
-	isAdmin := userRole == models.RoleAdmin || userRole == models.RoleSuperAdmin
+	isOwner := comment.UserID == actor.ID
+	isAdmin := actor.Role == models.RoleAdmin || actor.Role == models.RoleSuperAdmin
 
 	if !isOwner && !isAdmin {
-		return fmt.Errorf("access denied: cannot delete")
+		return errNotOwner
 	}
 
-	return s.repo.SetStatus(id, models.CommentStatusDeleted)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.SetStatus(ctx, id, models.CommentStatusDeleted); err != nil {
+			return err
+		}
+		// Удаление своего комментария — не модерация, в журнал не пишется
+		if isOwner {
+			return nil
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditCommentDelete, "comment", id,
+			map[string]interface{}{"status": comment.Status, "text": comment.Text},
+			map[string]interface{}{"status": models.CommentStatusDeleted})
+	})
 }
 
-func (s *commentService) GetByID(id int) (*models.Comment, error) {
-	return s.repo.GetByID(id)
+func (s *commentService) GetByID(ctx context.Context, id int) (*models.Comment, error) {
+	return s.repo.GetByID(ctx, id)
 }
 
-func (s *commentService) GetByBookID(bookID int, limit, offset int, statuses []string) ([]models.Comment, error) {
+func (s *commentService) GetByBookID(ctx context.Context, bookID int, limit, offset int, statuses []string) ([]models.Comment, error) {
 	if len(statuses) == 0 {
 		statuses = []string{models.CommentStatusActive} // по умолчанию только активные
 	}
 
-	return s.repo.GetByBookID(bookID, limit, offset, statuses)
+	return s.repo.GetByBookID(ctx, bookID, limit, offset, statuses)
 }
 
-func (s *commentService) GetByUserID(userID int, limit, offset int) ([]models.Comment, error) {
-	return s.repo.GetByUserID(userID, limit, offset)
+func (s *commentService) GetByUserID(ctx context.Context, userID int, limit, offset int) ([]models.Comment, error) {
+	return s.repo.GetByUserID(ctx, userID, limit, offset)
 }
 
-func (s *commentService) GetLast(limit int) ([]models.Comment, error) {
-	return s.repo.GetLast(limit)
+func (s *commentService) GetLast(ctx context.Context, limit int) ([]models.Comment, error) {
+	return s.repo.GetLast(ctx, limit)
 }
 
-func (s *commentService) SetStatus(id int, status, userRole string) error {
-	if userRole != models.RoleAdmin && userRole != models.RoleSuperAdmin {
-		return fmt.Errorf("access denied: only admin can change status")
+func (s *commentService) SetStatus(ctx context.Context, id int, status string, actor models.Actor) error {
+	if actor.Role != models.RoleAdmin && actor.Role != models.RoleSuperAdmin {
+		return adminOnly("change status")
 	}
 
-	return s.repo.SetStatus(id, status)
+	comment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.SetStatus(ctx, id, status); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditCommentStatus, "comment", id,
+			map[string]interface{}{"status": comment.Status, "text": comment.Text},
+			map[string]interface{}{"status": status})
+	})
+	if err != nil {
+		return err
+	}
+	s.log.InfoContext(ctx, "comment status changed", "comment_id", id, "from", comment.Status, "to", status,
+		"actor_id", actor.ID)
+	return nil
 }
 
-func (s *commentService) CountByBook(bookID int) (int, error) {
-	return s.repo.CountByBook(bookID)
+func (s *commentService) CountByBook(ctx context.Context, bookID int) (int, error) {
+	return s.repo.CountByBook(ctx, bookID)
 }
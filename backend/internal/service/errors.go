@@ -0,0 +1,17 @@
+package service
+
+import "online_library/backend/internal/pkg/apperr"
+
+// Ошибки, общие для нескольких сервисов.
+var (
+	errNotBookCreator    = apperr.Forbidden("not_book_creator", "permission denied: user is not the creator of the book")
+	errNotOwner          = apperr.Forbidden("not_owner", "access denied: not owner or admin")
+	errSourceIDsRequired = apperr.Validation("source_ids_required", "source_ids must not be empty")
+	errColorFormat       = apperr.Validation("invalid_color", "color must be a hex code like #FFAA00")
+	errLocatorRequired   = apperr.Validation("locator_required", "locator is required")
+)
+
+// adminOnly — отказ в действии, доступном только администратору.
+func adminOnly(action string) *apperr.Error {
+	return apperr.Forbiddenf("admin_only", "permission denied: only admin can %s", action)
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"online_library/backend/internal/repository"
+	"online_library/backend/migrations"
+)
+
+// RequiredSchemaVersion — номер последней миграции, под которую написан код;
+// берётся из встроенного каталога миграций.
+var RequiredSchemaVersion = migrations.Latest
+
+const (
+	CheckDatabase   = "database"
+	CheckMigrations = "migrations"
+)
+
+// Итог проверки, который видит зонд: подробности наружу не отдаются.
+const (
+	CheckOK          = "ok"
+	CheckUnavailable = "unavailable"
+	CheckDirty       = "dirty"
+	CheckOutdated    = "outdated"
+	CheckSkipped     = "skipped"
+)
+
+type Readiness struct {
+	Ready   bool              `json:"ready"`
+	Checks  map[string]string `json:"checks"`
+	Details map[string]string `json:"-"` // причины для журнала
+}
+
+type HealthService interface {
+	Readiness(ctx context.Context) Readiness
+}
+
+type healthService struct {
+	repo repository.HealthRepository
+}
+
+func NewHealthService(repo repository.HealthRepository) HealthService {
+	return &healthService{repo: repo}
+}
+
+// Readiness проверяет, что база отвечает и схема не старее кода; незавершённая
+// (dirty) миграция тоже считается неготовностью.
+func (s *healthService) Readiness(ctx context.Context) Readiness {
+	r := Readiness{Ready: true, Checks: map[string]string{}, Details: map[string]string{}}
+	fail := func(check, status, detail string) {
+		r.Ready = false
+		r.Checks[check] = status
+		r.Details[check] = detail
+	}
+
+	if err := s.repo.Ping(ctx); err != nil {
+		fail(CheckDatabase, CheckUnavailable, err.Error())
+		fail(CheckMigrations, CheckSkipped, "database unavailable")
+		return r
+	}
+	r.Checks[CheckDatabase] = CheckOK
+
+	version, dirty, err := s.repo.SchemaVersion(ctx)
+	switch {
+	case err != nil:
+		fail(CheckMigrations, CheckUnavailable, err.Error())
+	case dirty:
+		fail(CheckMigrations, CheckDirty, fmt.Sprintf("migration %d is dirty", version))
+	case version < RequiredSchemaVersion:
+		fail(CheckMigrations, CheckOutdated, fmt.Sprintf("schema version %d, required %d", version, RequiredSchemaVersion))
+	default:
+		r.Checks[CheckMigrations] = CheckOK
+	}
+	return r
+}
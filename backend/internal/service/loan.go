@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"online_library/backend/internal/middleware"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/repository"
+	"time"
+)
+
+// UserLoans — выдачи и очередь ожидания для профиля пользователя.
+type UserLoans struct {
+	Loans []models.Loan     `json:"loans"`
+	Holds []models.LoanHold `json:"holds"`
+}
+
+type LoanService interface {
+	SetBookCopies(ctx context.Context, bookID int, copies *int, actor models.Actor) error
+	GetAvailability(ctx context.Context, bookID, userID int, userRole string) (*models.BookAvailability, error)
+
+	Checkout(ctx context.Context, bookID, userID int, userRole string) (*models.Loan, error)
+	ReturnLoan(ctx context.Context, loanID, userID int, userRole string) error
+	RenewLoan(ctx context.Context, loanID, userID int) (*models.Loan, error)
+
+	PlaceHold(ctx context.Context, bookID, userID int, userRole string) (*models.LoanHold, error)
+	CancelHold(ctx context.Context, bookID, userID int) error
+
+	GetUserLoans(ctx context.Context, userID int) (*UserLoans, error)
+	ExpireOverdueLoans(ctx context.Context) (int, error)
+}
+
+type loanService struct {
+	repo     repository.LoanRepository
+	bookRepo repository.BookRepository
+	audit    repository.AuditRepository
+	tx       repository.TxManager
+}
+
+func NewLoanService(repo repository.LoanRepository, bookRepo repository.BookRepository, audit repository.AuditRepository, tx repository.TxManager) LoanService {
+	return &loanService{repo: repo, bookRepo: bookRepo, audit: audit, tx: tx}
+}
+
+func (s *loanService) ensureBookViewable(ctx context.Context, bookID int, userRole string) error {
+	statuses := getViewableStatuses(userRole)
+	if len(statuses) == 0 {
+		return repository.ErrBookNotFound
+	}
+	_, err := s.bookRepo.GetBookByID(ctx, bookID, statuses)
+	return err
+}
+
+func (s *loanService) SetBookCopies(ctx context.Context, bookID int, copies *int, actor models.Actor) error {
+	if !middleware.IsAdmin(actor.Role) {
+		return adminOnly("set lending copies")
+	}
+	if copies != nil && *copies < 0 {
+		return apperr.Validation("negative_copies", "copies must not be negative")
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetAvailability(ctx, bookID)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.SetBookCopies(ctx, bookID, copies); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditBookCopies, "book", bookID,
+			map[string]interface{}{"copies": before.Copies},
+			map[string]interface{}{"copies": copies})
+	})
+}
+
+func (s *loanService) GetAvailability(ctx context.Context, bookID, userID int, userRole string) (*models.BookAvailability, error) {
+	if err := s.ensureBookViewable(ctx, bookID, userRole); err != nil {
+		return nil, err
+	}
+
+	availability, err := s.repo.GetAvailability(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	loan, err := s.repo.GetActiveLoan(ctx, bookID, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	availability.MyLoan = loan
+
+	hold, err := s.repo.GetWaitingHold(ctx, bookID, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	availability.MyHold = hold
+
+	return availability, nil
+}
+
+func (s *loanService) Checkout(ctx context.Context, bookID, userID int, userRole string) (*models.Loan, error) {
+	if err := s.ensureBookViewable(ctx, bookID, userRole); err != nil {
+		return nil, err
+	}
+	return s.repo.Checkout(ctx, bookID, userID, models.LoanPeriod)
+}
+
+func (s *loanService) ReturnLoan(ctx context.Context, loanID, userID int, userRole string) error {
+	loan, err := s.repo.GetLoanByID(ctx, loanID)
+	if err != nil {
+		return err
+	}
+	if loan.UserID != userID && !middleware.IsAdmin(userRole) {
+		return repository.ErrLoanNotFound
+	}
+	return s.repo.Return(ctx, loanID)
+}
+
+func (s *loanService) RenewLoan(ctx context.Context, loanID, userID int) (*models.Loan, error) {
+	loan, err := s.repo.GetLoanByID(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if loan.UserID != userID {
+		return nil, repository.ErrLoanNotFound
+	}
+	return s.repo.Renew(ctx, loanID, models.LoanPeriod, models.LoanMaxRenewals)
+}
+
+func (s *loanService) PlaceHold(ctx context.Context, bookID, userID int, userRole string) (*models.LoanHold, error) {
+	if err := s.ensureBookViewable(ctx, bookID, userRole); err != nil {
+		return nil, err
+	}
+
+	availability, err := s.repo.GetAvailability(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if availability.Copies == nil {
+		return nil, repository.ErrLendingDisabled
+	}
+	if availability.Available > 0 && availability.HoldsWaiting == 0 {
+		return nil, repository.ErrCopiesAvailable
+	}
+
+	// Просроченная, но ещё не закрытая выдача тоже считается: иначе бронь
+	// не сможет превратиться в выдачу
+	borrowed, err := s.repo.HasActiveLoan(ctx, bookID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if borrowed {
+		return nil, repository.ErrAlreadyBorrowed
+	}
+
+	return s.repo.PlaceHold(ctx, bookID, userID)
+}
+
+func (s *loanService) CancelHold(ctx context.Context, bookID, userID int) error {
+	return s.repo.CancelHold(ctx, bookID, userID)
+}
+
+func (s *loanService) GetUserLoans(ctx context.Context, userID int) (*UserLoans, error) {
+	loans, err := s.repo.GetUserLoans(ctx, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	holds, err := s.repo.GetUserHolds(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if loans == nil {
+		loans = []models.Loan{}
+	}
+	if holds == nil {
+		holds = []models.LoanHold{}
+	}
+	return &UserLoans{Loans: loans, Holds: holds}, nil
+}
+
+func (s *loanService) ExpireOverdueLoans(ctx context.Context) (int, error) {
+	return s.repo.ExpireOverdue(ctx)
+}
+
+// RunLoanExpiryJob периодически закрывает просроченные выдачи, пока не отменён ctx.
+func RunLoanExpiryJob(ctx context.Context, s LoanService, interval time.Duration, log *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.ExpireOverdueLoans(ctx)
+			if err != nil {
+				log.Error("loan expiry job failed", "error", err)
+				continue
+			}
+			if expired > 0 {
+				log.Info("loan expiry job: loans expired", "count", expired)
+			}
+		}
+	}
+}
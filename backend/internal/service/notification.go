@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/repository"
+)
+
+// NotificationList — уведомления пользователя и число непрочитанных.
+type NotificationList struct {
+	Notifications []models.Notification `json:"notifications"`
+	Unread        int                   `json:"unread"`
+}
+
+type NotificationService interface {
+	Notify(ctx context.Context, userID int, kind, message, entityType string, entityID int) error
+	NotifyUser(ctx context.Context, userID int, kind, message string) error
+	GetUserNotifications(ctx context.Context, userID int, unreadOnly bool, limit, offset int) (*NotificationList, error)
+	MarkRead(ctx context.Context, id, userID int) error
+	MarkAllRead(ctx context.Context, userID int) error
+}
+
+type notificationService struct {
+	repo repository.NotificationRepository
+}
+
+func NewNotificationService(repo repository.NotificationRepository) NotificationService {
+	return &notificationService{repo: repo}
+}
+
+func (s *notificationService) Notify(ctx context.Context, userID int, kind, message, entityType string, entityID int) error {
+	return s.repo.Create(ctx, &models.Notification{
+		UserID:     userID,
+		Kind:       kind,
+		Message:    message,
+		EntityType: &entityType,
+		EntityID:   &entityID,
+	})
+}
+
+// NotifyUser — уведомление без ссылки на запись: например, когда запись
+// удалена и ссылаться не на что.
+func (s *notificationService) NotifyUser(ctx context.Context, userID int, kind, message string) error {
+	return s.repo.Create(ctx, &models.Notification{UserID: userID, Kind: kind, Message: message})
+}
+
+func (s *notificationService) GetUserNotifications(ctx context.Context, userID int, unreadOnly bool, limit, offset int) (*NotificationList, error) {
+	notifications, err := s.repo.GetByUser(ctx, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if notifications == nil {
+		notifications = []models.Notification{}
+	}
+	unread, err := s.repo.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &NotificationList{Notifications: notifications, Unread: unread}, nil
+}
+
+func (s *notificationService) MarkRead(ctx context.Context, id, userID int) error {
+	return s.repo.MarkRead(ctx, id, userID)
+}
+
+func (s *notificationService) MarkAllRead(ctx context.Context, userID int) error {
+	return s.repo.MarkAllRead(ctx, userID)
+}
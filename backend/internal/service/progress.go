@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/repository"
+	"strings"
+	"time"
+)
+
+// ProgressInput — позиция чтения, присланная клиентом.
+type ProgressInput struct {
+	Locator     string     `json:"locator"`
+	LocatorType string     `json:"locator_type" binding:"omitempty,oneof=cfi xpointer page percentage"`
+	Percentage  float64    `json:"percentage" binding:"min=0,max=1"`
+	Device      string     `json:"device" binding:"max=100"`
+	DeviceID    string     `json:"device_id" binding:"max=100"`
+	Timestamp   *time.Time `json:"timestamp,omitempty"` // время чтения на устройстве, по умолчанию — время запроса
+}
+
+type ProgressService interface {
+	SaveFileProgress(ctx context.Context, fileID, userID int, userRole string, input ProgressInput) (*models.ReadingProgress, error)
+	GetFileProgress(ctx context.Context, fileID, userID int, userRole string) (*models.ReadingProgress, error)
+	GetFileProgressHistory(ctx context.Context, fileID, userID int, userRole string, limit, offset int) ([]models.ReadingProgress, error)
+	GetUserProgress(ctx context.Context, userID int, limit, offset int) ([]models.ReadingProgress, error)
+
+	SetSyncPassword(ctx context.Context, userID int, password string) error
+	AuthenticateSyncUser(ctx context.Context, email, key string) (*models.User, error)
+	SaveDocumentProgress(ctx context.Context, document string, userID int, input ProgressInput) (*models.ReadingProgress, error)
+	GetDocumentProgress(ctx context.Context, document string, userID int) (*models.ReadingProgress, error)
+}
+
+type progressService struct {
+	repo     repository.ProgressRepository
+	fileRepo repository.BookFileRepository
+	bookRepo repository.BookRepository
+	userRepo repository.UserRepository
+}
+
+func NewProgressService(repo repository.ProgressRepository, fileRepo repository.BookFileRepository,
+	bookRepo repository.BookRepository, userRepo repository.UserRepository) ProgressService {
+	return &progressService{repo: repo, fileRepo: fileRepo, bookRepo: bookRepo, userRepo: userRepo}
+}
+
+func validateProgress(input *ProgressInput) error {
+	switch input.LocatorType {
+	case "":
+		input.LocatorType = models.LocatorPercentage
+	case models.LocatorCFI, models.LocatorXPointer, models.LocatorPage, models.LocatorPercentage:
+	default:
+		return apperr.Validationf("unknown_locator_type", "unknown locator type: %s", input.LocatorType)
+	}
+
+	if input.Percentage < 0 || input.Percentage > 1 {
+		return apperr.Validation("invalid_percentage", "percentage must be between 0 and 1")
+	}
+	if input.LocatorType == models.LocatorPercentage && input.Locator == "" {
+		input.Locator = fmt.Sprintf("%.5f", input.Percentage)
+	}
+	if input.Locator == "" {
+		return errLocatorRequired
+	}
+	return nil
+}
+
+// progressTime не даёт устройству с убежавшими вперёд часами навсегда «заблокировать» позицию.
+func progressTime(ts *time.Time) time.Time {
+	now := time.Now().UTC()
+	if ts == nil || ts.IsZero() || ts.After(now) {
+		return now
+	}
+	return ts.UTC()
+}
+
+// getViewableFile возвращает файл, если книга, к которой он относится, доступна пользователю.
+func (s *progressService) getViewableFile(ctx context.Context, fileID int, userRole string) (*models.BookFile, error) {
+	file, err := s.fileRepo.GetBookFileByID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := getViewableStatuses(userRole)
+	if len(statuses) == 0 {
+		return nil, repository.ErrFileNotFound
+	}
+	if _, err := s.bookRepo.GetBookByID(ctx, file.BookID, statuses); err != nil {
+		if errors.Is(err, repository.ErrBookNotFound) {
+			return nil, repository.ErrFileNotFound
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+func (s *progressService) save(ctx context.Context, p *models.ReadingProgress) (*models.ReadingProgress, error) {
+	applied, err := s.repo.SaveProgress(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if applied {
+		return p, nil
+	}
+	// На сервере более свежая позиция — возвращаем её, клиент должен перейти к ней
+	return s.repo.GetProgress(ctx, p.UserID, p.Document)
+}
+
+func (s *progressService) SaveFileProgress(ctx context.Context, fileID, userID int, userRole string, input ProgressInput) (*models.ReadingProgress, error) {
+	if err := validateProgress(&input); err != nil {
+		return nil, err
+	}
+	file, err := s.getViewableFile(ctx, fileID, userRole)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.save(ctx, &models.ReadingProgress{
+		UserID:      userID,
+		BookFileID:  &file.ID,
+		Document:    file.Hash,
+		Locator:     input.Locator,
+		LocatorType: input.LocatorType,
+		Percentage:  input.Percentage,
+		Device:      input.Device,
+		DeviceID:    input.DeviceID,
+		UpdatedAt:   progressTime(input.Timestamp),
+	})
+}
+
+func (s *progressService) GetFileProgress(ctx context.Context, fileID, userID int, userRole string) (*models.ReadingProgress, error) {
+	file, err := s.getViewableFile(ctx, fileID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetProgress(ctx, userID, file.Hash)
+}
+
+func (s *progressService) GetFileProgressHistory(ctx context.Context, fileID, userID int, userRole string, limit, offset int) ([]models.ReadingProgress, error) {
+	file, err := s.getViewableFile(ctx, fileID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetProgressHistory(ctx, userID, file.Hash, limit, offset)
+}
+
+func (s *progressService) GetUserProgress(ctx context.Context, userID int, limit, offset int) ([]models.ReadingProgress, error) {
+	return s.repo.GetUserProgress(ctx, userID, limit, offset)
+}
+
+// syncKey повторяет то, что KOReader отправляет в x-auth-key: md5 от пароля в hex.
+func syncKey(password string) string {
+	sum := md5.Sum([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *progressService) SetSyncPassword(ctx context.Context, userID int, password string) error {
+	if len(password) < 6 {
+		return apperr.Validation("sync_password_too_short", "sync password must be at least 6 characters")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(syncKey(password)), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return s.userRepo.SetSyncKeyHash(ctx, userID, string(hash))
+}
+
+func (s *progressService) AuthenticateSyncUser(ctx context.Context, email, key string) (*models.User, error) {
+	user, err := s.userRepo.GetSyncUserByEmail(ctx, email)
+	if err != nil || !user.Is_active || user.SyncKeyHash == nil {
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(*user.SyncKeyHash), []byte(strings.ToLower(key))); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// SaveDocumentProgress сохраняет позицию по digest документа (протокол KOReader).
+// Если digest совпадает с хешем файла библиотеки, позиция привязывается к файлу.
+func (s *progressService) SaveDocumentProgress(ctx context.Context, document string, userID int, input ProgressInput) (*models.ReadingProgress, error) {
+	if document == "" {
+		return nil, apperr.Validation("document_required", "document is required")
+	}
+	if err := validateProgress(&input); err != nil {
+		return nil, err
+	}
+
+	p := &models.ReadingProgress{
+		UserID:      userID,
+		Document:    document,
+		Locator:     input.Locator,
+		LocatorType: input.LocatorType,
+		Percentage:  input.Percentage,
+		Device:      input.Device,
+		DeviceID:    input.DeviceID,
+		UpdatedAt:   progressTime(input.Timestamp),
+	}
+	if file, err := s.fileRepo.GetBookFileByHash(ctx, document); err == nil {
+		p.BookFileID = &file.ID
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return s.save(ctx, p)
+}
+
+func (s *progressService) GetDocumentProgress(ctx context.Context, document string, userID int) (*models.ReadingProgress, error) {
+	return s.repo.GetProgress(ctx, userID, document)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"online_library/backend/internal/middleware"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/repository"
+	"strings"
+)
+
+type RatingService interface {
+	RateBook(ctx context.Context, bookID, score, userID int, userRole string) (*models.BookRating, error)
+	RemoveRating(ctx context.Context, bookID, userID int, userRole string) error
+	GetRatingSummary(ctx context.Context, bookID, userID int, userRole string) (*models.RatingSummary, error)
+
+	SaveReview(ctx context.Context, review *models.BookReview, userRole string) error
+	DeleteReview(ctx context.Context, id int, actor models.Actor) error
+	GetReviewsByBook(ctx context.Context, bookID int, userRole string, limit, offset int) ([]models.BookReview, error)
+	SetReviewStatus(ctx context.Context, id int, status string, actor models.Actor) error
+}
+
+type ratingService struct {
+	repo     repository.RatingRepository
+	bookRepo repository.BookRepository
+	audit    repository.AuditRepository
+	tx       repository.TxManager
+}
+
+func NewRatingService(repo repository.RatingRepository, bookRepo repository.BookRepository, audit repository.AuditRepository, tx repository.TxManager) RatingService {
+	return &ratingService{repo: repo, bookRepo: bookRepo, audit: audit, tx: tx}
+}
+
+func validateScore(score int) error {
+	if score < models.RatingMinScore || score > models.RatingMaxScore {
+		return apperr.Validationf("invalid_score", "score must be between %d and %d", models.RatingMinScore, models.RatingMaxScore)
+	}
+	return nil
+}
+
+// ensureBookViewable не даёт оценивать и рецензировать книги, которые пользователь не видит.
+func (s *ratingService) ensureBookViewable(ctx context.Context, bookID int, userRole string) error {
+	statuses := getViewableStatuses(userRole)
+	if len(statuses) == 0 {
+		return repository.ErrBookNotFound
+	}
+	_, err := s.bookRepo.GetBookByID(ctx, bookID, statuses)
+	return err
+}
+
+func (s *ratingService) RateBook(ctx context.Context, bookID, score, userID int, userRole string) (*models.BookRating, error) {
+	if err := validateScore(score); err != nil {
+		return nil, err
+	}
+	if err := s.ensureBookViewable(ctx, bookID, userRole); err != nil {
+		return nil, err
+	}
+
+	rating := &models.BookRating{UserID: userID, BookID: bookID, Score: score}
+	if err := s.repo.SetRating(ctx, rating); err != nil {
+		return nil, err
+	}
+	return rating, nil
+}
+
+func (s *ratingService) RemoveRating(ctx context.Context, bookID, userID int, userRole string) error {
+	if err := s.ensureBookViewable(ctx, bookID, userRole); err != nil {
+		return err
+	}
+	return s.repo.DeleteRating(ctx, userID, bookID)
+}
+
+func (s *ratingService) GetRatingSummary(ctx context.Context, bookID, userID int, userRole string) (*models.RatingSummary, error) {
+	if err := s.ensureBookViewable(ctx, bookID, userRole); err != nil {
+		return nil, err
+	}
+
+	summary, err := s.repo.GetRatingSummary(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	mine, err := s.repo.GetUserRating(ctx, userID, bookID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if mine != nil {
+		summary.MyScore = &mine.Score
+	}
+	return summary, nil
+}
+
+func (s *ratingService) SaveReview(ctx context.Context, review *models.BookReview, userRole string) error {
+	review.Text = strings.TrimSpace(review.Text)
+	if review.Text == "" {
+		return apperr.Validation("review_text_required", "review text is required")
+	}
+	if review.Score != nil {
+		if err := validateScore(*review.Score); err != nil {
+			return err
+		}
+	}
+	if err := s.ensureBookViewable(ctx, review.BookID, userRole); err != nil {
+		return err
+	}
+
+	review.Status = models.CommentStatusActive // либо "pending", если будет модерация
+	return s.repo.SaveReview(ctx, review)
+}
+
+func (s *ratingService) DeleteReview(ctx context.Context, id int, actor models.Actor) error {
+	review, err := s.repo.GetReviewByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	isOwner := review.UserID == actor.ID
+	if !isOwner && !middleware.IsAdmin(actor.Role) {
+		return errNotOwner
+	}
+
+	// Удаление своей рецензии — не модерация, в журнал не пишется
+	if isOwner {
+		return s.repo.SetReviewStatus(ctx, id, models.CommentStatusDeleted)
+	}
+	return s.moderateReview(ctx, review, models.CommentStatusDeleted, actor)
+}
+
+func (s *ratingService) GetReviewsByBook(ctx context.Context, bookID int, userRole string, limit, offset int) ([]models.BookReview, error) {
+	if err := s.ensureBookViewable(ctx, bookID, userRole); err != nil {
+		return nil, err
+	}
+	return s.repo.GetReviewsByBook(ctx, bookID, []string{models.CommentStatusActive}, limit, offset)
+}
+
+func (s *ratingService) SetReviewStatus(ctx context.Context, id int, status string, actor models.Actor) error {
+	if !middleware.IsAdmin(actor.Role) {
+		return adminOnly("change status")
+	}
+	review, err := s.repo.GetReviewByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.moderateReview(ctx, review, status, actor)
+}
+
+// moderateReview меняет статус рецензии и пишет это в журнал в одной транзакции.
+func (s *ratingService) moderateReview(ctx context.Context, review *models.BookReview, status string, actor models.Actor) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.SetReviewStatus(ctx, review.ID, status); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditReviewStatus, "review", review.ID,
+			map[string]interface{}{"status": review.Status, "text": review.Text},
+			map[string]interface{}{"status": status})
+	})
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
+	"online_library/backend/internal/repository"
+	"strings"
+)
+
+var errShelfNameRequired = apperr.Validation("shelf_name_required", "shelf name is required")
+
+type ShelfService interface {
+	GetUserShelves(ctx context.Context, userID int) ([]models.Shelf, error)
+	CreateShelf(ctx context.Context, name string, userID int) (*models.Shelf, error)
+	RenameShelf(ctx context.Context, shelfID int, name string, userID int) error
+	DeleteShelf(ctx context.Context, shelfID, userID int) error
+
+	AddBookToShelf(ctx context.Context, shelfID, bookID, userID int, userRole string) error
+	RemoveBookFromShelf(ctx context.Context, shelfID, bookID, userID int) error
+	MoveBook(ctx context.Context, fromShelfID, toShelfID, bookID, userID int) error
+	GetShelfBooks(ctx context.Context, shelfID, userID int, userRole string, limit, offset int) ([]models.ShelfEntry, int, error)
+}
+
+type shelfService struct {
+	repo     repository.ShelfRepository
+	bookRepo repository.BookRepository
+}
+
+func NewShelfService(repo repository.ShelfRepository, bookRepo repository.BookRepository) ShelfService {
+	return &shelfService{repo: repo, bookRepo: bookRepo}
+}
+
+// shelfStatuses — то же, что getViewableStatuses, но архивные книги остаются
+// на полках пользователя (с пометкой archived).
+func shelfStatuses(userRole string) []string {
+	statuses := getViewableStatuses(userRole)
+	for _, s := range statuses {
+		if s == models.StatusBookArchived {
+			return statuses
+		}
+	}
+	return append(statuses, models.StatusBookArchived)
+}
+
+// getOwnShelf возвращает полку, только если она принадлежит пользователю.
+func (s *shelfService) getOwnShelf(ctx context.Context, shelfID, userID int) (*models.Shelf, error) {
+	shelf, err := s.repo.GetShelfByID(ctx, shelfID)
+	if err != nil {
+		return nil, err
+	}
+	if shelf.UserID != userID {
+		return nil, repository.ErrShelfNotFound
+	}
+	return shelf, nil
+}
+
+func (s *shelfService) GetUserShelves(ctx context.Context, userID int) ([]models.Shelf, error) {
+	if err := s.repo.EnsureReadingStatusShelves(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetUserShelves(ctx, userID)
+}
+
+func (s *shelfService) CreateShelf(ctx context.Context, name string, userID int) (*models.Shelf, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errShelfNameRequired
+	}
+
+	shelf := &models.Shelf{UserID: userID, Name: name, Kind: models.ShelfCustom}
+	if err := s.repo.CreateShelf(ctx, shelf); err != nil {
+		return nil, fmt.Errorf("failed to create shelf: %w", err)
+	}
+	return shelf, nil
+}
+
+func (s *shelfService) RenameShelf(ctx context.Context, shelfID int, name string, userID int) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errShelfNameRequired
+	}
+
+	shelf, err := s.getOwnShelf(ctx, shelfID, userID)
+	if err != nil {
+		return err
+	}
+	shelf.Name = name
+	return s.repo.UpdateShelf(ctx, shelf)
+}
+
+func (s *shelfService) DeleteShelf(ctx context.Context, shelfID, userID int) error {
+	shelf, err := s.getOwnShelf(ctx, shelfID, userID)
+	if err != nil {
+		return err
+	}
+	if models.IsReadingStatusShelf(shelf.Kind) {
+		return apperr.Conflict("reading_shelf_protected", "reading status shelves cannot be deleted")
+	}
+	return s.repo.DeleteShelf(ctx, shelfID)
+}
+
+func (s *shelfService) AddBookToShelf(ctx context.Context, shelfID, bookID, userID int, userRole string) error {
+	shelf, err := s.getOwnShelf(ctx, shelfID, userID)
+	if err != nil {
+		return err
+	}
+
+	statuses := getViewableStatuses(userRole)
+	if len(statuses) == 0 {
+		return repository.ErrBookNotFound
+	}
+	if _, err := s.bookRepo.GetBookByID(ctx, bookID, statuses); err != nil {
+		return err
+	}
+
+	return s.repo.AddBookToShelf(ctx, shelf, bookID)
+}
+
+func (s *shelfService) RemoveBookFromShelf(ctx context.Context, shelfID, bookID, userID int) error {
+	if _, err := s.getOwnShelf(ctx, shelfID, userID); err != nil {
+		return err
+	}
+	return s.repo.RemoveBookFromShelf(ctx, shelfID, bookID)
+}
+
+func (s *shelfService) MoveBook(ctx context.Context, fromShelfID, toShelfID, bookID, userID int) error {
+	from, err := s.getOwnShelf(ctx, fromShelfID, userID)
+	if err != nil {
+		return err
+	}
+	to, err := s.getOwnShelf(ctx, toShelfID, userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.MoveBook(ctx, from, to, bookID)
+}
+
+func (s *shelfService) GetShelfBooks(ctx context.Context, shelfID, userID int, userRole string, limit, offset int) ([]models.ShelfEntry, int, error) {
+	if _, err := s.getOwnShelf(ctx, shelfID, userID); err != nil {
+		return nil, 0, err
+	}
+
+	statuses := shelfStatuses(userRole)
+	entries, err := s.repo.GetShelfBooks(ctx, shelfID, statuses, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	count, err := s.repo.CountShelfBooks(ctx, shelfID, statuses)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, count, nil
+}
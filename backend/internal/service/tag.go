@@ -1,79 +1,479 @@
 package service
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"math"
+	"online_library/backend/internal/middleware"
 	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
 	"online_library/backend/internal/repository"
+	"strings"
+	"time"
+)
+
+// Для счётчиков использования учитываются только опубликованные книги
+var tagUsageStatuses = []string{models.StatusBookVisible}
+
+// Лимит предложений тегов от одного пользователя (админов не касается)
+const (
+	tagProposalLimit  = 10
+	tagProposalWindow = 24 * time.Hour
+)
+
+var (
+	ErrTagRateLimited = apperr.New(apperr.KindTooManyRequests, "tag_rate_limited", "too many tags proposed, try again later")
+	ErrTagBlocked     = apperr.Validation("tag_blocked", "tag name is not allowed")
+	ErrTagPending     = apperr.Conflict("tag_pending", "tag with this name is awaiting moderation")
+
+	errTagIDRequired     = apperr.Validation("tag_id_required", "tag_id is required")
+	errBookTagIDRequired = apperr.Validation("book_tag_ids_required", "book ID and tag ID are required")
 )
 
 type TagService interface {
-	GetAllTags() ([]models.Tag, error)
-	GetTagByID(id int) (models.Tag, error)
+	GetAllTags(ctx context.Context) ([]models.Tag, error)
+	GetTagByID(ctx context.Context, id, userID int, userRole string) (models.Tag, error)
+
+	CreateTag(ctx context.Context, tag *models.Tag, userID int, userRole string) (bool, error)
+	UpdateTag(ctx context.Context, tag *models.Tag, actor models.Actor) error
+	DeleteTag(ctx context.Context, id int, actor models.Actor) error
+
+	GetTagsByBookID(ctx context.Context, bookID, userID int, userRole string) ([]models.Tag, error)
+	AssignTagToBook(ctx context.Context, bookTag *models.BookTag, userID int, userRole string) error
+	RemoveTagFromBook(ctx context.Context, bookID, tagID, userID int, userRole string) error
+
+	SearchTags(ctx context.Context, query string, userID int, userRole string, limit, offset int) ([]models.TagUsage, error)
+	AddSynonym(ctx context.Context, synonym *models.TagSynonym, actor models.Actor) error
+	RemoveSynonym(ctx context.Context, tagID, synonymID int, actor models.Actor) error
+	MergeTags(ctx context.Context, targetID int, sourceIDs []int, actor models.Actor) (int, error)
+	GetTagCloud(ctx context.Context, categoryID *int, limit int) ([]models.TagCloudEntry, error)
+
+	GetProposedTags(ctx context.Context, limit, offset int) ([]models.Tag, error)
+	ApproveTag(ctx context.Context, id int, actor models.Actor) error
+	RejectTag(ctx context.Context, id int, reason string, actor models.Actor) error
+
+	GetBlocklist(ctx context.Context) ([]models.TagBlock, error)
+	AddToBlocklist(ctx context.Context, block *models.TagBlock, actor models.Actor) error
+	RemoveFromBlocklist(ctx context.Context, id int, actor models.Actor) error
+}
 
-	CreateTag(tag *models.Tag) error
-	UpdateTag(tag *models.Tag) error
-	DeleteTag(id int) error
+// tagCloudLevels — число уровней размера шрифта в облаке
+const tagCloudLevels = 5
 
-	GetTagsByBookID(bookID int) ([]models.Tag, error)
-	AssignTagToBook(bookTag *models.BookTag) error
-	RemoveTagFromBook(bookID, tagID int) error
+func validateTagWeight(weight int) error {
+	if weight != models.TagWeightPrimary && weight != models.TagWeightSecondary {
+		return apperr.Validationf("invalid_tag_weight", "tag weight must be %d (primary) or %d (secondary)", models.TagWeightPrimary, models.TagWeightSecondary)
+	}
+	return nil
+}
+
+func validateTagColor(color string) error {
+	if color != "" && !models.IsHexColor(color) {
+		return errColorFormat
+	}
+	return nil
+}
+
+// tagVisibleTo — предложенный тег виден только автору и администраторам.
+func tagVisibleTo(tag models.Tag, userID int, userRole string) bool {
+	if tag.Status != models.TagStatusProposed || middleware.IsAdmin(userRole) {
+		return true
+	}
+	return tag.CreatedBy != nil && *tag.CreatedBy == userID
+}
+
+// checkTagVisible пускает к привязке только теги, видимые пользователю:
+// чужой предложенный тег выглядит так же, как несуществующий.
+func checkTagVisible(ctx context.Context, repo repository.TagRepository, tagID, userID int, userRole string) error {
+	tag, err := repo.GetTagByID(ctx, tagID)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && !tagVisibleTo(tag, userID, userRole) {
+		return repository.ErrTagNotFound
+	}
+	return err
 }
 
 type tagService struct {
-	tagRepo repository.TagRepository
+	tagRepo       repository.TagRepository
+	bookRepo      repository.BookRepository
+	audit         repository.AuditRepository
+	tx            repository.TxManager
+	notifications NotificationService
 }
 
-func NewTagService(tagRepo repository.TagRepository) TagService {
-	return &tagService{tagRepo: tagRepo}
+func NewTagService(tagRepo repository.TagRepository, bookRepo repository.BookRepository, audit repository.AuditRepository, tx repository.TxManager, notifications NotificationService) TagService {
+	return &tagService{tagRepo: tagRepo, bookRepo: bookRepo, audit: audit, tx: tx, notifications: notifications}
 }
 
-func (s *tagService) GetAllTags() ([]models.Tag, error) {
-	return s.tagRepo.GetAllTags()
+func (s *tagService) GetAllTags(ctx context.Context) ([]models.Tag, error) {
+	return s.tagRepo.GetAllTags(ctx)
 }
 
-func (s *tagService) GetTagByID(id int) (models.Tag, error) {
-	tag, err := s.tagRepo.GetTagByID(id)
+func (s *tagService) GetTagByID(ctx context.Context, id, userID int, userRole string) (models.Tag, error) {
+	tag, err := s.tagRepo.GetTagByID(ctx, id)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	if !tagVisibleTo(tag, userID, userRole) {
+		return models.Tag{}, repository.ErrTagNotFound
+	}
+	tag.Synonyms, err = s.tagRepo.GetSynonyms(ctx, id)
 	if err != nil {
 		return models.Tag{}, err
 	}
 	return tag, nil
 }
 
-func (s *tagService) CreateTag(tag *models.Tag) error {
+// CreateTag не создаёт дубликат: если название совпадает с тегом или его синонимом,
+// в tag возвращается существующий канонический тег и created = false.
+// Теги от не-админов создаются в статусе proposed и ждут модерации.
+func (s *tagService) CreateTag(ctx context.Context, tag *models.Tag, userID int, userRole string) (bool, error) {
+	tag.Name = strings.TrimSpace(tag.Name)
 	if tag.Name == "" {
-		return errors.New("tag name is required")
+		return false, apperr.Validation("tag_name_required", "tag name is required")
+	}
+	if err := validateTagColor(tag.Color); err != nil {
+		return false, err
+	}
+
+	existing, err := s.tagRepo.ResolveTag(ctx, tag.Name)
+	if err == nil {
+		if !tagVisibleTo(existing, userID, userRole) {
+			return false, ErrTagPending
+		}
+		*tag = existing
+		return false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, err
 	}
-	return s.tagRepo.CreateTag(tag)
+
+	isAdmin := middleware.IsAdmin(userRole)
+	if !isAdmin {
+		blocked, err := s.tagRepo.IsBlocked(ctx, tag.Name)
+		if err != nil {
+			return false, err
+		}
+		if blocked {
+			return false, ErrTagBlocked
+		}
+
+		recent, err := s.tagRepo.CountTagsCreatedSince(ctx, userID, time.Now().Add(-tagProposalWindow))
+		if err != nil {
+			return false, err
+		}
+		if recent >= tagProposalLimit {
+			return false, ErrTagRateLimited
+		}
+	}
+
+	tag.Status = models.TagStatusApproved
+	if !isAdmin {
+		tag.Status = models.TagStatusProposed
+	}
+	tag.CreatedBy = &userID
+	return true, s.tagRepo.CreateTag(ctx, tag)
 }
 
-func (s *tagService) UpdateTag(tag *models.Tag) error {
+func (s *tagService) UpdateTag(ctx context.Context, tag *models.Tag, actor models.Actor) error {
 	if tag.ID == 0 {
-		return errors.New("tag ID is required")
+		return errTagIDRequired
+	}
+	if err := validateTagColor(tag.Color); err != nil {
+		return err
 	}
-	return s.tagRepo.UpdateTag(tag)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.tagRepo.GetTagByID(ctx, tag.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.tagRepo.UpdateTag(ctx, tag); err != nil {
+			return err
+		}
+		after, err := s.tagRepo.GetTagByID(ctx, tag.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditTagUpdate, "tag", tag.ID, before, after)
+	})
 }
 
-func (s *tagService) DeleteTag(id int) error {
+func (s *tagService) DeleteTag(ctx context.Context, id int, actor models.Actor) error {
 	if id == 0 {
-		return errors.New("tag ID is required")
+		return errTagIDRequired
 	}
-	return s.tagRepo.DeleteTag(id)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		tag, err := s.tagRepo.GetTagByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.tagRepo.DeleteTag(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditTagDelete, "tag", id, tag, nil)
+	})
 }
 
-func (s *tagService) GetTagsByBookID(bookID int) ([]models.Tag, error) {
-	return s.tagRepo.GetTagsByBookID(bookID)
+func (s *tagService) GetTagsByBookID(ctx context.Context, bookID, userID int, userRole string) ([]models.Tag, error) {
+	return s.tagRepo.GetTagsByBookID(ctx, bookID, userID, middleware.IsAdmin(userRole))
 }
 
-func (s *tagService) AssignTagToBook(bt *models.BookTag) error {
-	if bt.BookID == 0 || bt.TagID == 0 {
-		return errors.New("book ID and tag ID are required")
+func (s *tagService) AssignTagToBook(ctx context.Context, bt *models.BookTag, userID int, userRole string) error {
+	var tag models.Tag
+	var err error
+	switch {
+	case bt.TagID != 0:
+		tag, err = s.tagRepo.GetTagByID(ctx, bt.TagID)
+	case bt.TagName != "":
+		tag, err = s.tagRepo.ResolveTag(ctx, bt.TagName)
+	default:
+		return errBookTagIDRequired
 	}
-	return s.tagRepo.AssignTagToBook(bt)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && !tagVisibleTo(tag, userID, userRole) {
+		return repository.ErrTagNotFound
+	}
+	if err != nil {
+		return err
+	}
+	bt.TagID = tag.ID
+
+	if bt.BookID == 0 {
+		return errBookTagIDRequired
+	}
+	if err := validateTagWeight(bt.Weight); err != nil {
+		return err
+	}
+	if err := checkBookOwner(ctx, s.bookRepo, bt.BookID, userID, userRole); err != nil {
+		return err
+	}
+	return s.tagRepo.AssignTagToBook(ctx, bt)
 }
 
-func (s *tagService) RemoveTagFromBook(bookID, tagID int) error {
+func (s *tagService) RemoveTagFromBook(ctx context.Context, bookID, tagID, userID int, userRole string) error {
 	if bookID == 0 || tagID == 0 {
-		return errors.New("book ID and tag ID are required")
+		return errBookTagIDRequired
+	}
+	if err := checkBookOwner(ctx, s.bookRepo, bookID, userID, userRole); err != nil {
+		return err
 	}
-	return s.tagRepo.RemoveTagFromBook(bookID, tagID)
+	return s.tagRepo.RemoveTagFromBook(ctx, bookID, tagID)
+}
+
+func (s *tagService) SearchTags(ctx context.Context, query string, userID int, userRole string, limit, offset int) ([]models.TagUsage, error) {
+	allProposed := middleware.IsAdmin(userRole)
+
+	var tags []models.TagUsage
+	var err error
+	if query == "" {
+		tags, err = s.tagRepo.GetTagsWithUsage(ctx, tagUsageStatuses, userID, allProposed, limit, offset)
+	} else {
+		tags, err = s.tagRepo.SearchTags(ctx, query, tagUsageStatuses, userID, allProposed, limit, offset)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tags == nil {
+		tags = []models.TagUsage{}
+	}
+	return tags, nil
+}
+
+func (s *tagService) AddSynonym(ctx context.Context, synonym *models.TagSynonym, actor models.Actor) error {
+	synonym.Name = strings.TrimSpace(synonym.Name)
+	if synonym.Name == "" {
+		return apperr.Validation("synonym_name_required", "synonym name is required")
+	}
+	if _, err := s.tagRepo.GetTagByID(ctx, synonym.TagID); err != nil {
+		return err
+	}
+
+	// Синоним не может совпадать с другим тегом или чужим синонимом — такие теги нужно слить
+	if _, err := s.tagRepo.ResolveTag(ctx, synonym.Name); err == nil {
+		return apperr.Conflict("tag_name_taken", "name is already used by a tag or synonym")
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.tagRepo.AddSynonym(ctx, synonym); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditTagSynonymAdd, "tag", synonym.TagID, nil, synonym)
+	})
+}
+
+func (s *tagService) RemoveSynonym(ctx context.Context, tagID, synonymID int, actor models.Actor) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		removed, err := s.tagRepo.RemoveSynonym(ctx, tagID, synonymID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditTagSynonymRemove, "tag", tagID, removed, nil)
+	})
+}
+
+func (s *tagService) MergeTags(ctx context.Context, targetID int, sourceIDs []int, actor models.Actor) (int, error) {
+	if len(sourceIDs) == 0 {
+		return 0, errSourceIDsRequired
+	}
+
+	seen := make(map[int]bool, len(sourceIDs))
+	unique := make([]int, 0, len(sourceIDs))
+	for _, id := range sourceIDs {
+		if id == targetID {
+			return 0, apperr.Validation("merge_into_itself", "cannot merge a tag into itself")
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	var books int
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		sources := make([]models.Tag, 0, len(unique))
+		for _, id := range unique {
+			tag, err := s.tagRepo.GetTagByID(ctx, id)
+			if err != nil {
+				return err
+			}
+			sources = append(sources, tag)
+		}
+		var err error
+		if books, err = s.tagRepo.MergeTags(ctx, targetID, unique); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditTagMerge, "tag", targetID,
+			map[string]interface{}{"sources": sources},
+			map[string]interface{}{"books_affected": books})
+	})
+	return books, err
+}
+
+// GetTagCloud возвращает облако тегов по опубликованным книгам; уровень 1–5
+// считается по логарифму популярности, чтобы редкие теги не терялись.
+func (s *tagService) GetTagCloud(ctx context.Context, categoryID *int, limit int) ([]models.TagCloudEntry, error) {
+	cloud, err := s.tagRepo.GetTagCloud(ctx, tagUsageStatuses, categoryID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(cloud) == 0 {
+		return []models.TagCloudEntry{}, nil
+	}
+
+	minScore, maxScore := cloud[0].Score, cloud[0].Score
+	for _, e := range cloud {
+		minScore = math.Min(minScore, e.Score)
+		maxScore = math.Max(maxScore, e.Score)
+	}
+	spread := math.Log(maxScore) - math.Log(minScore)
+	for i := range cloud {
+		cloud[i].Level = 1
+		if spread > 0 {
+			ratio := (math.Log(cloud[i].Score) - math.Log(minScore)) / spread
+			cloud[i].Level = 1 + int(math.Round(ratio*(tagCloudLevels-1)))
+		}
+	}
+	return cloud, nil
+}
+
+func (s *tagService) GetProposedTags(ctx context.Context, limit, offset int) ([]models.Tag, error) {
+	tags, err := s.tagRepo.GetProposedTags(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+	return tags, nil
+}
+
+// ApproveTag одобряет тег; уведомление автору пишется в той же транзакции,
+// так что решение и уведомление сохраняются вместе или не сохраняются вовсе.
+func (s *tagService) ApproveTag(ctx context.Context, id int, actor models.Actor) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		tag, err := s.tagRepo.GetTagByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.tagRepo.ApproveTag(ctx, id, actor.ID); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, s.audit, actor, models.AuditTagApprove, "tag", id,
+			map[string]interface{}{"status": tag.Status},
+			map[string]interface{}{"status": models.TagStatusApproved}); err != nil {
+			return err
+		}
+		if tag.CreatedBy == nil {
+			return nil
+		}
+		msg := fmt.Sprintf("Ваш тег «%s» одобрен", tag.Name)
+		return s.notifications.Notify(ctx, *tag.CreatedBy, models.NotificationTagApproved, msg, "tag", tag.ID)
+	})
+}
+
+// RejectTag удаляет предложенный тег (вместе со связями с книгами) и сообщает
+// автору причину. Тега больше нет, поэтому уведомление на него не ссылается.
+func (s *tagService) RejectTag(ctx context.Context, id int, reason string, actor models.Actor) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		tag, err := s.tagRepo.GetTagByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if tag.Status != models.TagStatusProposed {
+			return repository.ErrTagNotProposed
+		}
+		if err := s.tagRepo.DeleteTag(ctx, id); err != nil {
+			return err
+		}
+		reason = strings.TrimSpace(reason)
+		if err := recordAudit(ctx, s.audit, actor, models.AuditTagReject, "tag", id, tag,
+			map[string]interface{}{"reason": optionalString(reason)}); err != nil {
+			return err
+		}
+		if tag.CreatedBy == nil {
+			return nil
+		}
+		msg := fmt.Sprintf("Ваш тег «%s» отклонён", tag.Name)
+		if reason != "" {
+			msg += ": " + reason
+		}
+		return s.notifications.NotifyUser(ctx, *tag.CreatedBy, models.NotificationTagRejected, msg)
+	})
+}
+
+func (s *tagService) GetBlocklist(ctx context.Context) ([]models.TagBlock, error) {
+	blocks, err := s.tagRepo.GetBlocklist(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if blocks == nil {
+		blocks = []models.TagBlock{}
+	}
+	return blocks, nil
+}
+
+func (s *tagService) AddToBlocklist(ctx context.Context, block *models.TagBlock, actor models.Actor) error {
+	block.Pattern = strings.TrimSpace(block.Pattern)
+	if block.Pattern == "" {
+		return apperr.Validation("pattern_required", "pattern is required")
+	}
+	block.CreatedBy = &actor.ID
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.tagRepo.AddToBlocklist(ctx, block); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditTagBlockAdd, "tag_block", block.ID, nil, block)
+	})
+}
+
+func (s *tagService) RemoveFromBlocklist(ctx context.Context, id int, actor models.Actor) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		removed, err := s.tagRepo.RemoveFromBlocklist(ctx, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditTagBlockRemove, "tag_block", id, removed, nil)
+	})
 }
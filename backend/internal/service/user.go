@@ -2,41 +2,49 @@ package service
 
 import (
 	"context"
-	"fmt"
 	"golang.org/x/crypto/bcrypt"
+	"online_library/backend/internal/middleware"
 	"online_library/backend/internal/models"
+	"online_library/backend/internal/pkg/apperr"
 	"online_library/backend/internal/repository"
 )
 
+var errPasswordRequired = apperr.Validation("password_required", "password is required")
+
 type UserService interface {
 	GetAllUsers(ctx context.Context) ([]models.User, error)
-	CreateUser(ctx context.Context, input models.UserInput) (*models.User, error)
-	UpdateUser(ctx context.Context, id int, input models.UserInput) (*models.User, error)
-	UpdateUserByAdmin(ctx context.Context, id int, input models.AdminUserUpdateInput) (*models.User, error)
-	CheckEmailExists(email string) (bool, error)
-	SoftDeleteUser(ctx context.Context, id int) error
-	HardDeleteUser(ctx context.Context, id int) error
+	CreateUser(ctx context.Context, input models.UserInput, actor models.Actor) (*models.User, error)
+	UpdateUser(ctx context.Context, id int, input models.UserInput, actor models.Actor) (*models.User, error)
+	UpdateUserByAdmin(ctx context.Context, id int, input models.AdminUserUpdateInput, actor models.Actor) (*models.User, error)
+	CheckEmailExists(ctx context.Context, email string) (bool, error)
+	SoftDeleteUser(ctx context.Context, id int, actor models.Actor) error
+	HardDeleteUser(ctx context.Context, id int, actor models.Actor) error
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo  repository.UserRepository
+	audit repository.AuditRepository
+	tx    repository.TxManager
 }
 
-func NewUserService(r repository.UserRepository) UserService {
-	return &userService{repo: r}
+func NewUserService(r repository.UserRepository, audit repository.AuditRepository, tx repository.TxManager) UserService {
+	return &userService{repo: r, audit: audit, tx: tx}
 }
 
 func (s *userService) GetAllUsers(ctx context.Context) ([]models.User, error) {
 	return s.repo.GetAllActive(ctx)
 }
 
-func (s *userService) CreateUser(ctx context.Context, input models.UserInput) (*models.User, error) {
-	exists, err := s.repo.CheckEmailExists(input.Email)
+func (s *userService) CreateUser(ctx context.Context, input models.UserInput, actor models.Actor) (*models.User, error) {
+	if input.Password == "" {
+		return nil, errPasswordRequired
+	}
+	exists, err := s.repo.CheckEmailExists(ctx, input.Email)
 	if err != nil {
 		return nil, err
 	}
 	if exists {
-		return nil, fmt.Errorf("email already exists")
+		return nil, repository.ErrEmailTaken
 	}
 
 	hashed, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
@@ -44,26 +52,103 @@ func (s *userService) CreateUser(ctx context.Context, input models.UserInput) (*
 		return nil, err
 	}
 
-	return s.repo.SetNewUser(input.Email, input.Name, string(hashed), input.Bio)
+	var created *models.User
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.repo.SetNewUser(ctx, input.Email, input.Name, string(hashed), input.Bio); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditUserCreate, "user", created.ID, nil, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpdateUser меняет email, имя и био; правка чужого профиля админом пишется в журнал.
+func (s *userService) UpdateUser(ctx context.Context, id int, input models.UserInput, actor models.Actor) (*models.User, error) {
+	before, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated *models.User
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if updated, err = s.repo.UpdateUserByID(ctx, id, input); err != nil {
+			return err
+		}
+		if actor.ID == id {
+			return nil
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditUserProfile, "user", id, before, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *userService) UpdateUserByAdmin(ctx context.Context, id int, input models.AdminUserUpdateInput, actor models.Actor) (*models.User, error) {
+	before, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isKnownRole(input.Role) {
+		return nil, apperr.Validationf("unknown_role", "unknown role: %s", input.Role)
+	}
+	// Выдавать и отзывать права администратора может только суперадмин
+	touchesAdmin := middleware.IsAdmin(before.Role) || middleware.IsAdmin(input.Role)
+	if touchesAdmin && !middleware.IsSuperAdmin(actor.Role) {
+		return nil, apperr.Forbidden("superadmin_only", "permission denied: only superadmin can change admin roles")
+	}
+
+	// В журнал — строка, перечитанная в той же транзакции, а не запрос
+	var updated *models.User
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if updated, err = s.repo.AdminUpdateUser(ctx, id, input); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, models.AuditUserUpdate, "user", id, before, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
 }
 
-func (s *userService) UpdateUser(ctx context.Context, id int, input models.UserInput) (*models.User, error) {
-	return s.repo.UpdateUserByID(ctx, id, input)
+func isKnownRole(role string) bool {
+	switch role {
+	case models.RoleNewUser, models.RoleUser, models.RoleAdmin, models.RoleSuperAdmin:
+		return true
+	}
+	return false
 }
 
-func (s *userService) UpdateUserByAdmin(ctx context.Context, id int, input models.AdminUserUpdateInput) (*models.User, error) {
-	// возможно, тут стоит проверить права вызывающего (не в этом методе, а выше)
-	return s.repo.AdminUpdateUser(ctx, id, input)
+func (s *userService) CheckEmailExists(ctx context.Context, email string) (bool, error) {
+	return s.repo.CheckEmailExists(ctx, email)
 }
 
-func (s *userService) CheckEmailExists(email string) (bool, error) {
-	return s.repo.CheckEmailExists(email)
+func (s *userService) SoftDeleteUser(ctx context.Context, id int, actor models.Actor) error {
+	return s.deleteUser(ctx, id, actor, models.AuditUserSoftDelete, s.repo.SoftDeleteUserByID)
 }
 
-func (s *userService) SoftDeleteUser(ctx context.Context, id int) error {
-	return s.repo.SoftDeleteUserByID(ctx, id)
+func (s *userService) HardDeleteUser(ctx context.Context, id int, actor models.Actor) error {
+	return s.deleteUser(ctx, id, actor, models.AuditUserHardDelete, s.repo.HardDeleteUserByID)
 }
 
-func (s *userService) HardDeleteUser(ctx context.Context, id int) error {
-	return s.repo.HardDeleteUserByID(ctx, id)
+func (s *userService) deleteUser(ctx context.Context, id int, actor models.Actor, action string,
+	del func(ctx context.Context, id int) error) error {
+	before, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := del(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, actor, action, "user", id, before, nil)
+	})
 }
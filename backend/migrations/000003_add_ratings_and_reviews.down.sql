@@ -0,0 +1,6 @@
+ALTER TABLE books DROP COLUMN IF EXISTS rating_count;
+ALTER TABLE books DROP COLUMN IF EXISTS rating_avg;
+ALTER TABLE books ADD COLUMN rating INT DEFAULT 0;
+
+DROP TABLE IF EXISTS book_reviews;
+DROP TABLE IF EXISTS book_ratings;
@@ -0,0 +1,30 @@
+-- Оценки книг пользователями (1–5 звёзд, одна оценка на пользователя)
+CREATE TABLE IF NOT EXISTS book_ratings (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    book_id INT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    score SMALLINT NOT NULL CHECK (score BETWEEN 1 AND 5),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (user_id, book_id)
+    );
+
+CREATE INDEX idx_book_ratings_book_id ON book_ratings(book_id);
+
+-- Рецензии (одна на пользователя и книгу, оценка берётся из book_ratings)
+CREATE TABLE IF NOT EXISTS book_reviews (
+    id SERIAL PRIMARY KEY,
+    book_id INT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    text TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (book_id, user_id)
+    );
+
+CREATE INDEX idx_book_reviews_book_id ON book_reviews(book_id);
+
+-- Агрегаты рейтинга вместо редактируемого поля rating
+ALTER TABLE books DROP COLUMN IF EXISTS rating;
+ALTER TABLE books ADD COLUMN rating_avg NUMERIC(3, 2) NOT NULL DEFAULT 0;
+ALTER TABLE books ADD COLUMN rating_count INT NOT NULL DEFAULT 0;
@@ -0,0 +1,25 @@
+-- Полки пользователей: статусы чтения и пользовательские подборки
+CREATE TABLE IF NOT EXISTS shelves (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'custom'
+        CHECK (kind IN ('want_to_read', 'reading', 'finished', 'abandoned', 'custom')),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (user_id, name)
+    );
+
+-- У каждого пользователя не больше одной полки каждого статуса чтения
+CREATE UNIQUE INDEX idx_shelves_user_kind ON shelves(user_id, kind) WHERE kind <> 'custom';
+
+-- Книги на полках
+CREATE TABLE IF NOT EXISTS shelf_books (
+    shelf_id INT NOT NULL REFERENCES shelves(id) ON DELETE CASCADE,
+    book_id INT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    added_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (shelf_id, book_id)
+    );
+
+CREATE INDEX idx_shelf_books_book_id ON shelf_books(book_id);
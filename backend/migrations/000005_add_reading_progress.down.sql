@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN IF EXISTS sync_key_hash;
+
+DROP TABLE IF EXISTS reading_progress_history;
+DROP TABLE IF EXISTS reading_progress;
@@ -0,0 +1,34 @@
+-- Текущая позиция чтения (одна запись на пользователя и документ, побеждает последняя запись)
+CREATE TABLE IF NOT EXISTS reading_progress (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    book_file_id INT REFERENCES book_files(id) ON DELETE SET NULL,
+    document VARCHAR(128) NOT NULL, -- хеш файла (book_files.hash) или digest документа KOReader
+    locator TEXT NOT NULL,          -- EPUB CFI, xpointer KOReader, номер страницы PDF
+    locator_type VARCHAR(20) NOT NULL DEFAULT 'percentage'
+        CHECK (locator_type IN ('cfi', 'xpointer', 'page', 'percentage')),
+    percentage NUMERIC(6, 5) NOT NULL DEFAULT 0 CHECK (percentage BETWEEN 0 AND 1),
+    device VARCHAR(100),
+    device_id VARCHAR(100),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, document)
+    );
+
+-- История синхронизаций
+CREATE TABLE IF NOT EXISTS reading_progress_history (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    book_file_id INT REFERENCES book_files(id) ON DELETE SET NULL,
+    document VARCHAR(128) NOT NULL,
+    locator TEXT NOT NULL,
+    locator_type VARCHAR(20) NOT NULL,
+    percentage NUMERIC(6, 5) NOT NULL,
+    device VARCHAR(100),
+    device_id VARCHAR(100),
+    recorded_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX idx_reading_progress_history_user_document ON reading_progress_history(user_id, document, recorded_at DESC);
+
+-- Отдельный ключ синхронизации для e-reader клиентов (KOReader передаёт md5 пароля)
+ALTER TABLE users ADD COLUMN sync_key_hash VARCHAR(255);
@@ -0,0 +1,22 @@
+-- Личные пометки читателей: выделения, закладки и заметки
+CREATE TABLE IF NOT EXISTS annotations (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    book_id INT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    book_file_id INT REFERENCES book_files(id) ON DELETE SET NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'highlight'
+        CHECK (kind IN ('highlight', 'bookmark', 'note')),
+    locator TEXT NOT NULL,
+    locator_type VARCHAR(20) NOT NULL DEFAULT 'cfi'
+        CHECK (locator_type IN ('cfi', 'xpointer', 'page', 'percentage')),
+    excerpt TEXT,          -- выделенный фрагмент текста
+    color VARCHAR(7),      -- hex-код, напр. "#FFEB3B"
+    note TEXT,
+    is_public BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- статусы модерации комментариев, важны для публичных пометок
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+    );
+
+CREATE INDEX idx_annotations_user_book ON annotations(user_id, book_id);
+CREATE INDEX idx_annotations_public_book ON annotations(book_id) WHERE is_public;
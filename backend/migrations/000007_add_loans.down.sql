@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS loan_holds;
+DROP TABLE IF EXISTS loans;
+
+ALTER TABLE books DROP COLUMN IF EXISTS lending_copies;
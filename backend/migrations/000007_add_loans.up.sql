@@ -0,0 +1,34 @@
+-- Количество лицензионных копий для выдачи (NULL — книга в открытом доступе)
+ALTER TABLE books ADD COLUMN lending_copies INT CHECK (lending_copies >= 0);
+
+-- Выдачи
+CREATE TABLE IF NOT EXISTS loans (
+    id SERIAL PRIMARY KEY,
+    book_id INT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'returned', 'expired')),
+    checked_out_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    due_at TIMESTAMP NOT NULL,
+    returned_at TIMESTAMP,
+    renewals INT NOT NULL DEFAULT 0
+    );
+
+-- Не больше одной активной выдачи книги на пользователя
+CREATE UNIQUE INDEX idx_loans_active_book_user ON loans(book_id, user_id) WHERE status = 'active';
+CREATE INDEX idx_loans_active_due ON loans(due_at) WHERE status = 'active';
+CREATE INDEX idx_loans_user_id ON loans(user_id);
+
+-- Очередь ожидания
+CREATE TABLE IF NOT EXISTS loan_holds (
+    id SERIAL PRIMARY KEY,
+    book_id INT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'waiting'
+        CHECK (status IN ('waiting', 'fulfilled', 'cancelled')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    fulfilled_at TIMESTAMP
+    );
+
+CREATE UNIQUE INDEX idx_loan_holds_waiting_book_user ON loan_holds(book_id, user_id) WHERE status = 'waiting';
+CREATE INDEX idx_loan_holds_queue ON loan_holds(book_id, created_at) WHERE status = 'waiting';
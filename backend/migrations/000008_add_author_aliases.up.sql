@@ -0,0 +1,16 @@
+-- Поля профиля автора, которые уже используются в коде
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS bio TEXT;
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS photo_url VARCHAR(512);
+
+-- Псевдонимы и альтернативные написания ("Лев Толстой" / "L. N. Tolstoy" / "Leo Tolstoy")
+CREATE TABLE IF NOT EXISTS author_aliases (
+    id SERIAL PRIMARY KEY,
+    author_id INT NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'spelling'
+        CHECK (kind IN ('pseudonym', 'spelling', 'translit')),
+    created_at TIMESTAMP DEFAULT NOW()
+    );
+
+CREATE UNIQUE INDEX idx_author_aliases_author_name ON author_aliases(author_id, LOWER(name));
+CREATE INDEX idx_author_aliases_name ON author_aliases(LOWER(name));
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS author_merges;
+
+ALTER TABLE book_authors DROP CONSTRAINT IF EXISTS book_authors_author_id_fkey;
+ALTER TABLE book_authors
+    ADD CONSTRAINT book_authors_author_id_fkey
+        FOREIGN KEY (author_id) REFERENCES authors(id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_authors_name_en_trgm;
+DROP INDEX IF EXISTS idx_authors_name_ru_trgm;
@@ -0,0 +1,25 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Поиск похожих имён для отчёта о возможных дублях
+CREATE INDEX IF NOT EXISTS idx_authors_name_ru_trgm ON authors USING GIN (name_ru gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_authors_name_en_trgm ON authors USING GIN (name_en gin_trgm_ops);
+
+-- Автора с книгами нельзя удалить молча: связи переносятся слиянием
+ALTER TABLE book_authors DROP CONSTRAINT IF EXISTS book_authors_author_id_fkey;
+ALTER TABLE book_authors
+    ADD CONSTRAINT book_authors_author_id_fkey
+        FOREIGN KEY (author_id) REFERENCES authors(id) ON DELETE RESTRICT;
+
+-- Журнал слияний авторов
+CREATE TABLE IF NOT EXISTS author_merges (
+    id SERIAL PRIMARY KEY,
+    target_author_id INT REFERENCES authors(id) ON DELETE SET NULL,
+    source_author_id INT NOT NULL, -- автор удалён, храним только id и имена
+    source_name_ru VARCHAR(255) NOT NULL,
+    source_name_en VARCHAR(255) NOT NULL,
+    books_moved INT NOT NULL DEFAULT 0,
+    merged_by INT REFERENCES users(id) ON DELETE SET NULL,
+    merged_at TIMESTAMP DEFAULT NOW()
+    );
+
+CREATE INDEX idx_author_merges_target ON author_merges(target_author_id);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_book_authors_author_role;
+
+ALTER TABLE book_authors DROP CONSTRAINT IF EXISTS book_authors_pkey;
+-- Оставляем по одной строке на пару книга–автор
+DELETE FROM book_authors a
+    USING book_authors b
+WHERE a.book_id = b.book_id AND a.author_id = b.author_id AND a.role > b.role;
+ALTER TABLE book_authors ADD PRIMARY KEY (book_id, author_id);
+
+ALTER TABLE book_authors DROP COLUMN IF EXISTS position;
+ALTER TABLE book_authors DROP COLUMN IF EXISTS role;
@@ -0,0 +1,13 @@
+-- Роль участника (автор, переводчик, редактор, иллюстратор) и порядок на обложке
+ALTER TABLE book_authors
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'author'
+        CHECK (role IN ('author', 'translator', 'editor', 'illustrator')),
+    ADD COLUMN position INT NOT NULL DEFAULT 0;
+
+-- Один человек может быть, например, и автором, и иллюстратором книги
+ALTER TABLE book_authors DROP CONSTRAINT IF EXISTS book_authors_pkey;
+ALTER TABLE book_authors ALTER COLUMN book_id SET NOT NULL;
+ALTER TABLE book_authors ALTER COLUMN author_id SET NOT NULL;
+ALTER TABLE book_authors ADD PRIMARY KEY (book_id, author_id, role);
+
+CREATE INDEX idx_book_authors_author_role ON book_authors(author_id, role);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tags_name_trgm;
+DROP INDEX IF EXISTS idx_tags_name_lower;
+DROP TABLE IF EXISTS tag_synonyms;
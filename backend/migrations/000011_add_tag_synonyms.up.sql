@@ -0,0 +1,15 @@
+-- Синонимы тегов: "scifi", "фантастика" → канонический "sci-fi"
+CREATE TABLE IF NOT EXISTS tag_synonyms (
+    id SERIAL PRIMARY KEY,
+    tag_id INT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+    );
+
+CREATE UNIQUE INDEX idx_tag_synonyms_name ON tag_synonyms(LOWER(name));
+CREATE INDEX idx_tag_synonyms_tag ON tag_synonyms(tag_id);
+
+-- Автодополнение: префикс по LOWER(name) и похожесть через pg_trgm
+CREATE INDEX IF NOT EXISTS idx_tags_name_lower ON tags(LOWER(name) varchar_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_tags_name_trgm ON tags USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_tag_synonyms_name_trgm ON tag_synonyms USING GIN (name gin_trgm_ops);
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS tag_blocklist;
+
+DROP INDEX IF EXISTS idx_tags_created_by;
+DROP INDEX IF EXISTS idx_tags_status;
+ALTER TABLE tags
+    DROP COLUMN IF EXISTS reviewed_at,
+    DROP COLUMN IF EXISTS reviewed_by,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS created_by,
+    DROP COLUMN IF EXISTS status;
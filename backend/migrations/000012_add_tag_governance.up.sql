@@ -0,0 +1,35 @@
+-- Теги, предложенные пользователями, ждут одобрения администратора
+ALTER TABLE tags
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'approved'
+        CHECK (status IN ('approved', 'proposed')),
+    ADD COLUMN created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN created_at TIMESTAMP DEFAULT NOW(),
+    ADD COLUMN reviewed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN reviewed_at TIMESTAMP;
+
+CREATE INDEX idx_tags_status ON tags(status);
+CREATE INDEX idx_tags_created_by ON tags(created_by, created_at);
+
+-- Запрещённые названия тегов (проверяется вхождение без учёта регистра)
+CREATE TABLE IF NOT EXISTS tag_blocklist (
+    id SERIAL PRIMARY KEY,
+    pattern VARCHAR(100) NOT NULL,
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+    );
+
+CREATE UNIQUE INDEX idx_tag_blocklist_pattern ON tag_blocklist(LOWER(pattern));
+
+-- Уведомления пользователям внутри библиотеки
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL,
+    entity_type VARCHAR(50),
+    entity_id INT,
+    is_read BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT NOW()
+    );
+
+CREATE INDEX idx_notifications_user ON notifications(user_id, is_read, created_at DESC);
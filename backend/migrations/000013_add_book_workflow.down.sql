@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS book_status_history;
+
+DROP INDEX IF EXISTS idx_books_status;
+ALTER TABLE books DROP CONSTRAINT IF EXISTS books_status_check;
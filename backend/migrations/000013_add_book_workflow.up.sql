@@ -0,0 +1,24 @@
+-- Статусы книг приводятся к фиксированному набору: старое 'adult' становится 'private',
+-- неизвестные значения возвращаются на модерацию
+UPDATE books SET status = 'private' WHERE status = 'adult';
+UPDATE books SET status = 'quarantine'
+WHERE status NOT IN ('draft', 'quarantine', 'visible', 'rejected', 'archived', 'private');
+
+ALTER TABLE books
+    ADD CONSTRAINT books_status_check
+        CHECK (status IN ('draft', 'quarantine', 'visible', 'rejected', 'archived', 'private'));
+
+CREATE INDEX IF NOT EXISTS idx_books_status ON books(status, created_at);
+
+-- История смены статусов книги: кто, когда и почему
+CREATE TABLE IF NOT EXISTS book_status_history (
+    id SERIAL PRIMARY KEY,
+    book_id INT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    reason TEXT,
+    changed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX idx_book_status_history_book ON book_status_history(book_id, changed_at DESC);
@@ -0,0 +1,18 @@
+-- Предложенные правки книги от пользователей, которые не могут редактировать её сами
+CREATE TABLE IF NOT EXISTS book_edit_proposals (
+    id SERIAL PRIMARY KEY,
+    book_id INT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    proposed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    changes JSONB NOT NULL, -- только изменённые поля, authors и tags — списком целиком
+    comment TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'accepted', 'rejected')),
+    reviewed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    review_comment TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX idx_book_edit_proposals_book ON book_edit_proposals(book_id, status);
+CREATE INDEX idx_book_edit_proposals_pending ON book_edit_proposals(created_at) WHERE status = 'pending';
+CREATE INDEX idx_book_edit_proposals_user ON book_edit_proposals(proposed_by, created_at DESC);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS book_revisions;
+
+ALTER TABLE books DROP COLUMN IF EXISTS version;
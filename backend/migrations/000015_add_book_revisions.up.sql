@@ -0,0 +1,25 @@
+-- Версия книги для оптимистичной блокировки (ETag / If-Match)
+ALTER TABLE books ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- Ревизии: что изменилось и снимок редактируемых полей после изменения
+CREATE TABLE IF NOT EXISTS book_revisions (
+    id SERIAL PRIMARY KEY,
+    book_id INT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    changed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    changes JSONB NOT NULL DEFAULT '[]',
+    note TEXT,
+    snapshot JSONB NOT NULL
+    );
+
+CREATE UNIQUE INDEX idx_book_revisions_version ON book_revisions(book_id, version);
+
+-- Для существующих книг текущее состояние становится первой ревизией
+INSERT INTO book_revisions (book_id, version, changed_by, changed_at, note, snapshot)
+SELECT id, version, created_by, COALESCE(created_at, NOW()), 'исходная версия',
+       jsonb_strip_nulls(jsonb_build_object(
+           'title', title, 'description', description, 'publish_year', publish_year,
+           'pages', pages, 'language', language, 'publisher', publisher,
+           'type', type, 'cover_url', cover_url))
+FROM books;
@@ -0,0 +1,45 @@
+// Package migrations встраивает SQL-миграции в бинарник, чтобы код знал,
+// под какую версию схемы он написан.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Latest — номер последней миграции: наибольший префикс среди *.up.sql.
+var Latest = latest()
+
+func latest() int {
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		panic(err)
+	}
+	highest := 0
+	for _, name := range names {
+		version, err := Version(name)
+		if err != nil {
+			panic(err)
+		}
+		if version > highest {
+			highest = version
+		}
+	}
+	return highest
+}
+
+// Version — номер миграции из имени файла вида 000016_add_audit_log.up.sql.
+func Version(name string) (int, error) {
+	prefix, _, ok := strings.Cut(name, "_")
+	version, err := strconv.Atoi(prefix)
+	if !ok || err != nil || version <= 0 {
+		return 0, fmt.Errorf("migrations: unexpected file name %q", name)
+	}
+	return version, nil
+}
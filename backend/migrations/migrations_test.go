@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestMigrationsArePaired(t *testing.T) {
+	ups, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ups) != Latest {
+		t.Errorf("%d up migrations, latest version %d: versions must be consecutive", len(ups), Latest)
+	}
+	for _, up := range ups {
+		down := strings.TrimSuffix(up, ".up.sql") + ".down.sql"
+		if _, err := fs.Stat(files, down); err != nil {
+			t.Errorf("%s has no %s", up, down)
+		}
+	}
+}